@@ -0,0 +1,120 @@
+package skiptake
+
+// Accumulators of set operations over lists which arrive one at a time.
+
+// accumulatorFanIn is the most lists a UnionAccumulator buffers before
+// merging them.
+const accumulatorFanIn = 64
+
+// UnionAccumulator maintains the union of lists added one at a time. Added
+// lists are buffered, and merged together with the union so far in one k-way
+// merge, once they hold as many bytes as it, or there are accumulatorFanIn of
+// them. Each merge at least doubles the size of what is merged, so the union
+// is rewritten only a logarithmic number of times. Eg:
+//
+//		var acc skiptake.UnionAccumulator
+//		for l := range lists {
+//			acc.Add(l)
+//		}
+//		result := acc.Result()
+//
+// The zero UnionAccumulator is empty, and ready to use.
+type UnionAccumulator struct {
+	union   List
+	pending []List
+	size    int // Bytes in pending
+}
+
+// Add adds the list l to the union. The list is held until merged, so must
+// not be modified until the following call to Result().
+func (a *UnionAccumulator) Add(l List) {
+	if len(l) == 0 {
+		return
+	}
+	a.pending = append(a.pending, l)
+	if a.size += len(l); a.size >= len(a.union) || len(a.pending) >= accumulatorFanIn {
+		a.merge()
+	}
+}
+
+// Result returns the union of every list added. The returned list is not
+// modified by later calls to Add.
+func (a *UnionAccumulator) Result() List {
+	if len(a.pending) > 0 {
+		a.merge()
+	}
+	if a.union == nil {
+		return List{}
+	}
+	return a.union
+}
+
+// Reset empties the accumulator.
+func (a *UnionAccumulator) Reset() {
+	*a = UnionAccumulator{}
+}
+
+// merge merges the buffered lists into the union.
+func (a *UnionAccumulator) merge() {
+	if len(a.union) > 0 {
+		a.pending = append(a.pending, a.union)
+	}
+	a.union = Union(a.pending...)
+	for i := range a.pending {
+		a.pending[i] = nil
+	}
+	a.pending = a.pending[:0]
+	a.size = 0
+}
+
+// IntersectionAccumulator maintains the intersection of lists added one at a
+// time. Each added list narrows the intersection so far, in one merge of the
+// two which stops once either is exhausted, so an added list is decoded only
+// as far as the greatest member of the intersection. Once the intersection is
+// empty, further additions are ignored. Eg:
+//
+//		var acc skiptake.IntersectionAccumulator
+//		for filter := range filters {
+//			if acc.Add(filter); acc.Empty() {
+//				break
+//			}
+//		}
+//		result := acc.Result()
+//
+// The zero IntersectionAccumulator holds no lists. Its result is empty, and
+// the first list added becomes the intersection.
+type IntersectionAccumulator struct {
+	intersection List
+	added        bool
+}
+
+// Add narrows the intersection to the members of l.
+func (a *IntersectionAccumulator) Add(l List) {
+	switch {
+	case !a.added:
+		a.intersection = append(List{}, l...)
+		a.added = true
+	case !a.intersection.IsEmpty():
+		a.intersection = Intersection2(a.intersection, l)
+	}
+}
+
+// Empty returns true if lists have been added, and their intersection is
+// empty, so that no further addition can change the result.
+func (a *IntersectionAccumulator) Empty() bool {
+	return a.added && a.intersection.IsEmpty()
+}
+
+// Result returns the intersection of every list added, or an empty list if
+// none have been. The returned list is not modified by later calls to Add.
+func (a *IntersectionAccumulator) Result() List {
+	if a.intersection == nil {
+		return List{}
+	}
+	return a.intersection
+}
+
+// Reset empties the accumulator.
+func (a *IntersectionAccumulator) Reset() {
+	*a = IntersectionAccumulator{}
+}
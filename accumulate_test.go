@@ -0,0 +1,90 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_UnionAccumulator(t *testing.T) {
+	var acc UnionAccumulator
+	if r := acc.Result(); r == nil || r.Len() != 0 {
+		t.Fatalf("expected empty result, got %v", r)
+	}
+
+	lists := spillTestLists(200)
+	for i, l := range lists {
+		acc.Add(l)
+		acc.Add(List{})
+		if i%50 == 49 {
+			if r, expected := acc.Result(), Union(lists[:i+1]...); !equalUint64(r.Expand(), expected.Expand()) {
+				t.Fatalf("union of %d lists differs", i+1)
+			}
+		}
+	}
+	r := acc.Result()
+	if !equalUint64(r.Expand(), Union(lists...).Expand()) {
+		t.Fatal("union differs")
+	}
+
+	// The result is not changed by later additions.
+	before := append(List{}, r...)
+	acc.Add(makeRange(intrv{0, 1 << 30}))
+	if string(r) != string(before) {
+		t.Fatal("result modified by Add")
+	}
+	if expected := makeRange(intrv{0, 1 << 30}); string(acc.Result()) != string(expected) {
+		t.Fatal("union with covering range differs")
+	}
+
+	acc.Reset()
+	acc.Add(Create(3, 5, 7))
+	if !equalUint64(acc.Result().Expand(), []uint64{3, 5, 7}) {
+		t.Fatal("union after reset differs")
+	}
+}
+
+func Test_IntersectionAccumulator(t *testing.T) {
+	var acc IntersectionAccumulator
+	if acc.Empty() || acc.Result().Len() != 0 {
+		t.Fatal("expected no lists to be neither empty nor have members")
+	}
+
+	a := makeRange(intrv{0, 100}, intrv{200, 300})
+	acc.Add(a)
+	a[0] = 50 // The first list is copied.
+	acc.Add(makeRange(intrv{50, 250}))
+	acc.Add(makeRange(intrv{90, 210}))
+	expected := makeRange(intrv{90, 100}, intrv{200, 210})
+	r := acc.Result()
+	if !equalUint64(r.Expand(), expected.Expand()) || acc.Empty() {
+		t.Fatalf("expected %v, got %v", expected.Expand(), r.Expand())
+	}
+
+	acc.Add(makeRange(intrv{150, 160}))
+	if !acc.Empty() || acc.Result().Len() != 0 {
+		t.Fatal("expected empty intersection")
+	}
+	acc.Add(makeRange(intrv{0, 1000}))
+	if !acc.Empty() {
+		t.Fatal("expected intersection to stay empty")
+	}
+	if !equalUint64(r.Expand(), expected.Expand()) {
+		t.Fatal("result modified by Add")
+	}
+
+	acc.Reset()
+	acc.Add(Create(3, 5, 7))
+	if !equalUint64(acc.Result().Expand(), []uint64{3, 5, 7}) {
+		t.Fatal("intersection after reset differs")
+	}
+
+	// Agrees with Intersection of the lists at once.
+	lists := spillTestLists(4)
+	lists = append(lists, Union(lists[0], lists[1]), Union(lists[0], lists[2]))
+	acc.Reset()
+	for _, l := range lists[4:] {
+		acc.Add(l)
+	}
+	if !equalUint64(acc.Result().Expand(), Intersection(lists[4:]...).Expand()) {
+		t.Fatal("intersection differs")
+	}
+}
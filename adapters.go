@@ -0,0 +1,255 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// Adapters which transform a source of intervals lazily, so they can be used as
+// inputs to set operations and Builders without materializing a List. Eg:
+//
+//		iter := list.Iterate()
+//		even := FilterValues(&iter, func(v uint64) bool { return v%2 == 0 })
+//		result := IntersectionOf(even, &other)
+//
+
+// Filter yields the intervals of a source for which a predicate returns true.
+type Filter struct {
+	src  Intervals
+	keep func(first, last uint64) bool
+}
+
+// NewFilter returns a Filter over src, yielding only the intervals for which
+// keep returns true. keep is passed the bounds of each whole interval, so runs
+// of values can be accepted or rejected without expanding them.
+func NewFilter(src Intervals, keep func(first, last uint64) bool) *Filter {
+	return &Filter{src: src, keep: keep}
+}
+
+// NextInterval returns the next interval of the source accepted by the
+// predicate. Returns (math.MaxUint64, 0) in the case of end of stream.
+func (f *Filter) NextInterval() (first, last uint64) {
+	for first, last = f.src.NextInterval(); first <= last; first, last = f.src.NextInterval() {
+		if f.keep(first, last) {
+			return
+		}
+	}
+	return math.MaxUint64, 0
+}
+
+// ValueFilter yields the values of a source for which a predicate returns true,
+// as intervals.
+type ValueFilter struct {
+	src         Intervals
+	keep        func(v uint64) bool
+	first, last uint64 // Remainder of the current source interval
+	ok          bool   // If there is a remainder
+}
+
+// FilterValues returns a ValueFilter over src, yielding only the values for
+// which keep returns true. Unlike NewFilter, every value of the source is
+// passed to keep, so this should be reserved for predicates which cannot be
+// decided on whole intervals.
+func FilterValues(src Intervals, keep func(v uint64) bool) *ValueFilter {
+	return &ValueFilter{src: src, keep: keep}
+}
+
+// NextInterval returns the next run of consecutive values accepted by the
+// predicate. Returns (math.MaxUint64, 0) in the case of end of stream.
+func (f *ValueFilter) NextInterval() (first, last uint64) {
+	for {
+		if !f.ok {
+			if f.first, f.last = f.src.NextInterval(); f.first > f.last {
+				return math.MaxUint64, 0
+			}
+			f.ok = true
+		}
+		// Skip rejected values
+		for !f.keep(f.first) {
+			if f.first == f.last {
+				f.ok = false
+				break
+			}
+			f.first++
+		}
+		if !f.ok {
+			continue
+		}
+		// Extend the run over accepted values. The value ending the run has
+		// been rejected, so the remainder starts after it.
+		for first, last = f.first, f.first; last != f.last; last++ {
+			if !f.keep(last + 1) {
+				if last+1 == f.last {
+					f.ok = false
+				} else {
+					f.first = last + 2
+				}
+				return
+			}
+		}
+		f.ok = false
+		return
+	}
+}
+
+// Map yields the values of a source transformed by a strictly increasing
+// function, as intervals.
+type Map struct {
+	src         Intervals
+	fn          func(v uint64) uint64
+	offset      uint64 // If fn is nil, the amount added to each value
+	first, last uint64 // Remainder of the current source interval
+	image       uint64 // fn(first)
+	ok          bool   // If there is a remainder
+}
+
+// NewMap returns a Map over src, yielding fn(v) for each value v of the
+// source. fn must be strictly increasing, that is fn(a) < fn(b) whenever
+// a < b, so that the values yielded remain in ascending order. fn is called
+// once for every value of the source, and consecutive results are joined into
+// intervals.
+func NewMap(src Intervals, fn func(v uint64) uint64) *Map {
+	return &Map{src: src, fn: fn}
+}
+
+// NewAffineMap returns a Map over src, yielding v*stride + base for each value
+// v of the source, such as to re-map local IDs into a global ID space. stride
+// must be at least one, and the results must not overflow. A stride of one
+// shifts whole intervals without visiting their values.
+func NewAffineMap(src Intervals, stride, base uint64) *Map {
+	if stride == 1 {
+		return &Map{src: src, offset: base}
+	}
+	return NewMap(src, func(v uint64) uint64 { return v*stride + base })
+}
+
+// NextInterval returns the next interval of transformed values. Returns
+// (math.MaxUint64, 0) in the case of end of stream.
+func (m *Map) NextInterval() (first, last uint64) {
+	if m.fn == nil {
+		if first, last = m.src.NextInterval(); first > last {
+			return
+		}
+		return first + m.offset, last + m.offset
+	}
+	if !m.ok {
+		if m.first, m.last = m.src.NextInterval(); m.first > m.last {
+			return math.MaxUint64, 0
+		}
+		m.ok = true
+		m.image = m.fn(m.first)
+	}
+	// As fn is strictly increasing, the images of values either side of a gap
+	// in the source can never abut, so only values within an interval can be
+	// joined.
+	first, last = m.image, m.image
+	for m.first != m.last {
+		m.first++
+		m.image = m.fn(m.first)
+		if m.image != last+1 {
+			return
+		}
+		last = m.image
+	}
+	m.ok = false
+	return
+}
+
+// Chunks splits the intervals of a source into chunks of at most a maximum
+// number of values, such as for dividing a list into fixed-size work items.
+// Eg:
+//
+//		iter := list.Iterate()
+//		c := NewChunks(&iter, 1000)
+//		for first, last := c.NextChunk(); first <= last; first, last = c.NextChunk() {
+//			queue = append(queue, work{first, last})
+//		}
+//
+// Chunks split from one interval abut each other, so unlike intervals they are
+// yielded by NextChunk rather than NextInterval, and a Chunks is not a source
+// of Intervals.
+type Chunks struct {
+	src         Intervals
+	max         uint64
+	first, last uint64 // Remainder of the current source interval
+	ok          bool   // If there is a remainder
+}
+
+// NewChunks returns a Chunks over src, yielding chunks of at most max values.
+// A max of zero does not split intervals.
+func NewChunks(src Intervals, max uint64) *Chunks {
+	return &Chunks{src: src, max: max}
+}
+
+// NextChunk returns the next chunk of consecutive values, inclusive. Returns
+// (math.MaxUint64, 0) in the case of end of stream.
+func (c *Chunks) NextChunk() (first, last uint64) {
+	if !c.ok {
+		c.first, c.last = c.src.NextInterval()
+		if c.first > c.last {
+			return math.MaxUint64, 0
+		}
+		c.ok = true
+	}
+	first, last = c.first, c.last
+	if c.max != 0 && last-first >= c.max {
+		last = first + c.max - 1
+		c.first = last + 1
+		return
+	}
+	c.ok = false
+	return
+}
+
+// GapIterator yields the gaps strictly between the intervals of a source, that
+// is the runs of non-members lying between its first and last members. As
+// gaps neither overlap nor abut, a GapIterator is itself a source of
+// Intervals.
+type GapIterator struct {
+	src     Intervals
+	prev    uint64 // Last value of the previous interval
+	started bool
+}
+
+// NewGapIterator returns a GapIterator over the gaps of src.
+func NewGapIterator(src Intervals) *GapIterator {
+	return &GapIterator{src: src}
+}
+
+// Gaps returns a GapIterator over the gaps between the intervals of the list.
+// Eg:
+//
+//		g := list.Gaps()
+//		for first, last, n := g.NextGap(); n > 0; first, last, n = g.NextGap() {
+//			...
+//		}
+//
+func (l List) Gaps() *GapIterator {
+	iter := l.Iterate()
+	return NewGapIterator(&iter)
+}
+
+// NextGap returns the next gap, inclusive, along with its length. Returns
+// (math.MaxUint64, 0, 0) in the case of end of stream.
+func (g *GapIterator) NextGap() (first, last, n uint64) {
+	if !g.started {
+		g.started = true
+		var f uint64
+		if f, g.prev = g.src.NextInterval(); f > g.prev {
+			return math.MaxUint64, 0, 0
+		}
+	}
+	f, l := g.src.NextInterval()
+	if f > l {
+		return math.MaxUint64, 0, 0
+	}
+	first, last = g.prev+1, f-1
+	g.prev = l
+	return first, last, last - first + 1
+}
+
+// NextInterval returns the next gap, inclusive. Returns (math.MaxUint64, 0) in
+// the case of end of stream.
+func (g *GapIterator) NextInterval() (first, last uint64) {
+	first, last, _ = g.NextGap()
+	return
+}
@@ -0,0 +1,189 @@
+package skiptake
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func Test_Filter(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{20, 20}, intrv{30, 39}, intrv{100, 199})
+	iter := list.Iterate()
+	result := UnionOf(NewFilter(&iter, func(first, last uint64) bool {
+		return last-first >= 9
+	}))
+	if expected := makeRange(intrv{0, 9}, intrv{30, 39}, intrv{100, 199}); !Equal(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+
+	iter.Reset()
+	none := UnionOf(NewFilter(&iter, func(first, last uint64) bool { return false }))
+	if len(none) != 0 {
+		t.Errorf("Expected empty list, got %v", none)
+	}
+}
+
+func Test_FilterValues(t *testing.T) {
+	lists := []List{
+		{},
+		makeRange(intrv{0, 9}, intrv{20, 20}, intrv{30, 39}),
+		makeRange(intrv{1, 1}, intrv{3, 3}, intrv{5, 12}),
+		makeRange(intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+	}
+	predicates := map[string]func(uint64) bool{
+		"Even":  func(v uint64) bool { return v%2 == 0 },
+		"Odd":   func(v uint64) bool { return v%2 == 1 },
+		"Low":   func(v uint64) bool { return v%16 < 6 },
+		"All":   func(v uint64) bool { return true },
+		"None":  func(v uint64) bool { return false },
+		"Three": func(v uint64) bool { return v%3 != 0 },
+	}
+	for name, keep := range predicates {
+		t.Run(name, func(t *testing.T) {
+			for _, list := range lists {
+				expected := []uint64{}
+				for _, v := range list.Expand() {
+					if keep(v) {
+						expected = append(expected, v)
+					}
+				}
+				calls := 0
+				iter := list.Iterate()
+				result := UnionOf(FilterValues(&iter, func(v uint64) bool {
+					calls++
+					return keep(v)
+				}))
+				if !equalUint64(result.Expand(), expected) {
+					t.Errorf("FilterValues(%v) = %v, expected %v", list, result.Expand(), expected)
+				}
+				if uint64(calls) != list.Len() {
+					t.Errorf("Predicate called %d times for %d values", calls, list.Len())
+				}
+			}
+		})
+	}
+}
+
+func Test_Map(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{20, 20}, intrv{30, 39})
+
+	t.Run("Shift", func(t *testing.T) {
+		iter := list.Iterate()
+		result := UnionOf(NewAffineMap(&iter, 1, 1000))
+		if expected := makeRange(intrv{1000, 1009}, intrv{1020, 1020}, intrv{1030, 1039}); !Equal(result, expected) {
+			t.Errorf("%v != %v", result, expected)
+		}
+	})
+
+	t.Run("Affine", func(t *testing.T) {
+		iter := list.Iterate()
+		result := UnionOf(NewAffineMap(&iter, 3, 7))
+		expected := []uint64{}
+		for _, v := range list.Expand() {
+			expected = append(expected, v*3+7)
+		}
+		if !equalUint64(result.Expand(), expected) {
+			t.Errorf("%v != %v", result.Expand(), expected)
+		}
+	})
+
+	t.Run("Joined", func(t *testing.T) {
+		// Values below 5 are spread apart, the rest are kept together.
+		fn := func(v uint64) uint64 {
+			if v < 5 {
+				return v * 2
+			}
+			return v + 5
+		}
+		iter := list.Iterate()
+		result := UnionOf(NewMap(&iter, fn))
+		expected := []uint64{}
+		for _, v := range list.Expand() {
+			expected = append(expected, fn(v))
+		}
+		if !equalUint64(result.Expand(), expected) {
+			t.Errorf("%v != %v", result.Expand(), expected)
+		}
+		t.Logf("Mapped: %v", result)
+	})
+
+	t.Run("Intersection", func(t *testing.T) {
+		// Re-map local IDs and intersect with a global list without building
+		// the re-mapped list.
+		global := makeRange(intrv{1005, 1025})
+		iter, giter := list.Iterate(), global.Iterate()
+		result := IntersectionOf(NewAffineMap(&iter, 1, 1000), &giter)
+		if expected := makeRange(intrv{1005, 1009}, intrv{1020, 1020}); !Equal(result, expected) {
+			t.Errorf("%v != %v", result, expected)
+		}
+	})
+}
+
+func Test_Chunks(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{20, 22}, intrv{30, 30})
+	testCases := []struct {
+		max      uint64
+		expected [][2]uint64
+	}{
+		{4, [][2]uint64{{0, 3}, {4, 7}, {8, 9}, {20, 22}, {30, 30}}},
+		{5, [][2]uint64{{0, 4}, {5, 9}, {20, 22}, {30, 30}}},
+		{1, [][2]uint64{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}, {6, 6}, {7, 7}, {8, 8}, {9, 9}, {20, 20}, {21, 21}, {22, 22}, {30, 30}}},
+		{0, [][2]uint64{{0, 9}, {20, 22}, {30, 30}}},
+	}
+	for _, c := range testCases {
+		iter := list.Iterate()
+		chunks := NewChunks(&iter, c.max)
+		result := [][2]uint64{}
+		for first, last := chunks.NextChunk(); first <= last; first, last = chunks.NextChunk() {
+			result = append(result, [2]uint64{first, last})
+		}
+		if fmt.Sprint(result) != fmt.Sprint(c.expected) {
+			t.Errorf("Chunks of %d = %v, expected %v", c.max, result, c.expected)
+		}
+	}
+
+	// The top of the value range.
+	iter := makeRange(intrv{math.MaxUint64 - 4, math.MaxUint64}).Iterate()
+	chunks := NewChunks(&iter, 3)
+	result := [][2]uint64{}
+	for first, last := chunks.NextChunk(); first <= last; first, last = chunks.NextChunk() {
+		result = append(result, [2]uint64{first, last})
+	}
+	expected := [][2]uint64{{math.MaxUint64 - 4, math.MaxUint64 - 2}, {math.MaxUint64 - 1, math.MaxUint64}}
+	if fmt.Sprint(result) != fmt.Sprint(expected) {
+		t.Errorf("Chunks of 3 = %v, expected %v", result, expected)
+	}
+}
+
+func Test_GapIterator(t *testing.T) {
+	cases := []struct {
+		list     List
+		expected [][3]uint64
+	}{
+		{List{}, [][3]uint64{}},
+		{Create(5), [][3]uint64{}},
+		{makeRange(intrv{0, 2}, intrv{5, 5}, intrv{7, 9}, intrv{100, 100}), [][3]uint64{{3, 4, 2}, {6, 6, 1}, {10, 99, 90}}},
+		{FromRaw(3, 2, 0, 2, 2, 1), [][3]uint64{{7, 8, 2}}},
+	}
+	for _, c := range cases {
+		result := [][3]uint64{}
+		g := c.list.Gaps()
+		for first, last, n := g.NextGap(); n > 0; first, last, n = g.NextGap() {
+			result = append(result, [3]uint64{first, last, n})
+		}
+		if fmt.Sprint(result) != fmt.Sprint(c.expected) {
+			t.Errorf("Gaps(%v) = %v, expected %v", c.list, result, c.expected)
+		}
+		if first, last, n := g.NextGap(); first != math.MaxUint64 || last != 0 || n != 0 {
+			t.Errorf("Gaps(%v) after end = %d, %d, %d", c.list, first, last, n)
+		}
+	}
+
+	// Gaps are a source of intervals, such as for a bounded complement.
+	list := makeRange(intrv{10, 19}, intrv{30, 39}, intrv{50, 59})
+	iter := list.Iterate()
+	gaps := UnionOf(NewGapIterator(&iter))
+	if expected := ComplementRange(list, 10, 59); !Equal(gaps, expected) {
+		t.Errorf("UnionOf(gaps) = %v, expected %v", gaps, expected)
+	}
+}
@@ -0,0 +1,114 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Appender builds a list from a continuously growing sequence of increasing
+// values, such as monotonic event IDs, while allowing queries over everything
+// appended so far.
+//
+// The values are held as a sealed prefix List, of every interval which can no
+// longer grow, and an open tail interval which the next value may extend.
+// Queries combine the two, so no Finish or rebuild is needed between appending
+// and querying.
+//
+// An Appender is not safe for concurrent use.
+type Appender struct {
+	l List
+	b Builder
+	n uint64 // Count of values appended
+}
+
+// NewAppender returns a new empty Appender.
+func NewAppender() *Appender {
+	a := &Appender{}
+	a.b = Build(&a.l)
+	return a
+}
+
+// Append appends v, which must be greater than all values appended before.
+// Returns false, ignoring v, if not.
+func (a *Appender) Append(v uint64) bool {
+	if _, last, ok := a.tail(); ok && (v <= last || last == math.MaxUint64) {
+		return false
+	}
+	a.b.Next(v)
+	a.n++
+	return true
+}
+
+// tail returns the open tail interval. ok is false if nothing has been
+// appended.
+func (a *Appender) tail() (first, last uint64, ok bool) {
+	if a.b.take == 0 {
+		return 0, 0, false
+	}
+	return a.b.n - a.b.take, a.b.n - 1, true
+}
+
+// Len returns how many values have been appended.
+func (a *Appender) Len() uint64 {
+	return a.n
+}
+
+// Last returns the last value appended. ok is false if nothing has been
+// appended.
+func (a *Appender) Last() (last uint64, ok bool) {
+	_, last, ok = a.tail()
+	return
+}
+
+// Contains returns true if v has been appended.
+func (a *Appender) Contains(v uint64) bool {
+	first, last, ok := a.tail()
+	switch {
+	case !ok || v > last:
+		return false
+	case v >= first:
+		return true
+	}
+	return a.l.Contains(v)
+}
+
+// Iterate returns the intervals of the values appended so far. The Appender
+// must not be appended to while they are in use.
+func (a *Appender) Iterate() Intervals {
+	it := &appenderIntervals{iter: a.l.Iterate()}
+	it.first, it.last, it.tail = a.tail()
+	return it
+}
+
+// List returns a List of the values appended so far. The Appender keeps its
+// state, so appending can continue.
+func (a *Appender) List() List {
+	l := append(make(List, 0, len(a.l)+2*binary.MaxVarintLen64), a.l...)
+	b := a.b
+	b.Encoder.Elements = &l
+	return b.Finish()
+}
+
+// String implements the fmt.Stringer interface.
+func (a *Appender) String() string {
+	return a.List().String()
+}
+
+// appenderIntervals yields the intervals of the sealed list of an Appender,
+// followed by its tail.
+type appenderIntervals struct {
+	iter        Iterator
+	first, last uint64
+	tail        bool // If the tail is still to be returned
+}
+
+func (t *appenderIntervals) NextInterval() (first, last uint64) {
+	if first, last = t.iter.NextInterval(); first <= last {
+		return
+	}
+	if t.tail {
+		t.tail = false
+		return t.first, t.last
+	}
+	return math.MaxUint64, 0
+}
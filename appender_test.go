@@ -0,0 +1,68 @@
+package skiptake
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Appender(t *testing.T) {
+	a := NewAppender()
+	if a.Contains(0) || a.Len() != 0 || !a.List().IsEmpty() {
+		t.Errorf("New Appender is not empty: %v", a)
+	}
+	if _, ok := a.Last(); ok {
+		t.Errorf("Last() of new Appender is ok")
+	}
+
+	var values []uint64
+	for v := uint64(3); v < 2000; v += 1 + v%7/3 {
+		if !a.Append(v) {
+			t.Fatalf("Append(%d) failed", v)
+		}
+		values = append(values, v)
+
+		// Read your writes, at every step.
+		if !a.Contains(v) || a.Contains(v+1) {
+			t.Fatalf("After Append(%d), Contains(%d) = %v, Contains(%d) = %v", v, v, a.Contains(v), v+1, a.Contains(v+1))
+		}
+		if last, ok := a.Last(); !ok || last != v {
+			t.Fatalf("After Append(%d), Last() = %d, %v", v, last, ok)
+		}
+		if len(values)%97 == 0 {
+			if l := a.List(); !equalUint64(l.Expand(), values) {
+				t.Fatalf("After Append(%d), List() = %v", v, l)
+			}
+			if l := UnionOf(a.Iterate()); !equalUint64(l.Expand(), values) {
+				t.Fatalf("After Append(%d), Iterate() = %v", v, l)
+			}
+		}
+	}
+	expectUint64(t, a.Len(), uint64(len(values)))
+	for _, v := range []uint64{0, 2, 5, 1999, 2000} {
+		_, expected := searchUint64(values, v)
+		if a.Contains(v) != expected {
+			t.Errorf("Contains(%d) = %v, expected %v", v, a.Contains(v), expected)
+		}
+	}
+
+	// The encoding matches a Builder fed the same values.
+	if l := a.List(); string(l) != string(Create(values...)) {
+		t.Errorf("List() = %v, expected %v", []byte(l), []byte(Create(values...)))
+	}
+
+	if last, _ := a.Last(); a.Append(last) || a.Append(0) {
+		t.Errorf("Append() of a value not greater than the last succeeded")
+	}
+}
+
+func Test_AppenderMax(t *testing.T) {
+	a := NewAppender()
+	a.Append(math.MaxUint64 - 1)
+	a.Append(math.MaxUint64)
+	if a.Append(0) {
+		t.Errorf("Append() after math.MaxUint64 succeeded")
+	}
+	if !a.Contains(math.MaxUint64) || a.Len() != 2 {
+		t.Errorf("Appender = %v, Len() = %d", a, a.Len())
+	}
+}
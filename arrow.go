@@ -0,0 +1,134 @@
+package skiptake
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Interoperation with Apache Arrow. To keep this package free of the Arrow
+// dependency, arrays and builders are accepted through the small interfaces
+// below, which the Arrow Go types satisfy. Eg:
+//
+//		arr := builder.NewUint64Array() // *array.Uint64
+//		l, err := skiptake.FromArrowUint64(arr)
+//
+// Selections are converted to and from validity bitmaps, in the Arrow layout
+// of one bit per row, least significant bit first.
+
+// arrowChunk is how many values are appended to an Arrow builder at once.
+const arrowChunk = 1024
+
+// ArrowUint64Array is the subset of the methods of an Arrow UInt64 array, such
+// as *array.Uint64, used by FromArrowUint64.
+type ArrowUint64Array interface {
+	Len() int
+	IsNull(i int) bool
+	Value(i int) uint64
+}
+
+// ArrowUint64Builder is the subset of the methods of an Arrow UInt64 builder,
+// such as *array.Uint64Builder, used by AppendArrowUint64.
+type ArrowUint64Builder interface {
+	Reserve(n int)
+	AppendValues(v []uint64, valid []bool)
+}
+
+// FromArrowUint64 returns a list of the values of an Arrow UInt64 array, which
+// must be strictly increasing. Null elements are skipped. An out of order
+// value returns an error wrapping ErrUnsorted, which gives its index.
+func FromArrowUint64(a ArrowUint64Array) (List, error) {
+	b := Build(&List{})
+	for i := 0; i < a.Len(); i++ {
+		if a.IsNull(i) {
+			continue
+		}
+		if v := a.Value(i); !b.Next(v) {
+			return nil, fmt.Errorf("%w: value %d at index %d", ErrUnsorted, v, i)
+		}
+	}
+	return b.Finish(), nil
+}
+
+// AppendArrowUint64 appends the members of the list to an Arrow UInt64
+// builder, in chunks. The builder is first asked to reserve space for all of
+// them, so the list must be small enough to expand in memory.
+func AppendArrowUint64(dst ArrowUint64Builder, l List) {
+	dst.Reserve(int(l.Len()))
+	buf := make([]uint64, 0, arrowChunk)
+	iter := l.Iterate()
+	for v := iter.Next(); !iter.EOS(); v = iter.Next() {
+		if buf = append(buf, v); len(buf) == cap(buf) {
+			dst.AppendValues(buf, nil)
+			buf = buf[:0]
+		}
+	}
+	if len(buf) > 0 {
+		dst.AppendValues(buf, nil)
+	}
+}
+
+// ToBitmap returns the list as a bitmap of length rows, in the layout of an
+// Arrow validity bitmap: bit i, counting from the least significant bit of
+// byte 0, is set if i is a member. Members of length or more are ignored.
+func (l List) ToBitmap(length int) []byte {
+	bitmap := make([]byte, (length+7)/8)
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last && first < uint64(length); first, last = iter.NextInterval() {
+		if last >= uint64(length) {
+			last = uint64(length) - 1
+		}
+		setBits(bitmap, first, last)
+	}
+	return bitmap
+}
+
+// setBits sets bits first to last, inclusive.
+func setBits(bitmap []byte, first, last uint64) {
+	i, j := first/8, last/8
+	lo := byte(0xff) << (first % 8)
+	hi := byte(0xff) >> (7 - last%8)
+	if i == j {
+		bitmap[i] |= lo & hi
+		return
+	}
+	bitmap[i] |= lo
+	for k := i + 1; k < j; k++ {
+		bitmap[k] = 0xff
+	}
+	bitmap[j] |= hi
+}
+
+// FromBitmap returns a list of the rows set in a bitmap in the layout of an
+// Arrow validity bitmap, for the length rows starting at bit offset. Rows are
+// numbered from offset, as for an Arrow array slice.
+func FromBitmap(bitmap []byte, offset, length int) List {
+	b := Build(&List{})
+	appendBitmap(&b, bitmap, offset, length, 0)
+	return b.Finish()
+}
+
+// appendBitmap adds the rows set in length bits of the bitmap, starting at bit
+// offset, to the builder. Rows are numbered from base.
+func appendBitmap(b *Builder, bitmap []byte, offset, length int, base uint64) {
+	for row := 0; row < length; {
+		bit := offset + row
+		x := bitmap[bit/8] >> (bit % 8)
+		n := 8 - bit%8 // Bits of x which are in the bitmap byte
+		if n > length-row {
+			n = length - row
+			x &= 1<<n - 1
+		}
+		// Runs of set bits within the byte.
+		for x != 0 {
+			skip := bits.TrailingZeros8(x)
+			x >>= skip
+			run := bits.TrailingZeros8(^x)
+			first := base + uint64(row+skip)
+			b.interval(first, first+uint64(run)-1)
+			row += skip + run
+			n -= skip + run
+			x >>= run
+		}
+		row += n
+	}
+}
@@ -0,0 +1,114 @@
+package skiptake
+
+import (
+	"errors"
+	"testing"
+)
+
+// arrowArray is a stand-in for an Arrow UInt64 array. A nil valid means all
+// elements are valid.
+type arrowArray struct {
+	values []uint64
+	valid  []bool
+}
+
+func (a *arrowArray) Len() int           { return len(a.values) }
+func (a *arrowArray) IsNull(i int) bool  { return a.valid != nil && !a.valid[i] }
+func (a *arrowArray) Value(i int) uint64 { return a.values[i] }
+
+// arrowBuilder is a stand-in for an Arrow UInt64 builder.
+type arrowBuilder struct {
+	values   []uint64
+	reserved int
+	appends  int
+}
+
+func (b *arrowBuilder) Reserve(n int) { b.reserved += n }
+
+func (b *arrowBuilder) AppendValues(v []uint64, valid []bool) {
+	b.values = append(b.values, v...)
+	b.appends++
+}
+
+func expectValues(t *testing.T, result, expected []uint64) {
+	t.Helper()
+	if !equalUint64(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+}
+
+func Test_FromArrowUint64(t *testing.T) {
+	a := &arrowArray{
+		values: []uint64{1, 2, 0, 3, 10},
+		valid:  []bool{true, true, false, true, true},
+	}
+	l, err := FromArrowUint64(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, l.Expand(), []uint64{1, 2, 3, 10})
+
+	a.valid = nil
+	if _, err := FromArrowUint64(a); !errors.Is(err, ErrUnsorted) {
+		t.Errorf("FromArrowUint64() error = %v, expected %v", err, ErrUnsorted)
+	}
+
+	l, err = FromArrowUint64(&arrowArray{})
+	if err != nil || !l.IsEmpty() {
+		t.Errorf("FromArrowUint64(empty) = %v, %v", l, err)
+	}
+}
+
+func Test_AppendArrowUint64(t *testing.T) {
+	list := makeRange(intrv{0, 3}, intrv{10, 10}, intrv{1000, 2999})
+	b := &arrowBuilder{}
+	AppendArrowUint64(b, list)
+	expectValues(t, b.values, list.Expand())
+	if b.reserved != 2005 || b.appends != 2 {
+		t.Errorf("reserved %d, appends %d, expected 2005, 2", b.reserved, b.appends)
+	}
+
+	b = &arrowBuilder{}
+	AppendArrowUint64(b, List{})
+	if len(b.values) != 0 || b.appends != 0 {
+		t.Errorf("empty list appended %v in %d calls", b.values, b.appends)
+	}
+}
+
+func Test_ToBitmap(t *testing.T) {
+	list := makeRange(intrv{0, 0}, intrv{3, 5}, intrv{7, 17}, intrv{23, 23}, intrv{30, 40})
+	bitmap := list.ToBitmap(32)
+	expected := []byte{0xb9, 0xff, 0x83, 0xc0}
+	if string(bitmap) != string(expected) {
+		t.Errorf("ToBitmap(32) = %x, expected %x", bitmap, expected)
+	}
+
+	if bitmap := list.ToBitmap(0); len(bitmap) != 0 {
+		t.Errorf("ToBitmap(0) = %x", bitmap)
+	}
+	if bitmap := list.ToBitmap(5); string(bitmap) != "\x19" {
+		t.Errorf("ToBitmap(5) = %x, expected 19", bitmap)
+	}
+}
+
+func Test_FromBitmap(t *testing.T) {
+	bitmap := []byte{0xb9, 0xff, 0x03, 0xc0, 0x00, 0xff}
+	l := FromBitmap(bitmap, 0, 48)
+	expectValues(t, l.Expand(), makeRange(intrv{0, 0}, intrv{3, 5}, intrv{7, 17}, intrv{30, 31}, intrv{40, 47}).Expand())
+	if l.NumIntervals() != 5 {
+		t.Errorf("NumIntervals() = %d, expected 5", l.NumIntervals())
+	}
+
+	// Slices of the bitmap, with rows numbered from the offset
+	expectValues(t, FromBitmap(bitmap, 3, 4).Expand(), []uint64{0, 1, 2})
+	expectValues(t, FromBitmap(bitmap, 5, 20).Expand(), makeRange(intrv{0, 0}, intrv{2, 12}).Expand())
+	expectValues(t, FromBitmap(bitmap, 29, 15).Expand(), makeRange(intrv{1, 2}, intrv{11, 14}).Expand())
+	if l := FromBitmap(bitmap, 32, 8); !l.IsEmpty() {
+		t.Errorf("FromBitmap(zeros) = %v", l.Expand())
+	}
+
+	// Round trip
+	list := makeRange(intrv{1, 1}, intrv{8, 15}, intrv{17, 100}, intrv{250, 260})
+	expectValues(t, FromBitmap(list.ToBitmap(300), 0, 300).Expand(), list.Expand())
+	expectValues(t, FromBitmap(list.ToBitmap(255), 0, 255).Expand(), makeRange(intrv{1, 1}, intrv{8, 15}, intrv{17, 100}, intrv{250, 254}).Expand())
+}
@@ -0,0 +1,57 @@
+package skiptake
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Conversion between lists and big.Int bitsets, in which v is a member if bit
+// v is set.
+
+// FromBigInt returns the list of the set bits of x. The sign of x is ignored,
+// so the bits are those of its absolute value. The words of x are scanned
+// directly, and words of all zeros or all ones are consumed whole.
+func FromBigInt(x *big.Int) List {
+	b := Build(&List{})
+	for i, w := range x.Bits() {
+		base := uint64(i) * bits.UintSize
+		var bit int // Bits of w already consumed
+		for w != 0 {
+			skip := bits.TrailingZeros(uint(w))
+			w >>= uint(skip)
+			run := bits.TrailingZeros(^uint(w))
+			first := base + uint64(bit+skip)
+			b.interval(first, first+uint64(run)-1)
+			bit += skip + run
+			w >>= uint(run)
+		}
+	}
+	return b.Finish()
+}
+
+// ToBigInt returns the list as a big.Int with bit v set for each member v. The
+// big.Int holds a bit for every value up to the largest member, so this is
+// only suitable for lists whose members are reasonably small.
+func (l List) ToBigInt() *big.Int {
+	last, ok := l.Last()
+	if !ok {
+		return new(big.Int)
+	}
+	words := make([]big.Word, last/bits.UintSize+1)
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		i, j := first/bits.UintSize, last/bits.UintSize
+		lo := ^big.Word(0) << (first % bits.UintSize)
+		hi := ^big.Word(0) >> (bits.UintSize - 1 - last%bits.UintSize)
+		if i == j {
+			words[i] |= lo & hi
+			continue
+		}
+		words[i] |= lo
+		for k := i + 1; k < j; k++ {
+			words[k] = ^big.Word(0)
+		}
+		words[j] |= hi
+	}
+	return new(big.Int).SetBits(words)
+}
@@ -0,0 +1,58 @@
+package skiptake
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func Test_FromBigInt(t *testing.T) {
+	x, _ := new(big.Int).SetString("f0000000000000000ffffffffffffffff05", 16)
+	expectValues(t, FromBigInt(x).Expand(), makeRange(intrv{0, 0}, intrv{2, 2}, intrv{8, 71}, intrv{136, 139}).Expand())
+	if n := FromBigInt(x).NumIntervals(); n != 4 {
+		t.Errorf("NumIntervals() = %d, expected 4", n)
+	}
+
+	// The sign is ignored
+	expectValues(t, FromBigInt(big.NewInt(-6)).Expand(), []uint64{1, 2})
+
+	if l := FromBigInt(new(big.Int)); !l.IsEmpty() {
+		t.Errorf("FromBigInt(0) = %v", l.Expand())
+	}
+}
+
+func Test_ToBigInt(t *testing.T) {
+	list := makeRange(intrv{0, 0}, intrv{2, 2}, intrv{8, 71}, intrv{136, 139})
+	if x := list.ToBigInt(); x.Text(16) != "f0000000000000000ffffffffffffffff05" {
+		t.Errorf("ToBigInt() = %x", x)
+	}
+	if x := makeRange(intrv{63, 64}).ToBigInt(); x.Text(16) != "18000000000000000" {
+		t.Errorf("ToBigInt() = %x", x)
+	}
+	if x := (List{}).ToBigInt(); x.Sign() != 0 {
+		t.Errorf("ToBigInt() of empty list = %x", x)
+	}
+
+	// Round trip
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		x := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(r.Intn(1000))))
+		// Long runs of ones
+		x.Or(x, new(big.Int).Lsh(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(r.Intn(300))), big.NewInt(1)), uint(r.Intn(300))))
+		l := FromBigInt(x)
+		if y := l.ToBigInt(); y.Cmp(x) != 0 {
+			t.Fatalf("ToBigInt(FromBigInt(%x)) = %x", x, y)
+		}
+		if l.Len() != popCount(x) {
+			t.Fatalf("FromBigInt(%x).Len() = %d", x, l.Len())
+		}
+	}
+}
+
+func popCount(x *big.Int) uint64 {
+	var n uint64
+	for i := 0; i < x.BitLen(); i++ {
+		n += uint64(x.Bit(i))
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package skiptake
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBudget is returned by BudgetBuilder when a value does not fit within the
+// byte budget of the list.
+var ErrBudget = errors.New("skiptake: list exceeds byte budget")
+
+// BudgetBuilder builds a list from increasing values, like a Builder, but
+// which never grows beyond a budget of bytes. A value which would take the
+// list over budget is refused with ErrBudget, as are all values after it, so
+// the list holds exactly the values up to the last that fit. Eg, to pack
+// values into fixed size slots, each built into a list of its own:
+//
+//		b := skiptake.BuildBudget(&skiptake.List{}, slotSize)
+//		for _, v := range values {
+//			err := b.Next(v)
+//			if err == skiptake.ErrBudget {
+//				store(b.Finish())
+//				b = skiptake.BuildBudget(&skiptake.List{}, slotSize)
+//				err = b.Next(v)
+//			}
+//			if err != nil {
+//				return err // Unsorted, or too large for an empty slot
+//			}
+//		}
+//		store(b.Finish())
+//
+type BudgetBuilder struct {
+	b      Builder
+	budget int
+	last   uint64
+	n      uint64 // How many values have been added
+	full   bool
+}
+
+// BuildBudget returns a BudgetBuilder that stores the list it creates in l,
+// which is never more than budget bytes long.
+func BuildBudget(l *List, budget int) BudgetBuilder {
+	return BudgetBuilder{b: Build(l), budget: budget}
+}
+
+// Next adds the value v to the list. Returns ErrBudget, without adding v, if
+// the list would grow beyond its budget, and then for every value after.
+// Returns an error wrapping ErrUnsorted, without adding v, if v is not greater
+// than all previous values.
+func (b *BudgetBuilder) Next(v uint64) error {
+	if b.full {
+		return ErrBudget
+	}
+	if v < b.b.n {
+		return fmt.Errorf("%w: value %d follows %d", ErrUnsorted, v, b.last)
+	}
+	// Measure the list with v added, as Builder.Skip() would add it.
+	e := b.b.Encoder
+	size := len(*e.Elements)
+	if skip := v - b.b.n; skip == 0 {
+		size = e.grow(size, b.b.skip, b.b.take+1)
+	} else {
+		if b.b.take > 0 {
+			size = e.grow(size, b.b.skip, b.b.take)
+		}
+		size = e.grow(size, skip, 1)
+	}
+	if size > b.budget {
+		b.full = true
+		return ErrBudget
+	}
+	b.b.Skip(v - b.b.n)
+	b.last = v
+	b.n++
+	return nil
+}
+
+// Last returns the last value added to the list, and false if none have been.
+func (b *BudgetBuilder) Last() (uint64, bool) {
+	return b.last, b.n > 0
+}
+
+// Len returns how many values have been added to the list.
+func (b *BudgetBuilder) Len() uint64 {
+	return b.n
+}
+
+// Full returns true once a value has been refused for exceeding the budget.
+func (b *BudgetBuilder) Full() bool {
+	return b.full
+}
+
+// Size returns the length of the list in bytes.
+func (b *BudgetBuilder) Size() int {
+	return b.b.Size()
+}
+
+// Finish returns the list. See Builder.Finish().
+func (b *BudgetBuilder) Finish() List {
+	return b.b.Finish()
+}
@@ -0,0 +1,106 @@
+package skiptake
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// budgetTestValues returns values mixing runs, progressions, and random gaps
+// of varying sizes.
+func budgetTestValues() []uint64 {
+	r := rand.New(rand.NewSource(1))
+	var values []uint64
+	v := uint64(0)
+	for len(values) < 400 {
+		switch r.Intn(4) {
+		case 0:
+			for k := r.Intn(6); k >= 0; k-- {
+				values = append(values, v)
+				v++
+			}
+		case 1:
+			for k := r.Intn(20); k >= 0; k-- {
+				values = append(values, v)
+				v += 3
+			}
+		default:
+			values = append(values, v)
+			v += uint64(r.Int63n(1<<uint(r.Intn(40)))) + 1
+		}
+	}
+	return values
+}
+
+func Test_BuilderSize(t *testing.T) {
+	values := budgetTestValues()
+	for _, opts := range []Options{{}, {ZeroSkips: true, ZeroTakes: true}, {Split: 3}} {
+		b := BuildWith(&List{}, opts)
+		if b.Size() != 0 {
+			t.Errorf("Size() of empty list = %d", b.Size())
+		}
+		for i, v := range values {
+			b.Next(v)
+			expected := BuildWith(&List{}, opts)
+			for _, w := range values[:i+1] {
+				expected.Next(w)
+			}
+			if size, l := b.Size(), expected.Finish(); size != len(l) {
+				t.Fatalf("Options %+v: Size() after %d values = %d, expected %d", opts, i+1, size, len(l))
+			}
+		}
+	}
+}
+
+func Test_BudgetBuilder(t *testing.T) {
+	values := budgetTestValues()
+	for _, budget := range []int{1, 2, 5, 16, 100, 1000} {
+		var slots []List
+		var l List
+		b := BuildBudget(&l, budget)
+		start := 0 // Index of the first value of the slot
+		for i, v := range values {
+			err := b.Next(v)
+			if err == nil {
+				continue
+			}
+			if err != ErrBudget {
+				t.Fatal(err)
+			}
+			if last, ok := b.Last(); ok && last != values[i-1] || uint64(i-start) != b.Len() {
+				t.Errorf("Budget %d: Last() = %d, Len() = %d, at value %d", budget, last, b.Len(), i)
+			}
+			slot := append(List{}, b.Finish()...)
+			if len(slot) > budget {
+				t.Errorf("Budget %d: slot of %d bytes", budget, len(slot))
+			}
+			if next := Create(values[start : i+1]...); len(next) <= budget {
+				t.Errorf("Budget %d: value %d refused, but fits in %d bytes", budget, v, len(next))
+			}
+			if b.Next(v+1) != ErrBudget {
+				t.Errorf("Budget %d: value accepted after refusal", budget)
+			}
+			slots = append(slots, slot)
+			b = BuildBudget(&l, budget)
+			start = i
+			if err := b.Next(v); err != nil {
+				// A single value larger than the budget can not be packed.
+				if len(Create(v)) <= budget {
+					t.Errorf("Budget %d: value %d refused by empty list", budget, v)
+				}
+				break
+			}
+		}
+		if !b.Full() {
+			slots = append(slots, b.Finish())
+			if !equalUint64(Union(slots...).Expand(), values) {
+				t.Errorf("Budget %d: slots do not hold every value", budget)
+			}
+		}
+	}
+
+	b := BuildBudget(&List{}, 100)
+	b.Next(10)
+	if err := b.Next(10); err == nil || b.Full() {
+		t.Errorf("Repeated value = %v, Full() = %v", err, b.Full())
+	}
+}
@@ -10,6 +10,7 @@ type Builder struct {
 	n       uint64
 	skip    uint64
 	take    uint64
+	stream  *StreamEncoder // Writes out the list as it is built, if set
 }
 
 // Build returns a skip take builder that stores the list it creates in the
@@ -22,6 +23,13 @@ func Build(l *List) Builder {
 	return Builder{Encoder: l.Encode()}
 }
 
+// BuildWith returns a skip take builder like Build(), but which encodes with
+// the passed options.
+func BuildWith(l *List, opts Options) Builder {
+	l.Reset()
+	return Builder{Encoder: l.EncodeWith(opts)}
+}
+
 // Skip adds a skip value to the list being built. Every call to skip implies a
 // take of one. Repeat calls to Skip() will NOT sum together due to this
 // implied take.
@@ -58,6 +66,95 @@ func (b *Builder) Next(n uint64) bool {
 	return true
 }
 
+// interval adds the inclusive interval [first, last] to the list. first must
+// be greater than all previous values.
+func (b *Builder) interval(first, last uint64) {
+	b.Skip(first - b.n)
+	b.Take(last - first)
+}
+
+// progression adds the n values base + k*stride, for k < n. base must be
+// greater than all previous values. Runs of values are added as repeated
+// pairs, in constant time.
+func (b *Builder) progression(base, stride, n uint64) {
+	switch {
+	case n == 0:
+		return
+	case stride == 1:
+		b.interval(base, base+n-1)
+		return
+	}
+	b.Skip(base - b.n)
+	if n == 1 {
+		return
+	}
+	// The second value is left pending as the last, as its pair repeats.
+	b.Skip(stride - 1)
+	if n > 2 {
+		b.flush()
+		b.Encoder.addRepeats(n - 3)
+		b.n += (n - 2) * stride
+	}
+}
+
+// AppendList adds the members of other, each shifted up by baseOffset. The
+// pairs of other are rewritten in turn, with runs of repeated pairs added in
+// constant time, so this is far faster than adding its members one by one.
+// Eg, to assemble a list of global positions from lists of the positions
+// within each chunk:
+//
+//		b := skiptake.Build(&global)
+//		for i, chunk := range chunks {
+//			b.AppendList(chunk, uint64(i)*chunkSize)
+//		}
+//		global = b.Finish()
+//
+// Returns false if the first shifted member is not greater than all previous
+// values, in which case other is ignored.
+func (b *Builder) AppendList(other List, baseOffset uint64) bool {
+	pos := baseOffset
+	for d := other.Decode(); !d.EOS(); {
+		skip, take, count := d.NextRun()
+		if take == 0 {
+			pos += skip * count
+			continue
+		}
+		first := pos + skip
+		if first < b.n {
+			return false
+		}
+		b.Skip(first - b.n)
+		b.Take(take - 1)
+		switch {
+		case count == 1:
+		case skip == 0:
+			b.Take((count - 1) * take)
+		default:
+			// As progression(), the second pair is left pending as the last.
+			b.Skip(skip)
+			b.Take(take - 1)
+			if count > 2 {
+				b.flush()
+				b.Encoder.addRepeats(count - 3)
+				b.n += (count - 2) * (skip + take)
+			}
+		}
+		pos = b.n
+	}
+	return true
+}
+
+// Size returns the length in bytes of the list as it would be returned by
+// Finish() now.
+func (b *Builder) Size() int {
+	size := len(*b.Encoder.Elements)
+	if b.take > 0 {
+		e := b.Encoder
+		size = e.grow(size, b.skip, b.take)
+	}
+	return size
+}
+
 // Finish flushes any pending data to the built list and returns it.
 func (b *Builder) Finish() List {
 	b.flush()
@@ -65,8 +162,19 @@ func (b *Builder) Finish() List {
 	return *b.Encoder.Elements
 }
 
+// FinishFrame flushes any pending data, and appends the built list to dst as
+// a frame packed with whichever registered codec packs it smallest. Returns
+// the extended slice, and the ID of the codec chosen, which is also recorded
+// in the frame. See AppendFrameBest().
+func (b *Builder) FinishFrame(dst []byte) ([]byte, CodecID) {
+	return AppendFrameBest(dst, b.Finish())
+}
+
 func (b *Builder) flush() {
 	if b.take > 0 {
 		b.Encoder.Add(b.skip, b.take)
+		if b.stream != nil {
+			b.stream.drain(false)
+		}
 	}
 }
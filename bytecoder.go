@@ -2,6 +2,7 @@ package skiptake
 
 import (
 	"encoding/binary"
+	"math/bits"
 )
 
 // The details of the byte packing are isolated here, to allow for more complex
@@ -16,6 +17,7 @@ import (
 // - Skip values of 0 are rare, except as the first skip.
 // - Both skip and take values are likely to be small.
 // - Take values often repeat.
+// - Whole skip-take pairs often repeat, as in arithmetic progressions.
 // - The most common take value in a large sequence is 1.
 // - Shorter sequences have larger values, longer sequences have smaller values.
 
@@ -34,21 +36,84 @@ import (
 // values. This worst case scenario for a 64-bit int remains the same at 10
 // bytes.
 
-// Split is how many bits to add to the bottom of the varint. Split must be
-// between 0 and 6.
-const split = 1
-const splitLowmask = (1 << split) - 1
-const splitHighmask = 0x7f >> split
+// defaultSplit is how many bits to add to the bottom of the varint, unless
+// otherwise specified by Options.
+const defaultSplit = 1
+
+// Options configures the varint byte packing. The zero value of Options is the
+// default packing used by Encode() and Decode().
+//
+// A list must always be decoded with the same options it was encoded with.
+// Lists using options other than the default must be read with DecodeWith()
+// or IterateWith(), and cannot be passed to functions which take a List, such
+// as the set operations. Transcode can convert between them.
+type Options struct {
+	// Split is how many bits are added to the bottom of each varint, of which
+	// the lowest is used to mark the value as a skip or a take. Split must be
+	// between 1 and 6, with zero meaning the default of 1. Larger values are
+	// treated as 6.
+	Split uint8
+
+	// ZeroSkips indicates that skips of zero are common, so skips should be
+	// stored as-is, rather than as skip-1.
+	ZeroSkips bool
+
+	// ZeroTakes indicates that takes of zero are common, so takes should be
+	// stored as-is, rather than as take-1.
+	ZeroTakes bool
+}
+
+func (o Options) split() uint {
+	switch {
+	case o.Split == 0:
+		return defaultSplit
+	case o.Split > 6:
+		return 6
+	}
+	return uint(o.Split)
+}
+
+func (o Options) skipBias() uint64 {
+	if o.ZeroSkips {
+		return 0
+	}
+	return 1
+}
+
+func (o Options) takeBias() uint64 {
+	if o.ZeroTakes {
+		return 0
+	}
+	return 1
+}
 
 // readVarint2 Reads a varint from b starting at the offset pointed to by *i. *i
 // is incremented as read. Returns the varint value as u, the extra split bits
 // as e.
-func readVarint2(b []byte, i *int) (u uint64, e int8) {
+func readVarint2(b []byte, i *int, split uint) (u uint64, e int8) {
+	if *i+8 <= len(b) {
+		// Fast path. Load 8 bytes at once, and find the end of the varint from
+		// the first byte without a continuation bit.
+		w := binary.LittleEndian.Uint64(b[*i:])
+		if stop := ^w & 0x8080808080808080; stop != 0 {
+			n := uint(bits.TrailingZeros64(stop)/8 + 1)
+			if n < 8 {
+				w &= 1<<(8*n) - 1
+			}
+			// Compact the 7-bit groups of each byte together.
+			w &= 0x7f7f7f7f7f7f7f7f
+			w = (w&0x7f007f007f007f00)>>1 | w&0x007f007f007f007f
+			w = (w&0x3fff00003fff0000)>>2 | w&0x00003fff00003fff
+			w = (w&0x0fffffff00000000)>>4 | w&0x000000000fffffff
+			*i += int(n)
+			return w >> split, int8(w & (1<<split - 1))
+		}
+	}
 	var s uint
 	if *i < len(b) {
 		x := b[*i]
 		*i++
-		e = int8(x & splitLowmask)
+		e = int8(x & (1<<split - 1))
 		u = uint64((x & 0x7f) >> split)
 		s += 7 - split
 		if x >= 0x80 {
@@ -68,25 +133,31 @@ func readVarint2(b []byte, i *int) (u uint64, e int8) {
 
 // Append a varint value u and split bits e to target. Behaves like append(),
 // and returns the slice, if the slice was reallocated.
-func appendVarint2(target []byte, u uint64, e int8) []byte {
-	var ar [binary.MaxVarintLen64]byte
-	i := 0
-	x := byte(e&splitLowmask) | (byte(u&splitHighmask) << split)
-	if u >= splitHighmask {
-		ar[i] = x | 0x80
-		u >>= (7 - split)
-		i++
-		for u >= 0x80 {
-			ar[i] = byte(u) | 0x80
-			u >>= 7
-			i++
-		}
-		ar[i] = byte(u)
-	} else {
-		ar[i] = x
+func appendVarint2(target []byte, u uint64, e int8, split uint) []byte {
+	highmask := uint64(0x7f >> split)
+	x := byte(e&(1<<split-1)) | (byte(u&highmask) << split)
+	if u < highmask {
+		return append(target, x)
 	}
+	target = append(target, x|0x80)
+	u >>= (7 - split)
+	for u >= 0x80 {
+		target = append(target, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(target, byte(u))
+}
 
-	return append(target, ar[:i+1]...)
+// sizeVarint2 returns the number of bytes appendVarint2 would use to encode u.
+func sizeVarint2(u uint64, split uint) int {
+	if u < uint64(0x7f>>split) {
+		return 1
+	}
+	n := 2
+	for u >>= (7 - split); u >= 0x80; u >>= 7 {
+		n++
+	}
+	return n
 }
 
 const (
@@ -101,53 +172,152 @@ type Decoder struct {
 	i        int
 	Elements List
 	lastTake uint64
+	lastSkip uint64
+	repeat   uint64 // Remaining repeats of the last pair
+	opts     Options
 }
 
 // Next returns the next pair of skip, take values. Returns (0,0) in the case of
 // end-of-sequence, although this sequence can occur within a list.
 func (d *Decoder) Next() (skip, take uint64) {
-	if !d.EOS() {
-		n, e := readVarint2(d.Elements, &d.i)
-		if e == skipFlag {
-			skip = n
-			if !d.EOS() {
-				j := d.i
-				n, e = readVarint2(d.Elements, &j)
-				if e == takeFlag {
-					d.lastTake = n
-					d.i = j
-				}
-			}
-			return skip + 1, d.lastTake + 1
-		}
-		if e == takeFlag {
+	if d.repeat > 0 {
+		d.repeat--
+		return d.lastSkip, d.lastTake + d.opts.takeBias()
+	}
+	if d.i >= len(d.Elements) {
+		return 0, 0
+	}
+	n, e := readVarint2(d.Elements, &d.i, d.opts.split())
+	switch e {
+	case skipFlag:
+		skip = n + d.opts.skipBias()
+		if n, ok := d.peekTake(); ok {
 			d.lastTake = n
-			return 0, d.lastTake + 1
+		}
+	case takeFlag:
+		d.lastTake = n
+	default:
+		return 0, 0
+	}
+	d.lastSkip = skip
+	if n, ok := d.peekTake(); ok {
+		d.repeat = n + 1
+	}
+	return skip, d.lastTake + d.opts.takeBias()
+}
+
+// NextRun returns the next pair of skip, take values, along with how many times
+// the pair occurs consecutively, consuming all of them. Runs of identical pairs
+// encoded with a repeat count are returned without being decoded one by one.
+// Returns (0, 0, 0) in the case of end-of-sequence.
+func (d *Decoder) NextRun() (skip, take, count uint64) {
+	if d.EOS() {
+		return 0, 0, 0
+	}
+	skip, take = d.Next()
+	count = d.repeat + 1
+	d.repeat = 0
+	return
+}
+
+// NextPairs decodes up to len(pairs) of the following skip, take pairs into
+// pairs, returning how many were decoded. Returns 0 at end-of-sequence. Runs of
+// repeated pairs are copied out without decoding each one. Eg:
+//
+//		buf := make([][2]uint64, 256)
+//		for n := d.NextPairs(buf); n > 0; n = d.NextPairs(buf) {
+//			process(buf[:n])
+//		}
+//
+func (d *Decoder) NextPairs(pairs [][2]uint64) int {
+	n := 0
+	for n < len(pairs) && !d.EOS() {
+		if d.repeat == 0 {
+			pairs[n][0], pairs[n][1] = d.Next()
+			n++
+			continue
+		}
+		pair := [2]uint64{d.lastSkip, d.lastTake + d.opts.takeBias()}
+		end := n + int(d.skipRepeats(uint64(len(pairs)-n)))
+		for ; n < end; n++ {
+			pairs[n] = pair
 		}
 	}
-	return 0, 0
+	return n
+}
+
+// SeekPair positions the decoder at the start of the k'th pair of the list,
+// counting from zero, so that the following call to Next() returns it. Each
+// copy of a repeated pair counts as a pair, as they are returned by Next().
+// Returns false if the list holds k or fewer pairs, leaving the decoder at
+// end-of-sequence.
+//
+// The decoder seeks from the start of the list, in time linear in the pairs
+// before k, although runs of repeated pairs are stepped over in one step.
+func (d *Decoder) SeekPair(k uint64) bool {
+	d.Reset()
+	for k > 0 && !d.EOS() {
+		if d.repeat > 0 {
+			k -= d.skipRepeats(k)
+			continue
+		}
+		d.Next()
+		k--
+	}
+	return !d.EOS()
+}
+
+// skipRepeats consumes up to max remaining repeats of the last pair. Returns
+// how many were consumed.
+func (d *Decoder) skipRepeats(max uint64) uint64 {
+	if max > d.repeat {
+		max = d.repeat
+	}
+	d.repeat -= max
+	return max
+}
+
+// peekTake reads the next varint if it is a take, and advances past it.
+// Returns false, without advancing, if it is not.
+func (d *Decoder) peekTake() (uint64, bool) {
+	if d.i >= len(d.Elements) {
+		return 0, false
+	}
+	j := d.i
+	n, e := readVarint2(d.Elements, &j, d.opts.split())
+	if e != takeFlag {
+		return 0, false
+	}
+	d.i = j
+	return n, true
 }
 
 // PeekSkip returns the next skip values, without advancing the
 // current decode location.
 func (d *Decoder) PeekSkip() uint64 {
+	if d.repeat > 0 {
+		return d.lastSkip
+	}
 	j := d.i
-	n, e := readVarint2(d.Elements, &j)
+	n, e := readVarint2(d.Elements, &j, d.opts.split())
 	if e != skipFlag {
 		return 0
 	}
-	return n + 1
+	return n + d.opts.skipBias()
 }
 
 // EOS returns if the decoder is a that end of the sequence. Unlike
 // Iterator.EOS(), this will return true before a call to Next().
 func (d Decoder) EOS() bool {
-	return d.i >= len(d.Elements)
+	return d.i >= len(d.Elements) && d.repeat == 0
 }
 
 // Reset resets the location of the decoder to the beginning of the sequence.
 func (d *Decoder) Reset() {
 	d.i = 0
+	d.lastTake = 0
+	d.lastSkip = 0
+	d.repeat = 0
 }
 
 // Encoder abstracts appending items to the list.
@@ -156,10 +326,25 @@ func (d *Decoder) Reset() {
 type Encoder struct {
 	Elements *List
 	lastTake uint64
+	lastSkip uint64
+	run      uint64 // Number of pairs in the current run of identical pairs
+	runEnd   int    // Offset of the end of the first pair of the run
+	elided   bool   // If the take of the first pair of the run was omitted
+	start    int    // Offset in Elements at which the list being packed begins
+	opts     Options
 }
 
 // Add adds a new skip-take pair to the sequence.
 func (e *Encoder) Add(skip, take uint64) {
+	split := e.opts.split()
+	if e.run > 0 && skip == e.lastSkip && take-e.opts.takeBias() == e.lastTake {
+		e.addRepeat()
+		return
+	}
+	first := len(*e.Elements) == e.start && e.run == 0 // Nothing packed before
+	e.lastSkip = skip
+	e.run = 1
+
 	// Generally skips of zero should not occur in the middle of a list, so to
 	// optimize our variable byte encoding, we instead encode skip-1. Skips of
 	// size zero will still be encoded, but as the more expensive uint64(-1).
@@ -169,14 +354,16 @@ func (e *Encoder) Add(skip, take uint64) {
 	// common case, omit the skip and force-emit a take. When the decoder sees
 	// a take when it expects a skip, it infers a skip of zero.
 	//
-	// This scheme of using a take when a skip was expected could be used to
-	// encode all skips of zero. However, this requires that the previous take
-	// was not omitted, to prevent ambiguity. Considering zero takes are the
-	// exception, we don't do this, although the decoder would understand it.
-	emitSkip := (skip != 0 || len(*e.Elements) > 0)
-	skip--
+	// A take when a skip was expected is only inferred as a zero skip at the
+	// start of a list. Elsewhere, a take directly following a take is a repeat
+	// count. See addRepeat().
+	//
+	// With Options.ZeroSkips, skips are stored without subtracting one.
+	out := *e.Elements
+	emitSkip := (skip != 0 || !first)
+	skip -= e.opts.skipBias()
 	if emitSkip {
-		*e.Elements = appendVarint2(*e.Elements, skip, skipFlag)
+		out = appendVarint2(out, skip, skipFlag, split)
 	}
 
 	// Takes are only emitted if the new take value is different from the
@@ -189,11 +376,102 @@ func (e *Encoder) Add(skip, take uint64) {
 	//
 	// We store the last take emitted as (take - 1). This allows for the
 	// zero-state of both structures to correctly be a last take of one.
-	take--
-	if !emitSkip || take != e.lastTake {
-		*e.Elements = appendVarint2(*e.Elements, take, takeFlag)
+	//
+	// With Options.ZeroTakes, takes are stored without subtracting one, and
+	// the zero-state is a last take of zero.
+	take -= e.opts.takeBias()
+	e.elided = emitSkip && take == e.lastTake
+	if !e.elided {
+		out = appendVarint2(out, take, takeFlag, split)
 		e.lastTake = take
 	}
+	*e.Elements = out
+	e.runEnd = len(out)
+}
+
+// addRepeat adds another copy of the last pair added.
+//
+// Runs of identical pairs, such as those of an arithmetic progression, are
+// encoded as the first pair followed by a repeat count. The repeat count is a
+// take directly following the take of the first pair, which cannot otherwise
+// occur. As with other values, the count is stored as (repeats - 1). A first
+// pair whose take was omitted must have it emitted for the repeat count to
+// follow.
+//
+// Short runs are cheaper written out in full, as each repeat costs only a skip.
+// Each repeat rewrites the tail of the list from the end of the first pair in
+// whichever form is shorter, so the list is always complete.
+func (e *Encoder) addRepeat() {
+	split := e.opts.split()
+	skip := e.lastSkip - e.opts.skipBias()
+	e.run++
+	if !e.repeatShorter(e.run) {
+		*e.Elements = appendVarint2(*e.Elements, skip, skipFlag, split)
+		return
+	}
+	out := (*e.Elements)[:e.runEnd]
+	if e.elided {
+		out = appendVarint2(out, e.lastTake, takeFlag, split)
+	}
+	*e.Elements = appendVarint2(out, e.run-2, takeFlag, split)
+}
+
+// repeatShorter returns true if a run of n copies of the last pair is shorter
+// encoded with a repeat count than written out in full.
+func (e *Encoder) repeatShorter(n uint64) bool {
+	split := e.opts.split()
+	plainLen := (n - 1) * uint64(sizeVarint2(e.lastSkip-e.opts.skipBias(), split))
+	repeatLen := sizeVarint2(n-2, split)
+	if e.elided {
+		repeatLen += sizeVarint2(e.lastTake, split)
+	}
+	return plainLen > uint64(repeatLen)
+}
+
+// addRepeats adds n more copies of the last pair added. Once the run is
+// encoded with a repeat count, it is extended in one step, so this takes
+// constant time however large n is.
+func (e *Encoder) addRepeats(n uint64) {
+	for ; n > 0 && !e.repeatShorter(e.run+1); n-- {
+		e.addRepeat()
+	}
+	if n > 0 {
+		e.run += n - 1
+		e.addRepeat()
+	}
+}
+
+// grow returns the length a list of size bytes would have once Add(skip, take)
+// is called, and updates the state of e as Add does, without writing to the
+// list. Called on a copy of an Encoder, it measures what Add would write.
+func (e *Encoder) grow(size int, skip, take uint64) int {
+	split := e.opts.split()
+	if e.run > 0 && skip == e.lastSkip && take-e.opts.takeBias() == e.lastTake {
+		// As addRepeat().
+		e.run++
+		if !e.repeatShorter(e.run) {
+			return size + sizeVarint2(skip-e.opts.skipBias(), split)
+		}
+		size = e.runEnd
+		if e.elided {
+			size += sizeVarint2(e.lastTake, split)
+		}
+		return size + sizeVarint2(e.run-2, split)
+	}
+	emitSkip := skip != 0 || size > e.start || e.run > 0
+	e.lastSkip = skip
+	e.run = 1
+	if emitSkip {
+		size += sizeVarint2(skip-e.opts.skipBias(), split)
+	}
+	take -= e.opts.takeBias()
+	e.elided = emitSkip && take == e.lastTake
+	if !e.elided {
+		size += sizeVarint2(take, split)
+		e.lastTake = take
+	}
+	e.runEnd = size
+	return size
 }
 
 // Flush instructs the encoder to write out any pending state.
@@ -207,12 +485,30 @@ func (l List) Decode() Decoder {
 	return Decoder{Elements: l}
 }
 
+// DecodeInto reinitializes the passed Decoder to decode the list from its
+// beginning, avoiding the allocation of a new Decoder.
+func (l List) DecodeInto(d *Decoder) {
+	*d = Decoder{Elements: l}
+}
+
+// DecodeWith returns a new skiptake.Decoder for a list encoded with the
+// passed options.
+func (l List) DecodeWith(opts Options) Decoder {
+	return Decoder{Elements: l, opts: opts}
+}
+
 // Encode returns a new skiptake.Encoder for the list.
 // Note that the encoder must be passed by reference to maintain state.
 func (l *List) Encode() Encoder {
 	return Encoder{Elements: l}
 }
 
+// EncodeWith returns a new skiptake.Encoder for the list which encodes with
+// the passed options.
+func (l *List) EncodeWith(opts Options) Encoder {
+	return Encoder{Elements: l, opts: opts}
+}
+
 // Reset the list as a new empty list.
 func (l *List) Reset() {
 	if l != nil {
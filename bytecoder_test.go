@@ -1,6 +1,7 @@
 package skiptake
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -95,3 +96,243 @@ func Test_EncodeDecode(t *testing.T) {
 		[2]uint64{50, 50},
 	})
 }
+
+func repeatPairs(n int, pair [2]uint64) [][2]uint64 {
+	values := make([][2]uint64, n)
+	for i := range values {
+		values[i] = pair
+	}
+	return values
+}
+
+func Test_EncodeDecodeRepeat(t *testing.T) {
+	// Short run, written out in full
+	testEncodeDecode(t, repeatPairs(3, [2]uint64{1, 1}))
+
+	// Long runs
+	testEncodeDecode(t, repeatPairs(100, [2]uint64{9, 1}))
+	testEncodeDecode(t, repeatPairs(100, [2]uint64{0, 3}))
+	testEncodeDecode(t, repeatPairs(100, [2]uint64{0x100000000, 0x200000000}))
+
+	// Runs with and without an elided first take, between other pairs.
+	values := [][2]uint64{{5, 1}}
+	values = append(values, repeatPairs(20, [2]uint64{3, 1})...)
+	values = append(values, [2]uint64{7, 2})
+	values = append(values, repeatPairs(20, [2]uint64{3, 2})...)
+	values = append(values, repeatPairs(20, [2]uint64{3, 1})...)
+	values = append(values, [2]uint64{0, 4}, [2]uint64{0, 4}, [2]uint64{0, 4})
+	testEncodeDecode(t, values)
+}
+
+func Test_EncodeRepeatSize(t *testing.T) {
+	// Every 10th integer from 1M to 9M.
+	b := Build(&List{})
+	for v := uint64(1000000); v < 9000000; v += 10 {
+		b.Next(v)
+	}
+	list := b.Finish()
+	t.Logf("Encoded as %d bytes: %v", len(list), []byte(list))
+
+	if len(list) > 16 {
+		t.Errorf("Arithmetic progression encoded as %d bytes", len(list))
+	}
+	expectUint64(t, list.Len(), (9000000-1000000)/10)
+
+	iter := list.Iterate()
+	expectUint64(t, iter.Next(), 1000000)
+	expectUint64(t, iter.Next(), 1000010)
+	skip, _ := iter.Seek(5000)
+	expectUint64(t, skip, 1050000)
+}
+
+func Test_DecodeReset(t *testing.T) {
+	// The first take is omitted, relying on the default decoder state.
+	list := FromRaw(5, 1, 3, 2)
+	d := list.Decode()
+	for !d.EOS() {
+		d.Next()
+	}
+	d.Reset()
+	skip, take := d.Next()
+	expectUint64(t, skip, 5)
+	expectUint64(t, take, 1)
+}
+
+func Test_EncodeAppend(t *testing.T) {
+	// Pairs added to a list which already has elements continue it, so a
+	// leading zero skip is not omitted.
+	list := FromRaw(0, 3)
+	e := list.Encode()
+	e.Add(0, 2)
+	e.Add(5, 1)
+	var pairs []uint64
+	for d := list.Decode(); !d.EOS(); {
+		skip, take := d.Next()
+		pairs = append(pairs, skip, take)
+	}
+	if expected := []uint64{0, 3, 0, 2, 5, 1}; !equalUint64(pairs, expected) {
+		t.Errorf("Appended list decoded as %v, expected %v", pairs, expected)
+	}
+}
+
+func Test_SizeVarint2(t *testing.T) {
+	for split := uint(1); split <= 6; split++ {
+		for _, u := range []uint64{0, 1, 7, 8, 15, 16, 63, 64, 127, 128, 1 << 20, 1<<63 - 1, 1 << 63, ^uint64(0)} {
+			for _, v := range []uint64{u, u - 1, u + 1} {
+				if n, size := len(appendVarint2(nil, v, takeFlag, split)), sizeVarint2(v, split); n != size {
+					t.Errorf("sizeVarint2(%d, %d) = %d, expected %d", v, split, size, n)
+				}
+			}
+		}
+	}
+}
+
+func Test_DecodeNextRun(t *testing.T) {
+	values := [][2]uint64{{5, 1}}
+	values = append(values, repeatPairs(50, [2]uint64{3, 2})...)
+	values = append(values, [2]uint64{1, 1})
+
+	var l List
+	enc := l.Encode()
+	for _, pair := range values {
+		enc.Add(pair[0], pair[1])
+	}
+
+	expected := [][3]uint64{{5, 1, 1}, {3, 2, 50}, {1, 1, 1}, {0, 0, 0}}
+	d := l.Decode()
+	for _, e := range expected {
+		skip, take, count := d.NextRun()
+		if skip != e[0] || take != e[1] || count != e[2] {
+			t.Errorf("NextRun() = (%d, %d, %d), expected %v", skip, take, count, e)
+		}
+	}
+}
+
+func Test_DecodeNextPairs(t *testing.T) {
+	values := [][2]uint64{{5, 1}}
+	values = append(values, repeatPairs(50, [2]uint64{3, 2})...)
+	values = append(values, [2]uint64{1, 1}, [2]uint64{0, 7}, [2]uint64{9, 0})
+	values = append(values, repeatPairs(10, [2]uint64{4, 4})...)
+
+	var l List
+	enc := l.Encode()
+	for _, pair := range values {
+		enc.Add(pair[0], pair[1])
+	}
+
+	for _, size := range []int{1, 3, 7, 64, 1000} {
+		d := l.Decode()
+		buf := make([][2]uint64, size)
+		result := [][2]uint64{}
+		for n := d.NextPairs(buf); n > 0; n = d.NextPairs(buf) {
+			result = append(result, buf[:n]...)
+		}
+		if fmt.Sprint(result) != fmt.Sprint(values) {
+			t.Errorf("NextPairs() with buffer of %d = %v, expected %v", size, result, values)
+		}
+	}
+}
+
+func Test_EncodeDecodeOptions(t *testing.T) {
+	options := []Options{
+		{Split: 2},
+		{Split: 6},
+		{ZeroSkips: true},
+		{ZeroTakes: true},
+		{Split: 3, ZeroSkips: true, ZeroTakes: true},
+	}
+	for _, opts := range options {
+		for name, values := range codecTestCases {
+			t.Run(fmt.Sprintf("%+v/%s", opts, name), func(t *testing.T) {
+				var l List
+				enc := l.EncodeWith(opts)
+				testPairCodec(t, values, &enc, func() PairDecoder {
+					d := l.DecodeWith(opts)
+					return &d
+				})
+			})
+		}
+	}
+}
+
+func Test_EncodeZeroSkips(t *testing.T) {
+	values := [][2]uint64{}
+	for i := uint64(0); i < 100; i++ {
+		values = append(values, [2]uint64{i % 2, i%5 + 1})
+	}
+
+	var def, zero List
+	e := def.Encode()
+	z := zero.EncodeWith(Options{ZeroSkips: true})
+	for _, p := range values {
+		e.Add(p[0], p[1])
+		z.Add(p[0], p[1])
+	}
+	t.Logf("Default: %d bytes, ZeroSkips: %d bytes", len(def), len(zero))
+	if len(zero) >= len(def) {
+		t.Errorf("ZeroSkips encoding (%d bytes) not smaller than default (%d bytes)", len(zero), len(def))
+	}
+
+	iter := zero.IterateWith(Options{ZeroSkips: true})
+	expected := def.Expand()
+	for i, n := 0, iter.Next(); !iter.EOS(); i, n = i+1, iter.Next() {
+		if n != expected[i] {
+			t.Fatalf("Value %d: %d != %d", i, n, expected[i])
+		}
+	}
+}
+
+func Test_ReadVarint2(t *testing.T) {
+	values := []uint64{0, 1, 2, 0x3f, 0x40, 0x7f, 0x80, 0x3fff, 0x4000, 1 << 48, 1<<55 - 1, 1 << 55, 1 << 56, 1<<63 + 5, 0xffffffffffffffff}
+	for shift := uint(0); shift < 64; shift += 3 {
+		values = append(values, 0x5a5a5a5a5a5a5a5a>>shift, 1<<shift, 1<<shift-1)
+	}
+	for split := uint(1); split <= 6; split++ {
+		for _, u := range values {
+			e := int8(u % (1 << split))
+			b := appendVarint2(nil, u, e, split)
+
+			// Without trailing bytes, decoded one byte at a time.
+			i := 0
+			ru, re := readVarint2(b, &i, split)
+			if ru != u || re != e || i != len(b) {
+				t.Errorf("readVarint2(%v, split %d) = %d, %d, read %d, expected %d, %d", b, split, ru, re, i, u, e)
+			}
+
+			// With trailing bytes, decoded by the word-at-a-time path where the
+			// varint fits.
+			padded := append(append([]byte{0xff}, b...), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01)
+			i = 1
+			ru, re = readVarint2(padded, &i, split)
+			if ru != u || re != e || i != len(b)+1 {
+				t.Errorf("readVarint2(%v, split %d) = %d, %d, read %d, expected %d, %d", padded, split, ru, re, i, u, e)
+			}
+		}
+	}
+}
+
+func Test_DecodeSeekPair(t *testing.T) {
+	l := FromRaw(0, 3, 2, 2, 2, 2, 2, 2, 2, 2, 5, 1, 0, 4, 7, 7, 7, 7)
+	var pairs [][2]uint64
+	for d := l.Decode(); !d.EOS(); {
+		skip, take := d.Next()
+		pairs = append(pairs, [2]uint64{skip, take})
+	}
+	d := l.Decode()
+	for k := range pairs {
+		if !d.SeekPair(uint64(k)) {
+			t.Fatalf("SeekPair(%d) returned false", k)
+		}
+		for j, expected := range pairs[k:] {
+			if skip, take := d.Next(); skip != expected[0] || take != expected[1] {
+				t.Errorf("SeekPair(%d): pair %d = (%d, %d), expected %v", k, k+j, skip, take, expected)
+			}
+		}
+		if !d.EOS() {
+			t.Errorf("SeekPair(%d): pairs remain", k)
+		}
+	}
+	if d.SeekPair(uint64(len(pairs))) || !d.EOS() {
+		t.Errorf("SeekPair() past the last pair returned true")
+	}
+}
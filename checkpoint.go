@@ -0,0 +1,116 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrBadState is returned when resuming from a state that does not fit the
+// list.
+var ErrBadState = errors.New("skiptake: state does not fit list")
+
+// DecoderState is the position of a Decoder within its list, as returned by
+// Decoder.State(). A Decoder can be resumed from it, so that a long scan can
+// be broken off and continued later. Eg:
+//
+//		d := l.Decode()
+//		for !d.EOS() && time.Now().Before(deadline) {
+//			process(d.Next())
+//		}
+//		saved := d.State()
+//		...
+//		d = l.Decode()
+//		if err := d.Resume(saved); err != nil {
+//			...
+//		}
+//
+type DecoderState struct {
+	Offset   int    // Byte offset of the next varint to decode
+	LastSkip uint64 // The last pair returned by Next()
+	LastTake uint64
+	Repeat   uint64 // Remaining repeats of the last pair
+}
+
+// Position returns the byte offset within the list of the next varint to be
+// decoded.
+func (d *Decoder) Position() int {
+	return d.i
+}
+
+// State returns the position of the decoder, from which it can be resumed.
+func (d *Decoder) State() DecoderState {
+	return DecoderState{
+		Offset:   d.i,
+		LastSkip: d.lastSkip,
+		LastTake: d.lastTake + d.opts.takeBias(),
+		Repeat:   d.repeat,
+	}
+}
+
+// Resume positions the decoder as it was when s was returned by State(). The
+// decoder must be of the same list, decoded with the same options. Returns
+// ErrBadState, leaving the decoder unchanged, if the offset of s is not the
+// start of a varint of the list.
+func (d *Decoder) Resume(s DecoderState) error {
+	if s.Offset < 0 || s.Offset > len(d.Elements) || (s.Offset > 0 && d.Elements[s.Offset-1] >= 0x80) {
+		return ErrBadState
+	}
+	d.i = s.Offset
+	d.lastSkip = s.LastSkip
+	d.lastTake = s.LastTake - d.opts.takeBias()
+	d.repeat = s.Repeat
+	return nil
+}
+
+// iteratorStateVersion is the first byte of the state of an Iterator, so that
+// its layout can change.
+const iteratorStateVersion = 1
+
+// State returns the position of the iterator as an opaque token, from which
+// it can be resumed with ResumeIterator(). Eg, to continue a listing in a
+// following request:
+//
+//		iter, err := skiptake.ResumeIterator(l, req.Token)
+//		...
+//		for i := 0; i < pageSize; i++ {
+//			v, ok := iter.NextOK()
+//			...
+//		}
+//		resp.Token = iter.State()
+//
+// The token is laid out as uvarints of the decoder state, followed by the
+// iterator's position in the expanded sequence.
+func (t *Iterator) State() []byte {
+	s := t.Decoder.State()
+	b := []byte{iteratorStateVersion}
+	for _, v := range []uint64{uint64(s.Offset), s.LastSkip, s.LastTake, s.Repeat, t.skipSum, t.n, t.take} {
+		b = appendUvarint(b, v)
+	}
+	return b
+}
+
+// ResumeIterator returns an iterator of l positioned as the iterator whose
+// State() returned state. Returns ErrBadState if state is malformed, or does
+// not fit l.
+func ResumeIterator(l List, state []byte) (Iterator, error) {
+	if len(state) == 0 || state[0] != iteratorStateVersion {
+		return Iterator{}, ErrBadState
+	}
+	var v [7]uint64
+	b := state[1:]
+	for i := range v {
+		n, k := binary.Uvarint(b)
+		if k <= 0 {
+			return Iterator{}, ErrBadState
+		}
+		v[i], b = n, b[k:]
+	}
+	if len(b) != 0 || v[0] > uint64(len(l)) {
+		return Iterator{}, ErrBadState
+	}
+	d := l.Decode()
+	if err := d.Resume(DecoderState{Offset: int(v[0]), LastSkip: v[1], LastTake: v[2], Repeat: v[3]}); err != nil {
+		return Iterator{}, err
+	}
+	return Iterator{Decoder: &d, skipSum: v[4], n: v[5], take: v[6]}, nil
+}
@@ -0,0 +1,92 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_DecoderResume(t *testing.T) {
+	l := FromRaw(0, 3, 2, 2, 2, 2, 2, 2, 2, 2, 5, 1, 1, 1, 300, 4, 7, 7, 7, 7)
+	for _, opts := range []Options{{}, {ZeroTakes: true}} {
+		var list List
+		e := list.EncodeWith(opts)
+		d := l.Decode()
+		Transcode(&e, &d)
+
+		var pairs [][2]uint64
+		for d := list.DecodeWith(opts); !d.EOS(); {
+			skip, take := d.Next()
+			pairs = append(pairs, [2]uint64{skip, take})
+		}
+		d = list.DecodeWith(opts)
+		for k := range pairs {
+			saved := d.State()
+			if saved.Offset != d.Position() {
+				t.Errorf("State() offset %d, Position() %d", saved.Offset, d.Position())
+			}
+			r := list.DecodeWith(opts)
+			if err := r.Resume(saved); err != nil {
+				t.Fatal(err)
+			}
+			for j, expected := range pairs[k:] {
+				if skip, take := r.Next(); skip != expected[0] || take != expected[1] {
+					t.Errorf("Options %+v, resumed at %d: pair %d = (%d, %d), expected %v", opts, k, k+j, skip, take, expected)
+				}
+			}
+			if !r.EOS() {
+				t.Errorf("Options %+v, resumed at %d: pairs remain", opts, k)
+			}
+			d.Next()
+		}
+	}
+
+	d := l.Decode()
+	for _, offset := range []int{-1, len(l) + 1} {
+		if err := d.Resume(DecoderState{Offset: offset}); err != ErrBadState {
+			t.Errorf("Resume() at offset %d = %v, expected %v", offset, err, ErrBadState)
+		}
+	}
+	// The skip of 300 is a two byte varint.
+	mid := Create(0, 301)
+	d = mid.Decode()
+	if err := d.Resume(DecoderState{Offset: 2}); err != ErrBadState {
+		t.Errorf("Resume() within a varint = %v, expected %v", err, ErrBadState)
+	}
+}
+
+func Test_IteratorResume(t *testing.T) {
+	l := FromRaw(0, 3, 2, 2, 2, 2, 2, 2, 2, 2, 5, 1, 1, 1, 300, 4, 7, 7, 7, 7)
+	values := l.Expand()
+	iter := l.Iterate()
+	for k := 0; k <= len(values); k++ {
+		r, err := ResumeIterator(l, iter.State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rest []uint64
+		for v, ok := r.NextOK(); ok; v, ok = r.NextOK() {
+			rest = append(rest, v)
+		}
+		if !equalUint64(rest, values[k:]) {
+			t.Errorf("Resumed after %d values: %v, expected %v", k, rest, values[k:])
+		}
+		iter.Next()
+	}
+
+	// Resuming after a Seek().
+	iter.Reset()
+	iter.Seek(10)
+	r, err := ResumeIterator(l, iter.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := r.Next(); v != values[10] {
+		t.Errorf("Resumed after Seek(10): %d, expected %d", v, values[10])
+	}
+
+	state := iter.State()
+	for _, bad := range [][]byte{nil, {2}, state[:len(state)-1], append(state, 0), {1, 200, 1, 0, 0, 0, 0, 0, 0}} {
+		if _, err := ResumeIterator(l, bad); err != ErrBadState {
+			t.Errorf("ResumeIterator(%v) = %v, expected %v", bad, err, ErrBadState)
+		}
+	}
+}
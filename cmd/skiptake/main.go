@@ -0,0 +1,180 @@
+// Command skiptake inspects and manipulates skip-take lists stored in files.
+//
+// Usage:
+//
+//		skiptake [-raw] command [arguments]
+//
+// The commands are:
+//
+//		encode              read values and ranges from stdin, Eg "1 3-7 12", and
+//		                    write them as a list to stdout
+//		decode FILE         print the values of a list, one per line
+//		print FILE          print a list as ranges
+//		explain FILE        print each encoded pair of a list
+//		stats FILE          print the size of a list, and check it is valid
+//		union FILE...       write the union of lists to stdout
+//		intersect FILE...   write the intersection of lists to stdout
+//		diff FILE FILE      write the first list less the second to stdout
+//
+// Lists are read and written as frames (see skiptake.AppendFrame), or as bare
+// lists with -raw. A FILE of "-" reads stdin. Lists written to a terminal are
+// printed as ranges instead.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/arthurt/skiptake"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "skiptake: %v\n", err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("usage: skiptake [-raw] encode|decode|print|explain|stats|union|intersect|diff [FILE...]")
+
+// cli holds the options and streams of one invocation.
+type cli struct {
+	raw    bool
+	text   bool // Write lists as text rather than bytes
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("skiptake", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	c := &cli{stdin: stdin, stdout: stdout, text: isTerminal(stdout)}
+	flags.BoolVar(&c.raw, "raw", false, "read and write bare lists rather than frames")
+	flags.BoolVar(&c.text, "text", c.text, "write lists as ranges rather than bytes")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return errUsage
+	}
+	cmd, files := flags.Arg(0), flags.Args()[1:]
+
+	switch cmd {
+	case "encode":
+		if len(files) != 0 {
+			return errUsage
+		}
+		l, err := parseList(c.stdin)
+		if err != nil {
+			return err
+		}
+		return c.write(l)
+	case "decode", "print", "explain", "stats":
+		if len(files) != 1 {
+			return errUsage
+		}
+		l, err := c.read(files[0])
+		if err != nil {
+			return err
+		}
+		return c.show(cmd, l)
+	case "union", "intersect", "diff":
+		if len(files) == 0 || (cmd == "diff" && len(files) != 2) {
+			return errUsage
+		}
+		lists := make([]skiptake.List, len(files))
+		for i, name := range files {
+			l, err := c.read(name)
+			if err != nil {
+				return err
+			}
+			lists[i] = l
+		}
+		switch cmd {
+		case "union":
+			return c.write(skiptake.Union(lists...))
+		case "intersect":
+			return c.write(skiptake.Intersection(lists...))
+		default:
+			return c.write(skiptake.Difference(lists[0], lists[1]))
+		}
+	}
+	return errUsage
+}
+
+// show writes the output of one of the inspecting commands for l.
+func (c *cli) show(cmd string, l skiptake.List) error {
+	w := bufio.NewWriter(c.stdout)
+	switch cmd {
+	case "decode":
+		iter := l.Iterate()
+		for n := iter.Next(); !iter.EOS(); n = iter.Next() {
+			fmt.Fprintln(w, n)
+		}
+	case "print":
+		fmt.Fprintln(w, l.FormatWith(skiptake.FormatOptions{}))
+	case "explain":
+		io.WriteString(w, l.Explain())
+	case "stats":
+		fmt.Fprintln(w, l.SizeStats())
+		if err := l.Validate(); err != nil {
+			fmt.Fprintf(w, "invalid: %v\n", err)
+		}
+	}
+	return w.Flush()
+}
+
+// read reads the list held by the named file, or stdin for "-".
+func (c *cli) read(name string) (skiptake.List, error) {
+	var b []byte
+	var err error
+	if name == "-" {
+		b, err = ioutil.ReadAll(c.stdin)
+	} else {
+		b, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.raw {
+		l := skiptake.List(b)
+		if err := l.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return l, nil
+	}
+	l, err := skiptake.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return l, nil
+}
+
+// write writes l to stdout, as text or as bytes.
+func (c *cli) write(l skiptake.List) error {
+	var err error
+	switch {
+	case c.text:
+		_, err = fmt.Fprintln(c.stdout, l.FormatWith(skiptake.FormatOptions{}))
+	case c.raw:
+		_, err = c.stdout.Write(l)
+	default:
+		_, err = c.stdout.Write(l.AppendTo(nil))
+	}
+	return err
+}
+
+// isTerminal returns whether w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arthurt/skiptake"
+)
+
+func Test_ParseList(t *testing.T) {
+	testCases := map[string]string{
+		"":                           "",
+		"1 2 3":                      "[1 - 3]",
+		"7, 3-5\n1":                  "1, [3 - 5], 7",
+		"0x10 - 0x12, [20 - 30], 25": "[16 - 18], [20 - 30]",
+		"5-9 1-6 12":                 "[1 - 9], 12",
+	}
+	for input, expected := range testCases {
+		l, err := parseList(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("parseList(%q) error: %v", input, err)
+		} else if l.String() != expected {
+			t.Errorf("parseList(%q) = %v, expected %s", input, l, expected)
+		}
+	}
+
+	for _, input := range []string{"x", "5-3", "1-", "0-18446744073709551615", "-1"} {
+		if l, err := parseList(strings.NewReader(input)); err == nil {
+			t.Errorf("parseList(%q) = %v, expected an error", input, l)
+		}
+	}
+}
+
+func Test_Run(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, values string, args ...string) string {
+		var out bytes.Buffer
+		if err := run(append(args, "encode"), strings.NewReader(values), &out); err != nil {
+			t.Fatalf("encode %q: %v", values, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, out.Bytes(), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a", "1-10 20-30")
+	b := write("b", "5-25")
+	raw := write("raw", "5-25", "-raw")
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"print", a}, "[1 - 10], [20 - 30]\n"},
+		{[]string{"decode", b}, "5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20\n21\n22\n23\n24\n25\n"},
+		{[]string{"-text", "union", a, b}, "[1 - 30]\n"},
+		{[]string{"-text", "intersect", a, b}, "[5 - 10], [20 - 25]\n"},
+		{[]string{"-text", "diff", a, b}, "[1 - 4], [26 - 30]\n"},
+		{[]string{"-raw", "print", raw}, "[5 - 25]\n"},
+		{[]string{"explain", b}, skiptake.FromRaw(5, 21).Explain()},
+		{[]string{"stats", b}, skiptake.FromRaw(5, 21).SizeStats().String() + "\n"},
+	}
+	for _, c := range testCases {
+		var out bytes.Buffer
+		if err := run(c.args, nil, &out); err != nil {
+			t.Errorf("run(%v) error: %v", c.args, err)
+		} else if out.String() != c.expected {
+			t.Errorf("run(%v) = %q, expected %q", c.args, out.String(), c.expected)
+		}
+	}
+
+	// Lists are written as frames, or bare with -raw.
+	var out bytes.Buffer
+	if err := run([]string{"union", a, b}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if l, err := skiptake.Unmarshal(out.Bytes()); err != nil || l.String() != "[1 - 30]" {
+		t.Errorf("union wrote %v, %v", l, err)
+	}
+	out.Reset()
+	if err := run([]string{"-raw", "print", "-"}, bytes.NewReader(skiptake.FromRaw(3, 2)), &out); err != nil || out.String() != "[3 - 4]\n" {
+		t.Errorf("print of stdin wrote %q, %v", out.String(), err)
+	}
+
+	for _, args := range [][]string{{}, {"bogus"}, {"print"}, {"diff", a}, {"encode", a}, {"-bogus", "print", a}} {
+		if err := run(args, nil, &out); !errors.Is(err, errUsage) {
+			t.Errorf("run(%v) = %v, expected usage error", args, err)
+		}
+	}
+	if err := run([]string{"print", raw}, nil, &out); err == nil {
+		t.Errorf("run(print) of bare list succeeded")
+	}
+	if err := run([]string{"print", filepath.Join(dir, "missing")}, nil, &out); err == nil {
+		t.Errorf("run(print) of missing file succeeded")
+	}
+}
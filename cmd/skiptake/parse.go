@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/arthurt/skiptake"
+)
+
+// rangeDash matches the dash of a range, with any surrounding space.
+var rangeDash = regexp.MustCompile(`\s*-\s*`)
+
+// parseList reads values and inclusive ranges from r, in any order, and
+// returns the list holding them. Values are decimal, or hexadecimal with a 0x
+// prefix, and ranges are two values joined by a dash. Values and ranges are
+// separated by space or commas, and brackets are ignored, so the output of
+// List.String() can be read back. Eg:
+//
+//		1, 3-7, 0x10 - 0x1f, [40 - 50]
+//
+func parseList(r io.Reader) (skiptake.List, error) {
+	in, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := rangeDash.ReplaceAllString(string(in), "-")
+	fields := strings.FieldsFunc(text, func(c rune) bool {
+		return unicode.IsSpace(c) || c == ',' || c == '[' || c == ']'
+	})
+
+	intervals := make([][2]uint64, 0, len(fields))
+	for _, f := range fields {
+		var iv [2]uint64
+		first, last := f, f
+		if i := strings.IndexByte(f, '-'); i >= 0 {
+			first, last = f[:i], f[i+1:]
+		}
+		if iv[0], err = strconv.ParseUint(first, 0, 64); err != nil {
+			return nil, fmt.Errorf("bad value %q", f)
+		}
+		if iv[1], err = strconv.ParseUint(last, 0, 64); err != nil || iv[1] < iv[0] {
+			return nil, fmt.Errorf("bad range %q", f)
+		}
+		intervals = append(intervals, iv)
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i][0] < intervals[j][0]
+	})
+
+	l := skiptake.List{}
+	b := skiptake.Build(&l)
+	for i := 0; i < len(intervals); {
+		// Merge overlapping intervals. The builder joins abutting ones.
+		first, last := intervals[i][0], intervals[i][1]
+		for i++; i < len(intervals) && intervals[i][0] <= last; i++ {
+			if intervals[i][1] > last {
+				last = intervals[i][1]
+			}
+		}
+		if first == 0 && last == math.MaxUint64 {
+			return nil, fmt.Errorf("range [0 - %d] cannot be represented", last)
+		}
+		b.Next(first)
+		b.Take(last - first)
+	}
+	return b.Finish(), nil
+}
@@ -0,0 +1,158 @@
+package skiptake
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PairEncoder is the interface implemented by types which pack a sequence of
+// (skip, take) pairs into bytes, such as *Encoder.
+//
+// Encoders may buffer pairs internally, so Flush must be called once all pairs
+// have been added for the output to be complete.
+type PairEncoder interface {
+	Add(skip, take uint64)
+	Flush()
+}
+
+// PairDecoder is the interface implemented by types which unpack a sequence of
+// (skip, take) pairs from bytes, such as *Decoder.
+type PairDecoder interface {
+	Next() (skip, take uint64)
+	EOS() bool
+}
+
+// Transcode reads every remaining pair from src and adds it to dst, flushing
+// dst once src is exhausted. It can be used to convert a list between byte
+// packings, Eg:
+//
+//		var packed []byte
+//		d := list.Decode()
+//		Transcode(&GroupEncoder{Elements: &packed}, &d)
+//
+// Runs of repeated pairs are copied in one step between a *Decoder and an
+// *Encoder.
+func Transcode(dst PairEncoder, src PairDecoder) {
+	d, decodesRuns := src.(*Decoder)
+	e, encodesRuns := dst.(runEncoder)
+	for !src.EOS() {
+		if decodesRuns && encodesRuns {
+			e.addRun(d.NextRun())
+		} else {
+			dst.Add(src.Next())
+		}
+	}
+	dst.Flush()
+}
+
+// CodecID identifies a byte packing of skip-take pairs within a frame.
+type CodecID uint8
+
+// The built-in codecs. IDs from CodecUser upwards are reserved for codecs
+// registered by users of this package.
+const (
+	// CodecVarint is the packing used by List.
+	CodecVarint CodecID = iota
+	// CodecVarintZeroSkips is the List packing with Options{ZeroSkips: true}.
+	CodecVarintZeroSkips
+	// CodecGroup is the packing of GroupEncoder.
+	CodecGroup
+	// CodecSimple8b is the packing of Simple8bEncoder.
+	CodecSimple8b
+	// CodecDict is the packing of DictEncoder.
+	CodecDict
+
+	CodecUser CodecID = 128
+)
+
+// Codec is a registered byte packing of skip-take pairs.
+type Codec struct {
+	Name string
+	// NewEncoder returns an encoder which appends to *b.
+	NewEncoder func(b *[]byte) PairEncoder
+	// NewDecoder returns a decoder which reads from b. A decoder which can
+	// detect a malformed payload reports it from an Err() error method.
+	NewDecoder func(b []byte) PairDecoder
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[CodecID]Codec{}
+)
+
+func init() {
+	RegisterCodec(CodecVarint, Codec{
+		Name: "varint",
+		NewEncoder: func(b *[]byte) PairEncoder {
+			e := (*List)(b).Encode()
+			return &e
+		},
+		NewDecoder: func(b []byte) PairDecoder {
+			d := List(b).Decode()
+			return &d
+		},
+	})
+	RegisterCodec(CodecVarintZeroSkips, Codec{
+		Name: "varint-zeroskips",
+		NewEncoder: func(b *[]byte) PairEncoder {
+			e := (*List)(b).EncodeWith(Options{ZeroSkips: true})
+			return &e
+		},
+		NewDecoder: func(b []byte) PairDecoder {
+			d := List(b).DecodeWith(Options{ZeroSkips: true})
+			return &d
+		},
+	})
+	RegisterCodec(CodecGroup, Codec{
+		Name:       "group",
+		NewEncoder: func(b *[]byte) PairEncoder { return &GroupEncoder{Elements: b} },
+		NewDecoder: func(b []byte) PairDecoder { return &GroupDecoder{Elements: b} },
+	})
+	RegisterCodec(CodecSimple8b, Codec{
+		Name:       "simple8b",
+		NewEncoder: func(b *[]byte) PairEncoder { return &Simple8bEncoder{Elements: b} },
+		NewDecoder: func(b []byte) PairDecoder { return &Simple8bDecoder{Elements: b} },
+	})
+	RegisterCodec(CodecDict, Codec{
+		Name:       "dict",
+		NewEncoder: func(b *[]byte) PairEncoder { return &DictEncoder{Elements: b} },
+		NewDecoder: func(b []byte) PairDecoder { return &DictDecoder{Elements: b} },
+	})
+}
+
+// RegisterCodec makes a codec available under the passed ID for use in frames.
+// If RegisterCodec is called twice with the same ID, or with a codec lacking
+// an encoder or decoder, it panics.
+func RegisterCodec(id CodecID, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if c.NewEncoder == nil || c.NewDecoder == nil {
+		panic("skiptake: RegisterCodec codec is incomplete")
+	}
+	if _, dup := codecs[id]; dup {
+		panic(fmt.Sprintf("skiptake: RegisterCodec called twice for codec %d", id))
+	}
+	codecs[id] = c
+}
+
+// LookupCodec returns the codec registered under the passed ID.
+func LookupCodec(id CodecID) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[id]
+	return c, ok
+}
+
+// registeredCodecs returns the IDs of every registered codec, in ascending
+// order.
+func registeredCodecs() []CodecID {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	ids := make([]CodecID, 0, len(codecs))
+	for id := range codecs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,67 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+// codecTestCases are sequences of (skip, take) pairs that every byte packing
+// should round-trip exactly.
+var codecTestCases = map[string][][2]uint64{
+	"Empty":         {},
+	"DefaultState":  {{0, 1}},
+	"ZeroSkipStart": {{0, 5000000000}},
+	"SkipStart":     {{5, 100}},
+	"SingleOffset":  {{30, 1}},
+	"RepeatTake":    {{0, 2}, {2, 2}},
+	"Average": {
+		{0, 1}, {1, 1}, {1, 1}, {1, 1}, {83, 1}, {3, 4}, {100, 1}, {32, 2},
+	},
+	"Large": {
+		{0x100000000, 0x200000000},
+		{0x400000000000, 0x2000000000000},
+		{0x8000000000000000, 0x8000000000000000},
+		{0xffffffffffffffff, 0xffffffffffffffff},
+	},
+	"MidZeroSkip":     {{9, 1}, {0, 1}, {1, 1}},
+	"MidZeroTake":     {{9, 1}, {3, 0}, {1, 1}},
+	"StartZeroTake":   {{0, 0}, {3, 1}, {1, 1}},
+	"MidZeroSkipTake": {{0, 4}, {0, 0}, {50, 50}},
+}
+
+func testPairCodec(t *testing.T, values [][2]uint64, enc PairEncoder, dec func() PairDecoder) {
+	for _, pair := range values {
+		enc.Add(pair[0], pair[1])
+	}
+	enc.Flush()
+
+	d := dec()
+	for _, pair := range values {
+		if d.EOS() {
+			t.Fatalf("Decoder reached end-of-sequence early")
+		}
+		skip, take := d.Next()
+		if skip != pair[0] || take != pair[1] {
+			t.Fatalf("Decoded list differs from encoded values. (%d != %d) || (%d != %d)", skip, pair[0], take, pair[1])
+		}
+	}
+	if !d.EOS() {
+		skip, take := d.Next()
+		t.Fatalf("Decoder has more symbols than encoded. Read %d, %d", skip, take)
+	}
+}
+
+func Test_Transcode(t *testing.T) {
+	list := FromRaw(3, 4, 10, 1, 1, 1, 20, 3)
+
+	var packed []byte
+	d := list.Decode()
+	Transcode(&GroupEncoder{Elements: &packed}, &d)
+
+	var result List
+	e := result.Encode()
+	Transcode(&e, &GroupDecoder{Elements: packed})
+
+	if !Equal(list, result) {
+		t.Errorf("%v != %v", result, list)
+	}
+}
@@ -0,0 +1,142 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// Complemented is a set of all values except those of a list, that is the
+// complement of the list within [0, math.MaxUint64], held without being
+// built.
+//
+// The complement of a small list spans almost the whole uint64 domain, so
+// calling Expand() or allocating by Len() on the result of Complement() is a
+// hazard. Set operations between a Complemented and a List are instead
+// simplified algebraically, and only ever build lists no larger than their
+// inputs. Eg:
+//
+//		blocked := Not(denied)
+//		allowed := blocked.Intersection(requested) // requested minus denied
+//
+type Complemented struct {
+	// Excluded is the list of values not in the set.
+	Excluded List
+}
+
+// Not returns the set of all values which are not members of l.
+func Not(l List) Complemented {
+	return Complemented{Excluded: l}
+}
+
+// Universe returns the set of all values, [0, math.MaxUint64].
+func Universe() Complemented {
+	return Complemented{Excluded: List{}}
+}
+
+// Contains returns true if v is a member of the set, that is v is not excluded.
+func (c Complemented) Contains(v uint64) bool {
+	return !c.Excluded.Contains(v)
+}
+
+// Len returns how many values are in the set. As the set of all values has 2^64
+// members, which cannot be represented, full is returned as true in that case,
+// with n as zero.
+func (c Complemented) Len() (n uint64, full bool) {
+	excluded := c.Excluded.Len()
+	if excluded == 0 {
+		return 0, true
+	}
+	return math.MaxUint64 - excluded + 1, false
+}
+
+// Complement returns the complement of the set, which is the excluded list.
+func (c Complemented) Complement() List {
+	return c.Excluded
+}
+
+// Union returns the union of the set and the passed lists. Values of the lists
+// are no longer excluded.
+func (c Complemented) Union(lists ...List) Complemented {
+	return Not(Difference(c.Excluded, Union(lists...)))
+}
+
+// Intersection returns the intersection of the set and the passed list, which
+// is the values of l that are not excluded.
+func (c Complemented) Intersection(l List) List {
+	return Difference(l, c.Excluded)
+}
+
+// Difference returns the set with the values of the passed lists removed, by
+// excluding them too.
+func (c Complemented) Difference(lists ...List) Complemented {
+	return Not(Union(append([]List{c.Excluded}, lists...)...))
+}
+
+// From returns the values of l that are not members of the set, that is
+// l minus the set.
+func (c Complemented) From(l List) List {
+	return Intersection(l, c.Excluded)
+}
+
+// UnionNot returns the union of complemented sets, which excludes only those
+// values excluded by all of them.
+func UnionNot(a Complemented, sets ...Complemented) Complemented {
+	lists := []List{a.Excluded}
+	for i := range sets {
+		lists = append(lists, sets[i].Excluded)
+	}
+	return Not(Intersection(lists...))
+}
+
+// IntersectionNot returns the intersection of complemented sets, which excludes
+// the values excluded by any of them. The intersection of no sets is the
+// Universe().
+func IntersectionNot(sets ...Complemented) Complemented {
+	lists := make([]List, len(sets))
+	for i := range sets {
+		lists[i] = sets[i].Excluded
+	}
+	return Not(Union(lists...))
+}
+
+// Intervals returns the intervals of the set, computed lazily from the
+// excluded list. This allows a Complemented to be passed to UnionOf or
+// IntersectionOf. Note that the result of UnionOf would be a List of nearly the
+// whole domain.
+func (c Complemented) Intervals() Intervals {
+	iter := c.Excluded.Iterate()
+	return &complementIntervals{src: &iter}
+}
+
+// List builds the set as a List, which is Complement(c.Excluded). The set of
+// all values cannot be built, see Complement().
+func (c Complemented) List() List {
+	return Complement(c.Excluded)
+}
+
+// complementIntervals yields the gaps between the intervals of src, within
+// [0, math.MaxUint64].
+type complementIntervals struct {
+	src  Intervals
+	n    uint64 // First value not yet passed
+	done bool
+}
+
+func (c *complementIntervals) NextInterval() (first, last uint64) {
+	for !c.done {
+		f, l := c.src.NextInterval()
+		if f > l {
+			c.done = true
+			return c.n, math.MaxUint64
+		}
+		first, last = c.n, f-1
+		if l == math.MaxUint64 {
+			c.done = true
+		} else {
+			c.n = l + 1
+		}
+		if f > first {
+			return
+		}
+	}
+	return math.MaxUint64, 0
+}
@@ -0,0 +1,79 @@
+package skiptake
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_Complemented(t *testing.T) {
+	denied := makeRange(intrv{10, 19}, intrv{50, 50})
+	c := Not(denied)
+
+	for _, v := range []uint64{0, 9, 10, 19, 20, 50, 51, math.MaxUint64} {
+		if c.Contains(v) == denied.Contains(v) {
+			t.Errorf("Contains(%d) = %v", v, c.Contains(v))
+		}
+	}
+
+	n, full := c.Len()
+	expectUint64(t, n, math.MaxUint64-10)
+	if full {
+		t.Errorf("Len() reported full set")
+	}
+	if n, full := Universe().Len(); !full || n != 0 {
+		t.Errorf("Universe().Len() = %d, %v", n, full)
+	}
+
+	requested := makeRange(intrv{0, 14}, intrv{45, 55})
+	if result, expected := c.Intersection(requested), Difference(requested, denied); !Equal(result, expected) {
+		t.Errorf("Intersection() = %v, expected %v", result, expected)
+	}
+	if result, expected := c.From(requested), Intersection(requested, denied); !Equal(result, expected) {
+		t.Errorf("From() = %v, expected %v", result, expected)
+	}
+	if result, expected := c.Union(requested), Not(makeRange(intrv{15, 19})); !Equal(result.Excluded, expected.Excluded) {
+		t.Errorf("Union() = %v, expected %v", result.Excluded, expected.Excluded)
+	}
+	if result, expected := c.Difference(requested), Not(Union(denied, requested)); !Equal(result.Excluded, expected.Excluded) {
+		t.Errorf("Difference() = %v, expected %v", result.Excluded, expected.Excluded)
+	}
+	if !Equal(c.Complement(), denied) {
+		t.Errorf("Complement() = %v, expected %v", c.Complement(), denied)
+	}
+	if !Equal(c.List(), Complement(denied)) {
+		t.Errorf("List() = %v, expected %v", c.List(), Complement(denied))
+	}
+
+	other := Not(makeRange(intrv{15, 30}))
+	if result := UnionNot(c, other); !Equal(result.Excluded, makeRange(intrv{15, 19})) {
+		t.Errorf("UnionNot() = %v", result.Excluded)
+	}
+	if result := IntersectionNot(c, other); !Equal(result.Excluded, makeRange(intrv{10, 30}, intrv{50, 50})) {
+		t.Errorf("IntersectionNot() = %v", result.Excluded)
+	}
+	if result := IntersectionNot(); len(result.Excluded) != 0 {
+		t.Errorf("IntersectionNot() of no sets = %v", result.Excluded)
+	}
+}
+
+func Test_ComplementedIntervals(t *testing.T) {
+	lists := []List{
+		makeRange(intrv{10, 19}, intrv{50, 50}),
+		makeRange(intrv{0, 19}, intrv{50, 50}),
+		makeRange(intrv{10, 19}, intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+		makeRange(intrv{0, 0}, intrv{0xffffffffffffffff, 0xffffffffffffffff}),
+	}
+	for _, l := range lists {
+		result := UnionOf(Not(l).Intervals())
+		if expected := Complement(l); !Equal(result, expected) {
+			t.Errorf("Not(%v).Intervals() = %v, expected %v", l, result, expected)
+		}
+	}
+
+	// Lazily intersected with a list, without building the complement.
+	l := lists[0]
+	iter := makeRange(intrv{5, 60}).Iterate()
+	if result, expected := IntersectionOf(Not(l).Intervals(), &iter), makeRange(intrv{5, 9}, intrv{20, 49}, intrv{51, 60}); !Equal(result, expected) {
+		t.Errorf("IntersectionOf() = %v, expected %v", result, expected)
+	}
+}
@@ -0,0 +1,109 @@
+package skiptake
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+)
+
+// Compressor is a general-purpose compression scheme applied on top of the
+// List packing by CompressedList. Flate is provided using the standard
+// library. Others, such as zstd, can be supplied by implementing this
+// interface around a third-party package.
+type Compressor interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Flate is a Compressor using compress/flate. Level is a compress/flate level.
+// As zero is flate.NoCompression, which defeats the purpose, a Level of zero
+// is taken to mean flate.DefaultCompression.
+type Flate struct {
+	Level int
+}
+
+// NewWriter implements Compressor.
+func (f Flate) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := f.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	return flate.NewWriter(w, level)
+}
+
+// NewReader implements Compressor.
+func (f Flate) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+// CompressedList holds a list further compressed by a Compressor, for lists
+// which are stored for long periods but rarely read. The list is decompressed
+// on first use, and kept until Release() is called.
+//
+// A CompressedList is not safe for concurrent use.
+type CompressedList struct {
+	data []byte
+	c    Compressor
+	list List
+}
+
+// Compress returns the list l compressed by c.
+func Compress(l List, c Compressor) (*CompressedList, error) {
+	var buf bytes.Buffer
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(l); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return &CompressedList{data: buf.Bytes(), c: c}, nil
+}
+
+// NewCompressedList returns a CompressedList for data previously returned by
+// CompressedList.Bytes(), which was compressed with c.
+func NewCompressedList(data []byte, c Compressor) *CompressedList {
+	return &CompressedList{data: data, c: c}
+}
+
+// Bytes returns the compressed representation of the list.
+func (cl *CompressedList) Bytes() []byte {
+	return cl.data
+}
+
+// List returns the decompressed list. The list is decompressed on the first
+// call, and the same List returned until Release() is called.
+func (cl *CompressedList) List() (List, error) {
+	if cl.list != nil {
+		return cl.list, nil
+	}
+	r, err := cl.c.NewReader(bytes.NewReader(cl.data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cl.list = List(b)
+	return cl.list, nil
+}
+
+// Iterate returns a new skiptake.Iterator for the decompressed list.
+func (cl *CompressedList) Iterate() (Iterator, error) {
+	l, err := cl.List()
+	if err != nil {
+		return Iterator{}, err
+	}
+	return l.Iterate(), nil
+}
+
+// Release discards the decompressed list, if any. It will be decompressed
+// again on next use.
+func (cl *CompressedList) Release() {
+	cl.list = nil
+}
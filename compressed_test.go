@@ -0,0 +1,72 @@
+package skiptake
+
+import (
+	"io"
+	"testing"
+)
+
+func Test_CompressedList(t *testing.T) {
+	b := Build(&List{})
+	for v := uint64(0); v < 100000; v += 3 + v%11 {
+		b.Next(v)
+	}
+	list := b.Finish()
+
+	cl, err := Compress(list, Flate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("List of %d bytes compressed to %d bytes", len(list), len(cl.Bytes()))
+
+	reopened := NewCompressedList(cl.Bytes(), Flate{})
+	result, err := reopened.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(result, list) {
+		t.Errorf("%v != %v", result, list)
+	}
+
+	iter, err := reopened.Iterate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectUint64(t, iter.Next(), 0)
+	expectUint64(t, iter.Next(), 3)
+
+	reopened.Release()
+	result, err = reopened.List()
+	if err != nil || !Equal(result, list) {
+		t.Errorf("After Release(): %v, %v", result, err)
+	}
+}
+
+// nopCompressor stands in for a third-party compressor.
+type nopCompressor struct{}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func (nopCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (nopCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+func Test_CompressedListHook(t *testing.T) {
+	list := Create(1, 2, 3, 10, 11, 50)
+	cl, err := Compress(list, nopCompressor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(List(cl.Bytes()), list) {
+		t.Errorf("%v != %v", List(cl.Bytes()), list)
+	}
+	result, err := cl.List()
+	if err != nil || !Equal(result, list) {
+		t.Errorf("%v != %v: %v", result, list, err)
+	}
+}
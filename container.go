@@ -0,0 +1,281 @@
+package skiptake
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+// A container is a single file holding many named lists. It is laid out as:
+//
+//		| magic "SKTC" | version (1 byte) |
+//		| list data ... |
+//		| directory |
+//		| directory offset (8 bytes, little-endian) | magic "SKTC" |
+//
+// The list data is the List bytes of each list, one after the other. The
+// directory follows, holding an entry for each list, in the order added:
+//
+//		| name length (uvarint) | name | offset (uvarint) | size (uvarint) |
+//		| len (uvarint) | first (uvarint) | last (uvarint) |
+//
+// The footer at the end of the file locates the directory, so a reader can
+// read the directory, and then any one list, without scanning the rest.
+
+const containerMagic = "SKTC"
+const containerVersion = 1
+const containerFooterLen = 8 + len(containerMagic)
+
+var (
+	// ErrBadContainer is returned when a container file is malformed.
+	ErrBadContainer = errors.New("skiptake: malformed container")
+
+	// ErrDuplicateName is returned when adding a list to a container with a
+	// name already in use.
+	ErrDuplicateName = errors.New("skiptake: duplicate list name")
+
+	// ErrNotFound is returned when opening a list not in a container.
+	ErrNotFound = errors.New("skiptake: list not found")
+)
+
+// ContainerEntry is the directory entry of a list in a container.
+type ContainerEntry struct {
+	Name   string
+	Offset int64  // Offset of the list data from the start of the file
+	Size   int64  // Size of the list data in bytes
+	Len    uint64 // How many values are in the list
+	First  uint64 // The least value of the list, if Len > 0
+	Last   uint64 // The greatest value of the list, if Len > 0
+}
+
+// ContainerWriter writes lists to a container file.
+type ContainerWriter struct {
+	w       io.Writer
+	off     int64
+	entries []ContainerEntry
+	names   map[string]bool
+}
+
+// NewContainerWriter starts a new container written to w.
+func NewContainerWriter(w io.Writer) (*ContainerWriter, error) {
+	cw := &ContainerWriter{w: w, names: map[string]bool{}}
+	if err := cw.write(append([]byte(containerMagic), containerVersion)); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Add writes the list l to the container under the passed name.
+func (cw *ContainerWriter) Add(name string, l List) error {
+	if cw.names[name] {
+		return ErrDuplicateName
+	}
+	entry := ContainerEntry{Name: name, Offset: cw.off, Size: int64(len(l))}
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if entry.Len == 0 {
+			entry.First = first
+		}
+		entry.Last = last
+		entry.Len += last - first + 1
+	}
+	if err := cw.write(l); err != nil {
+		return err
+	}
+	cw.names[name] = true
+	cw.entries = append(cw.entries, entry)
+	return nil
+}
+
+// Close writes the directory of the container. It does not close the
+// underlying writer.
+func (cw *ContainerWriter) Close() error {
+	var dir []byte
+	for _, e := range cw.entries {
+		dir = appendUvarint(dir, uint64(len(e.Name)))
+		dir = append(dir, e.Name...)
+		dir = appendUvarint(dir, uint64(e.Offset))
+		dir = appendUvarint(dir, uint64(e.Size))
+		dir = appendUvarint(dir, e.Len)
+		dir = appendUvarint(dir, e.First)
+		dir = appendUvarint(dir, e.Last)
+	}
+	var footer [8]byte
+	binary.LittleEndian.PutUint64(footer[:], uint64(cw.off))
+	dir = append(dir, footer[:]...)
+	dir = append(dir, containerMagic...)
+	return cw.write(dir)
+}
+
+func (cw *ContainerWriter) write(b []byte) error {
+	n, err := cw.w.Write(b)
+	cw.off += int64(n)
+	return err
+}
+
+// ContainerReader reads lists from a container file.
+type ContainerReader struct {
+	r       io.ReaderAt
+	entries []ContainerEntry
+	index   map[string]int
+}
+
+// OpenContainer reads the directory of the container held by r, which is size
+// bytes long.
+func OpenContainer(r io.ReaderAt, size int64) (*ContainerReader, error) {
+	header := make([]byte, len(containerMagic)+1)
+	footer := make([]byte, containerFooterLen)
+	if size < int64(len(header)+len(footer)) {
+		return nil, ErrBadContainer
+	}
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(footer, size-int64(len(footer))); err != nil {
+		return nil, err
+	}
+	if string(header[:len(containerMagic)]) != containerMagic ||
+		header[len(containerMagic)] != containerVersion ||
+		string(footer[8:]) != containerMagic {
+		return nil, ErrBadContainer
+	}
+
+	dirOff := binary.LittleEndian.Uint64(footer)
+	dirEnd := size - int64(len(footer))
+	if dirOff > uint64(dirEnd) {
+		return nil, ErrBadContainer
+	}
+	// The bytes left of the directory are those not yet read from section,
+	// and those buffered by dir.
+	section := &io.LimitedReader{R: io.NewSectionReader(r, int64(dirOff), dirEnd-int64(dirOff)), N: dirEnd - int64(dirOff)}
+	dir := bufio.NewReader(section)
+
+	cr := &ContainerReader{r: r, index: map[string]int{}}
+	for {
+		nameLen, err := binary.ReadUvarint(dir)
+		if err == io.EOF {
+			break
+		}
+		var v [5]uint64
+		var name []byte
+		if err == nil {
+			if nameLen > uint64(section.N)+uint64(dir.Buffered()) {
+				return nil, ErrBadContainer
+			}
+			name = make([]byte, nameLen)
+			_, err = io.ReadFull(dir, name)
+		}
+		for i := range v {
+			if err == nil {
+				v[i], err = binary.ReadUvarint(dir)
+			}
+		}
+		if err != nil || v[1] > dirOff || v[0] > dirOff-v[1] {
+			return nil, ErrBadContainer
+		}
+		e := ContainerEntry{
+			Name:   string(name),
+			Offset: int64(v[0]),
+			Size:   int64(v[1]),
+			Len:    v[2],
+			First:  v[3],
+			Last:   v[4],
+		}
+		cr.index[e.Name] = len(cr.entries)
+		cr.entries = append(cr.entries, e)
+	}
+	return cr, nil
+}
+
+// Entries returns the directory entries of all lists in the container.
+func (cr *ContainerReader) Entries() []ContainerEntry {
+	return cr.entries
+}
+
+// Lookup returns the directory entry of the named list.
+func (cr *ContainerReader) Lookup(name string) (ContainerEntry, bool) {
+	i, ok := cr.index[name]
+	if !ok {
+		return ContainerEntry{}, false
+	}
+	return cr.entries[i], true
+}
+
+// Open reads the named list from the container. Only the bytes of that list
+// are read.
+func (cr *ContainerReader) Open(name string) (List, error) {
+	e, ok := cr.Lookup(name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	l := make(List, e.Size)
+	if _, err := cr.r.ReadAt(l, e.Offset); err != nil {
+		return nil, err
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ByteRange is a span of bytes of a container holding the data of one or more
+// lists, which lie one after the other in the file.
+type ByteRange struct {
+	Offset  int64            // Offset from the start of the file
+	Size    int64            // Size in bytes
+	Entries []ContainerEntry // The lists held by the range, in file order
+}
+
+// ByteRanges returns the spans of the container holding the lists which may
+// hold values within [lo, hi], as found from their directory entries. Lists
+// which lie one after the other are returned as a single span. A container
+// whose lists are segments of one long list, as for Segments, is then a paged
+// format: a query of a range of values need only fetch the segments within it,
+// such as by ranged GETs from object storage. Eg:
+//
+//		for _, r := range cr.ByteRanges(lo, hi) {
+//			data := fetch(r.Offset, r.Size)
+//			lists, err := r.Lists(data)
+//			...
+//		}
+//
+func (cr *ContainerReader) ByteRanges(lo, hi uint64) []ByteRange {
+	var entries []ContainerEntry
+	for _, e := range cr.entries {
+		if e.Len > 0 && e.First <= hi && e.Last >= lo {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Offset < entries[j].Offset })
+
+	var ranges []ByteRange
+	for i, e := range entries {
+		if k := len(ranges) - 1; k >= 0 && ranges[k].Offset+ranges[k].Size == e.Offset {
+			ranges[k].Size += e.Size
+			ranges[k].Entries = entries[i-len(ranges[k].Entries) : i+1 : i+1]
+			continue
+		}
+		ranges = append(ranges, ByteRange{Offset: e.Offset, Size: e.Size, Entries: entries[i : i+1 : i+1]})
+	}
+	return ranges
+}
+
+// Lists returns the lists held by data, the bytes of the span read from the
+// container. Returns ErrBadContainer if data is not Size bytes long, or
+// ErrTruncated or ErrOverflow if a list is malformed.
+func (r ByteRange) Lists(data []byte) ([]List, error) {
+	if int64(len(data)) != r.Size {
+		return nil, ErrBadContainer
+	}
+	lists := make([]List, len(r.Entries))
+	for i, e := range r.Entries {
+		start := e.Offset - r.Offset
+		lists[i] = List(data[start : start+e.Size])
+		if err := lists[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return lists, nil
+}
@@ -0,0 +1,161 @@
+package skiptake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func Test_Container(t *testing.T) {
+	lists := map[string]List{
+		"first":  Create(1, 2, 3, 10),
+		"empty":  List{},
+		"large":  makeRange(intrv{100, 100000}, intrv{200000, 300000}),
+		"max":    Create(0xffffffffffffffff),
+		"":       Create(7),
+		"second": Create(4, 5, 6),
+	}
+	names := []string{"first", "empty", "large", "max", "", "second"}
+
+	var buf bytes.Buffer
+	cw, err := NewContainerWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err := cw.Add(name, lists[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Add("first", List{}); err != ErrDuplicateName {
+		t.Errorf("Add() of duplicate name: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("Container of %d bytes", buf.Len())
+
+	cr, err := OpenContainer(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := cr.Entries()
+	if len(entries) != len(names) {
+		t.Fatalf("%d entries, expected %d", len(entries), len(names))
+	}
+	for i, e := range entries {
+		if e.Name != names[i] {
+			t.Errorf("Entry %d named %q, expected %q", i, e.Name, names[i])
+		}
+		l := lists[e.Name]
+		expectUint64(t, e.Len, l.Len())
+		if values := l.Expand(); len(values) > 0 {
+			expectUint64(t, e.First, values[0])
+			expectUint64(t, e.Last, values[len(values)-1])
+		}
+
+		result, err := cr.Open(e.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(result, l) {
+			t.Errorf("%q: %v != %v", e.Name, result, l)
+		}
+	}
+
+	if _, err := cr.Open("missing"); err != ErrNotFound {
+		t.Errorf("Open() of missing list: %v", err)
+	}
+
+	b := buf.Bytes()
+	if _, err := OpenContainer(bytes.NewReader(b[:len(b)-1]), int64(len(b)-1)); err != ErrBadContainer {
+		t.Errorf("OpenContainer() of truncated container: %v", err)
+	}
+}
+
+func Test_ContainerBadDirectory(t *testing.T) {
+	// A container with one list of 3 bytes, followed by the passed directory.
+	container := func(dir ...byte) []byte {
+		b := append([]byte(containerMagic), containerVersion, 1, 2, 3)
+		dirOff := uint64(len(b))
+		b = append(b, dir...)
+		var footer [8]byte
+		binary.LittleEndian.PutUint64(footer[:], dirOff)
+		return append(append(b, footer[:]...), containerMagic...)
+	}
+	open := func(b []byte) error {
+		_, err := OpenContainer(bytes.NewReader(b), int64(len(b)))
+		return err
+	}
+
+	if err := open(container(1, 'a', 5, 3, 1, 0, 0)); err != nil {
+		t.Fatalf("OpenContainer() of valid directory: %v", err)
+	}
+	maxSize := appendUvarint(nil, math.MaxUint64)
+	for name, dir := range map[string][]byte{
+		"overflowing size": append(append([]byte{1, 'a', 1}, maxSize...), 0, 0, 0),
+		"past directory":   {1, 'a', 5, 4, 1, 0, 0},
+		"long name":        {200, 1, 'a', 5, 3, 1, 0, 0},
+		"huge name":        append(appendUvarint(nil, math.MaxInt32), 'a'),
+	} {
+		if err := open(container(dir...)); err != ErrBadContainer {
+			t.Errorf("OpenContainer() with %s: %v", name, err)
+		}
+	}
+}
+
+func Test_ContainerByteRanges(t *testing.T) {
+	// Segments of one list, with an unrelated list between the third and
+	// fourth segments.
+	segments := []List{
+		makeRange(intrv{0, 99}),
+		makeRange(intrv{200, 299}, intrv{350, 360}),
+		makeRange(intrv{400, 499}),
+		makeRange(intrv{600, 699}),
+	}
+	var buf bytes.Buffer
+	cw, err := NewContainerWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range segments {
+		if i == 3 {
+			cw.Add("other", Create(2000))
+			cw.Add("empty", List{})
+		}
+		cw.Add(string(rune('a'+i)), l)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	cr, err := OpenContainer(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ranges := cr.ByteRanges(250, 650)
+	if len(ranges) != 2 || len(ranges[0].Entries) != 2 || len(ranges[1].Entries) != 1 {
+		t.Fatalf("ByteRanges() = %v, expected segments b and c, then d", ranges)
+	}
+	var lists []List
+	for _, r := range ranges {
+		l, err := r.Lists(data[r.Offset : r.Offset+r.Size])
+		if err != nil {
+			t.Fatal(err)
+		}
+		lists = append(lists, l...)
+	}
+	all := Union(segments...)
+	if result, expected := Intersection(Union(lists...), makeRange(intrv{250, 650})), Intersection(all, makeRange(intrv{250, 650})); !Equal(result, expected) {
+		t.Errorf("%v != %v", result.Expand(), expected.Expand())
+	}
+
+	if ranges := cr.ByteRanges(100, 199); len(ranges) != 0 {
+		t.Errorf("ByteRanges() of a gap = %v", ranges)
+	}
+	if _, err := ranges[0].Lists(data[:ranges[0].Size-1]); err != ErrBadContainer {
+		t.Errorf("Lists() of short data: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package skiptake
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Building and set algebra which can be cancelled with a context.Context.
+
+// BuildFromChannel builds a list from the strictly increasing values received
+// from ch, until it is closed. Eg:
+//
+//		values := make(chan uint64)
+//		go ingest(values) // Closes values when done
+//		l, err := skiptake.BuildFromChannel(ctx, values)
+//
+// If ctx is done before ch is closed, returns ctx.Err(). An out of order value
+// returns an error wrapping ErrUnsorted, which gives its index. In either case
+// the rest of ch is not drained, so the sender must also watch ctx, or ch must
+// be buffered enough not to block it.
+func BuildFromChannel(ctx context.Context, ch <-chan uint64) (List, error) {
+	b := Build(&List{})
+	var i uint64
+	var prev uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case v, ok := <-ch:
+			if !ok {
+				return b.Finish(), nil
+			}
+			if !b.Next(v) {
+				return nil, fmt.Errorf("%w: value %d at index %d follows %d", ErrUnsorted, v, i, prev)
+			}
+			prev = v
+			i++
+		}
+	}
+}
+
+// ctxCheckInterval is how many intervals are read by a cancellable set
+// operation between checks of its context.
+const ctxCheckInterval = 1024
+
+// ctxCheck is shared by the sources of a cancellable set operation. The
+// context is checked every ctxCheckInterval intervals, and once it is done,
+// every source reports end of stream, so the operation winds up promptly.
+type ctxCheck struct {
+	ctx context.Context
+	n   int
+	err error
+}
+
+func (c *ctxCheck) done() bool {
+	if c.err == nil {
+		if c.n++; c.n%ctxCheckInterval == 0 {
+			c.err = c.ctx.Err()
+		}
+	}
+	return c.err != nil
+}
+
+// ctxIntervals is a source of intervals which ends early once its ctxCheck is
+// done.
+type ctxIntervals struct {
+	src   Intervals
+	check *ctxCheck
+}
+
+func (c *ctxIntervals) NextInterval() (first, last uint64) {
+	if c.check.done() {
+		return math.MaxUint64, 0
+	}
+	return c.src.NextInterval()
+}
+
+// iterate returns cursors over the lists, like iterateAll(), which end early
+// once the check is done.
+func (c *ctxCheck) iterate(lists []List) []cursor {
+	cur := iterateAll(lists)
+	wrapped := make([]ctxIntervals, len(cur))
+	for i := range cur {
+		wrapped[i] = ctxIntervals{src: cur[i].src, check: c}
+		cur[i].src, cur[i].seeker = &wrapped[i], nil
+	}
+	return cur
+}
+
+// UnionContext returns Union(lists...), unless ctx is done before the union is
+// complete, in which case it returns ctx.Err(). The context is checked
+// periodically as the lists are merged, so a union of huge or adversarial
+// lists can be abandoned part way.
+func UnionContext(ctx context.Context, lists ...List) (List, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	check := ctxCheck{ctx: ctx}
+	b := Build(&List{})
+	union(&b, check.iterate(lists))
+	if check.err != nil {
+		return nil, check.err
+	}
+	return b.Finish(), nil
+}
+
+// IntersectionContext returns Intersection(lists...), unless ctx is done
+// before the intersection is complete, in which case it returns ctx.Err().
+func IntersectionContext(ctx context.Context, lists ...List) (List, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	max, ok := intersectionBound(lists)
+	if !ok {
+		return List{}, nil
+	}
+	check := ctxCheck{ctx: ctx}
+	b := Build(&List{})
+	intersection(&b, check.iterate(lists), max)
+	if check.err != nil {
+		return nil, check.err
+	}
+	return b.Finish(), nil
+}
+
+// ComplementContext returns ComplementRange(list, min, max), unless ctx is
+// done before the complement is complete, in which case it returns ctx.Err().
+func ComplementContext(ctx context.Context, list List, min, max uint64) (List, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	check := ctxCheck{ctx: ctx}
+	b := Build(&List{})
+	iter := list.Iterate()
+	complement(&b, &ctxIntervals{src: &iter, check: &check}, min, max)
+	if check.err != nil {
+		return nil, check.err
+	}
+	return b.Finish(), nil
+}
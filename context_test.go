@@ -0,0 +1,167 @@
+package skiptake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_BuildFromChannel(t *testing.T) {
+	ch := make(chan uint64)
+	go func() {
+		for _, v := range []uint64{1, 2, 3, 10, 20, 21} {
+			ch <- v
+		}
+		close(ch)
+	}()
+	l, err := BuildFromChannel(context.Background(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalUint64(l.Expand(), []uint64{1, 2, 3, 10, 20, 21}) {
+		t.Errorf("BuildFromChannel() = %v", l.Expand())
+	}
+
+	ch = make(chan uint64, 4)
+	ch <- 5
+	ch <- 7
+	ch <- 7
+	close(ch)
+	if _, err := BuildFromChannel(context.Background(), ch); !errors.Is(err, ErrUnsorted) {
+		t.Errorf("BuildFromChannel() of repeated value error = %v", err)
+	} else if err.Error() != "skiptake: values not strictly increasing: value 7 at index 2 follows 7" {
+		t.Errorf("BuildFromChannel() error = %q", err)
+	}
+
+	ch = make(chan uint64)
+	close(ch)
+	if l, err := BuildFromChannel(context.Background(), ch); err != nil || !l.IsEmpty() {
+		t.Errorf("BuildFromChannel() of closed channel = %v, %v", l, err)
+	}
+}
+
+func Test_BuildFromChannel_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan uint64)
+	done := make(chan error)
+	go func() {
+		_, err := BuildFromChannel(ctx, ch)
+		done <- err
+	}()
+	ch <- 1
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("BuildFromChannel() error = %v, expected %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BuildFromChannel() not cancelled")
+	}
+}
+
+// countdownContext is a context which is done after its Err method has been
+// called n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func Test_SetOperationsContext(t *testing.T) {
+	lists := []List{
+		makeRange(intrv{0, 10}, intrv{20, 30}, intrv{40, 50}),
+		makeRange(intrv{5, 25}, intrv{28, 45}),
+		makeRange(intrv{8, 42}),
+	}
+	ctx := context.Background()
+	if l, err := UnionContext(ctx, lists...); err != nil || !equalUint64(l.Expand(), Union(lists...).Expand()) {
+		t.Errorf("UnionContext() = %v, %v", l, err)
+	}
+	if l, err := IntersectionContext(ctx, lists...); err != nil || !equalUint64(l.Expand(), Intersection(lists...).Expand()) {
+		t.Errorf("IntersectionContext() = %v, %v", l, err)
+	}
+	if l, err := ComplementContext(ctx, lists[0], 3, 45); err != nil || !equalUint64(l.Expand(), ComplementRange(lists[0], 3, 45).Expand()) {
+		t.Errorf("ComplementContext() = %v, %v", l, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := UnionContext(cancelled, lists...); err != context.Canceled {
+		t.Errorf("UnionContext() error = %v", err)
+	}
+	if _, err := IntersectionContext(cancelled, lists...); err != context.Canceled {
+		t.Errorf("IntersectionContext() error = %v", err)
+	}
+	if _, err := ComplementContext(cancelled, lists[0], 0, 100); err != context.Canceled {
+		t.Errorf("ComplementContext() error = %v", err)
+	}
+}
+
+func Test_SetOperationsContext_Abort(t *testing.T) {
+	// Lists of many intervals, so that the context is checked part way.
+	var values []uint64
+	for v := uint64(0); v < 100000; v += 2 {
+		values = append(values, v)
+	}
+	a := Create(values...)
+	b := Create(append(values[1:], 200001)...)
+
+	ops := []func(ctx context.Context) (List, error){
+		func(ctx context.Context) (List, error) { return UnionContext(ctx, a, b) },
+		func(ctx context.Context) (List, error) { return IntersectionContext(ctx, a, b) },
+		func(ctx context.Context) (List, error) { return ComplementContext(ctx, a, 0, 1<<20) },
+	}
+	for i, op := range ops {
+		// Done at the first check after starting
+		ctx := &countdownContext{Context: context.Background(), n: 1}
+		if l, err := op(ctx); err != context.Canceled || l != nil {
+			t.Errorf("op %d = %d bytes, %v, expected %v", i, len(l), err, context.Canceled)
+		}
+		if ctx.n != -1 {
+			t.Errorf("op %d checked the context %d times, expected 2", i, 1-ctx.n)
+		}
+	}
+}
+
+func Test_SetOperationsContext_Repeats(t *testing.T) {
+	// A short list of one interval, held as 2^40 repeats of a zero skip pair,
+	// which must be coalesced without decoding each repeat.
+	var l List
+	e := l.Encode()
+	e.Add(5, 1)
+	e.Add(0, 1)
+	e.addRepeats(1<<40 - 1)
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	expected := makeRange(intrv{1, 1}, intrv{5, 5 + 1<<40})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u, err := UnionContext(ctx, l, Create(1))
+	if err != nil || !Equal(u, expected) {
+		t.Errorf("UnionContext() = %v, %v", u, err)
+	}
+	if l2, err := IntersectionContext(ctx, l, Create(1, 6, 1<<41)); err != nil || !Equal(l2, Create(6)) {
+		t.Errorf("IntersectionContext() = %v, %v", l2.Expand(), err)
+	}
+
+	// Repeats of a zero take only lengthen the skip.
+	var z List
+	e = z.Encode()
+	e.Add(1, 0)
+	e.addRepeats(1<<40 - 1)
+	e.Add(3, 2)
+	iter := z.Iterate()
+	if first, last := iter.NextInterval(); first != 3+1<<40 || last != first+1 {
+		t.Errorf("NextInterval() = [%d, %d]", first, last)
+	}
+}
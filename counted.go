@@ -0,0 +1,85 @@
+package skiptake
+
+// CountedList is a List which caches its length, bounds and number of
+// intervals. Each is computed on first use, and the cache is cleared whenever
+// the list is changed through the CountedList.
+//
+// A CountedList is not safe for concurrent use. The zero value of CountedList
+// holds an empty list.
+type CountedList struct {
+	l         List
+	valid     uint8 // Which of the cached values have been computed
+	len       uint64
+	first     uint64
+	last      uint64
+	nonEmpty  bool
+	intervals uint64
+}
+
+const (
+	countedLen = 1 << iota
+	countedBounds
+	countedIntervals
+)
+
+// NewCountedList returns a CountedList holding l. l must not be modified
+// after, except through the CountedList.
+func NewCountedList(l List) *CountedList {
+	return &CountedList{l: l}
+}
+
+// List returns the list. The returned list must not be modified.
+func (c *CountedList) List() List {
+	return c.l
+}
+
+// Set replaces the list with l, clearing the cache.
+func (c *CountedList) Set(l List) {
+	c.l = l
+	c.valid = 0
+}
+
+// Update replaces the list with the result of fn, which is passed the current
+// list, clearing the cache.
+func (c *CountedList) Update(fn func(List) List) {
+	c.Set(fn(c.l))
+}
+
+// Len returns how many values are in the expanded sequence.
+func (c *CountedList) Len() uint64 {
+	if c.valid&countedLen == 0 {
+		c.len = c.l.Len()
+		c.valid |= countedLen
+	}
+	return c.len
+}
+
+// Bounds returns the smallest and largest members of the list. ok is false if
+// the list is empty.
+func (c *CountedList) Bounds() (first, last uint64, ok bool) {
+	if c.valid&countedBounds == 0 {
+		c.first, c.last, c.nonEmpty = c.l.Bounds()
+		c.valid |= countedBounds
+	}
+	return c.first, c.last, c.nonEmpty
+}
+
+// NumIntervals returns how many intervals of consecutive values are in the
+// list.
+func (c *CountedList) NumIntervals() uint64 {
+	if c.valid&countedIntervals == 0 {
+		c.intervals = c.l.NumIntervals()
+		c.valid |= countedIntervals
+	}
+	return c.intervals
+}
+
+// Iterate returns a new skiptake.Iterator for the list.
+func (c *CountedList) Iterate() Iterator {
+	return c.l.Iterate()
+}
+
+// String implements the fmt.Stringer interface.
+func (c *CountedList) String() string {
+	return c.l.String()
+}
@@ -0,0 +1,33 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_CountedList(t *testing.T) {
+	var c CountedList
+	expectUint64(t, c.Len(), 0)
+	expectUint64(t, c.NumIntervals(), 0)
+	if _, _, ok := c.Bounds(); ok {
+		t.Errorf("Bounds() of empty list reported ok")
+	}
+
+	c.Set(makeRange(intrv{5, 9}, intrv{20, 29}))
+	expectUint64(t, c.Len(), 15)
+	expectUint64(t, c.NumIntervals(), 2)
+	first, last, ok := c.Bounds()
+	if first != 5 || last != 29 || !ok {
+		t.Errorf("Bounds() = %d, %d, %v", first, last, ok)
+	}
+
+	c.Update(func(l List) List {
+		return Union(l, makeRange(intrv{10, 12}, intrv{100, 100}))
+	})
+	expectUint64(t, c.Len(), 19)
+	expectUint64(t, c.NumIntervals(), 3)
+	first, last, ok = c.Bounds()
+	if first != 5 || last != 100 || !ok {
+		t.Errorf("Bounds() after Update() = %d, %d, %v", first, last, ok)
+	}
+
+}
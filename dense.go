@@ -0,0 +1,147 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Conversion between lists and flat binary columns of fixed-width
+// little-endian values.
+
+var (
+	// ErrWidth is returned when a value width other than 4 or 8 bytes is
+	// requested.
+	ErrWidth = errors.New("skiptake: value width must be 4 or 8 bytes")
+
+	// ErrValueWidth is returned by ExpandTo when a value does not fit the
+	// requested width.
+	ErrValueWidth = errors.New("skiptake: value too large for width")
+
+	// ErrUnsorted is returned by ReadDense and FromArrowUint64 when values
+	// are not strictly increasing, and the DenseOptions do not allow it.
+	ErrUnsorted = errors.New("skiptake: values not strictly increasing")
+)
+
+// denseChunk is the size in bytes of the chunks in which values are written.
+const denseChunk = 4096
+
+// ExpandTo writes the members of the list to w as little-endian unsigned
+// integers of width bytes, which must be 4 or 8. Values are written in chunks,
+// so unlike Expand() the whole sequence is never held in memory. Returns the
+// number of bytes written.
+//
+// If a member does not fit in width bytes, ErrValueWidth is returned, after
+// the members before it have been written.
+func (l List) ExpandTo(w io.Writer, width int) (int64, error) {
+	if width != 4 && width != 8 {
+		return 0, ErrWidth
+	}
+	var written int64
+	buf := make([]byte, 0, denseChunk)
+	flush := func() error {
+		n, err := w.Write(buf)
+		written += int64(n)
+		buf = buf[:0]
+		return err
+	}
+
+	overflow := false
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last && !overflow; first, last = iter.NextInterval() {
+		if width == 4 && last > math.MaxUint32 {
+			overflow = true
+			if first > math.MaxUint32 {
+				break
+			}
+			last = math.MaxUint32
+		}
+		for v := first; ; v++ {
+			if len(buf) == cap(buf) {
+				if err := flush(); err != nil {
+					return written, err
+				}
+			}
+			k := len(buf)
+			buf = buf[:k+width]
+			if width == 4 {
+				binary.LittleEndian.PutUint32(buf[k:], uint32(v))
+			} else {
+				binary.LittleEndian.PutUint64(buf[k:], v)
+			}
+			if v == last {
+				break
+			}
+		}
+	}
+	if err := flush(); err != nil || !overflow {
+		return written, err
+	}
+	return written, ErrValueWidth
+}
+
+// DenseOptions configures how strictly ReadDense checks its input. The zero
+// value of DenseOptions requires values to be strictly increasing.
+type DenseOptions struct {
+	// Duplicates allows a value to repeat the one before it. Repeats are
+	// ignored.
+	Duplicates bool
+
+	// Unsorted allows values less than the one before them. Such values are
+	// dropped, rather than included.
+	Unsorted bool
+}
+
+// ReadDense builds a list from the little-endian unsigned integers of width
+// bytes, which must be 4 or 8, read from r until EOF. This is the inverse of
+// List.ExpandTo(). The input is read in chunks, and fed straight to a Builder.
+//
+// Values must be strictly increasing, unless allowed otherwise by opts. An
+// out of order value returns an error wrapping ErrUnsorted, which gives its
+// index. Input ending part way through a value returns io.ErrUnexpectedEOF.
+func ReadDense(r io.Reader, width int, opts DenseOptions) (List, error) {
+	if width != 4 && width != 8 {
+		return nil, ErrWidth
+	}
+	b := Build(&List{})
+	buf := make([]byte, denseChunk)
+	var i uint64 // Index of the next value
+	var prev uint64
+	k := 0 // Bytes held in buf
+	for {
+		n, err := r.Read(buf[k:])
+		k += n
+		end := k - k%width
+		for j := 0; j < end; j += width {
+			var v uint64
+			if width == 4 {
+				v = uint64(binary.LittleEndian.Uint32(buf[j:]))
+			} else {
+				v = binary.LittleEndian.Uint64(buf[j:])
+			}
+			switch {
+			case i == 0 || v > prev:
+				b.Next(v)
+				prev = v
+			case v == prev && opts.Duplicates:
+			case v < prev && opts.Unsorted:
+			default:
+				return nil, fmt.Errorf("%w: value %d at index %d follows %d", ErrUnsorted, v, i, prev)
+			}
+			i++
+		}
+		k = copy(buf, buf[end:k])
+
+		if err == io.EOF {
+			if k != 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return b.Finish(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
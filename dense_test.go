@@ -0,0 +1,119 @@
+package skiptake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func Test_ExpandTo(t *testing.T) {
+	list := makeRange(intrv{0, 3}, intrv{10, 10}, intrv{1000, 2999})
+	values := list.Expand()
+
+	for _, width := range []int{4, 8} {
+		var out bytes.Buffer
+		n, err := list.ExpandTo(&out, width)
+		if err != nil || n != int64(len(values)*width) || out.Len() != len(values)*width {
+			t.Fatalf("ExpandTo(%d) = %d, %v, wrote %d bytes", width, n, err, out.Len())
+		}
+		b := out.Bytes()
+		for i, v := range values {
+			var r uint64
+			if width == 4 {
+				r = uint64(binary.LittleEndian.Uint32(b[i*4:]))
+			} else {
+				r = binary.LittleEndian.Uint64(b[i*8:])
+			}
+			if r != v {
+				t.Fatalf("ExpandTo(%d) value %d = %d, expected %d", width, i, r, v)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	if _, err := list.ExpandTo(&out, 2); err != ErrWidth {
+		t.Errorf("ExpandTo(2) error = %v, expected %v", err, ErrWidth)
+	}
+
+	// Values beyond 32 bits.
+	big := makeRange(intrv{5, 6}, intrv{math.MaxUint32 - 1, math.MaxUint32 + 5}, intrv{1 << 40, 1 << 40})
+	out.Reset()
+	n, err := big.ExpandTo(&out, 4)
+	if err != ErrValueWidth || n != 16 {
+		t.Errorf("ExpandTo(4) of %v = %d, %v, expected 16, %v", big, n, err, ErrValueWidth)
+	}
+	out.Reset()
+	if n, err := big.ExpandTo(&out, 8); err != nil || n != int64(big.Len()*8) {
+		t.Errorf("ExpandTo(8) of %v = %d, %v", big, n, err)
+	}
+}
+
+// oneByteReader returns at most one byte per Read, exercising values split
+// between reads.
+type oneByteReader struct {
+	r *bytes.Reader
+}
+
+func (o oneByteReader) Read(b []byte) (int, error) {
+	if len(b) > 1 {
+		b = b[:1]
+	}
+	return o.r.Read(b)
+}
+
+func Test_ReadDense(t *testing.T) {
+	list := makeRange(intrv{0, 3}, intrv{10, 10}, intrv{1000, 2999}, intrv{1 << 20, 1<<20 + 5})
+	for _, width := range []int{4, 8} {
+		var out bytes.Buffer
+		list.ExpandTo(&out, width)
+
+		result, err := ReadDense(bytes.NewReader(out.Bytes()), width, DenseOptions{})
+		if err != nil || !bytes.Equal(result, list) {
+			t.Errorf("ReadDense(%d) = %v, %v, expected %v", width, result, err, list)
+		}
+		result, err = ReadDense(oneByteReader{bytes.NewReader(out.Bytes())}, width, DenseOptions{})
+		if err != nil || !bytes.Equal(result, list) {
+			t.Errorf("ReadDense(%d) a byte at a time = %v, %v, expected %v", width, result, err, list)
+		}
+		_, err = ReadDense(bytes.NewReader(out.Bytes()[:out.Len()-1]), width, DenseOptions{})
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadDense(%d) of truncated input error = %v, expected %v", width, err, io.ErrUnexpectedEOF)
+		}
+	}
+
+	dense := func(values ...uint64) *bytes.Reader {
+		b := make([]byte, 8*len(values))
+		for i, v := range values {
+			binary.LittleEndian.PutUint64(b[i*8:], v)
+		}
+		return bytes.NewReader(b)
+	}
+	testCases := []struct {
+		opts     DenseOptions
+		expected string
+		err      bool
+	}{
+		{DenseOptions{}, "", true},
+		{DenseOptions{Duplicates: true}, "", true},
+		{DenseOptions{Unsorted: true}, "", true},
+		{DenseOptions{Duplicates: true, Unsorted: true}, "[1 - 3], 7", false},
+	}
+	for _, c := range testCases {
+		result, err := ReadDense(dense(1, 2, 2, 3, 1, 7), 8, c.opts)
+		if (err != nil) != c.err || (err == nil && result.String() != c.expected) {
+			t.Errorf("ReadDense(%+v) = %v, %v", c.opts, result, err)
+		}
+		if err != nil && !errors.Is(err, ErrUnsorted) {
+			t.Errorf("ReadDense(%+v) error = %v, expected %v", c.opts, err, ErrUnsorted)
+		}
+	}
+	if _, err := ReadDense(dense(), 3, DenseOptions{}); err != ErrWidth {
+		t.Errorf("ReadDense(3) error = %v, expected %v", err, ErrWidth)
+	}
+	if l, err := ReadDense(dense(), 8, DenseOptions{}); err != nil || !l.IsEmpty() {
+		t.Errorf("ReadDense() of nothing = %v, %v", l, err)
+	}
+}
@@ -0,0 +1,208 @@
+package skiptake
+
+import (
+	"encoding/binary"
+)
+
+// This file implements an alternative byte packing of (skip, take) pairs,
+// which replaces recurring sequences of pairs with references to an earlier
+// occurrence, in the manner of LZ77. The dictionary is the window of the most
+// recent dictWindow pairs.
+//
+// Lists such as weekly schedules consist of the same short motif of pairs
+// repeated many times. The first occurrence is stored in full, and the
+// remainder as a single reference, as a reference may overlap the pairs it
+// produces.
+//
+// The packing is a sequence of tokens. Each token begins with a split varint,
+// as used by the List packing, whose flag selects the token type:
+//
+//		literal:   varint2(count-1, 0), then count pairs as uvarint skip, take
+//		reference: varint2(length-1, 1), uvarint(distance-1)
+//
+// A reference copies length pairs, starting distance pairs before the current
+// position. A reference copies at most dictMaxCopy pairs, and reaches back at
+// most dictWindow pairs, so that a short payload can not expand without bound.
+//
+// The whole list must be known before choosing references, so DictEncoder
+// buffers all pairs and packs them on Flush. To compress a list built with a
+// Builder, frame it with CodecDict:
+//
+//		b, err := Marshal(builder.Finish(), CodecDict)
+//
+
+const (
+	dictWindow   = 1 << 12 // Pairs kept by the decoder
+	dictMinMatch = 3       // Shortest sequence of pairs replaced by a reference
+	dictHashBits = 14
+	dictMaxCopy  = 1 << 16 // Longest sequence of pairs copied by a reference
+)
+
+const (
+	dictLiteral   int8 = 0
+	dictReference      = 1
+)
+
+// DictEncoder packs (skip, take) pairs into Elements, replacing recurring
+// sequences of pairs with references.
+//
+// All pairs are buffered until Flush is called.
+type DictEncoder struct {
+	Elements *[]byte
+	pairs    [][2]uint64
+}
+
+// Add adds a new skip-take pair to the sequence.
+func (e *DictEncoder) Add(skip, take uint64) {
+	e.pairs = append(e.pairs, [2]uint64{skip, take})
+}
+
+// Flush packs all buffered pairs.
+func (e *DictEncoder) Flush() {
+	p := e.pairs
+	table := make([]int32, 1<<dictHashBits) // Last position+1 of each hash
+	litStart := 0
+
+	for i := 0; i+dictMinMatch <= len(p); {
+		h := dictHash(p[i:])
+		cand := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if cand < 0 || i-cand > dictWindow || !dictEqual(p[cand:], p[i:], dictMinMatch) {
+			i++
+			continue
+		}
+		n := dictMinMatch
+		for i+n < len(p) && n < dictMaxCopy && p[cand+n] == p[i+n] {
+			n++
+		}
+		e.literals(p[litStart:i])
+		out := appendVarint2(*e.Elements, uint64(n-1), dictReference, defaultSplit)
+		*e.Elements = appendUvarint(out, uint64(i-cand-1))
+		for j := i + 1; j < i+n && j+dictMinMatch <= len(p); j++ {
+			table[dictHash(p[j:])] = int32(j + 1)
+		}
+		i += n
+		litStart = i
+	}
+	e.literals(p[litStart:])
+	e.pairs = e.pairs[:0]
+}
+
+func (e *DictEncoder) literals(p [][2]uint64) {
+	if len(p) == 0 {
+		return
+	}
+	out := appendVarint2(*e.Elements, uint64(len(p)-1), dictLiteral, defaultSplit)
+	for _, pair := range p {
+		out = appendUvarint(out, pair[0])
+		out = appendUvarint(out, pair[1])
+	}
+	*e.Elements = out
+}
+
+func dictHash(p [][2]uint64) uint32 {
+	var h uint64
+	for _, pair := range p[:dictMinMatch] {
+		h = (h ^ pair[0]) * 0x9e3779b97f4a7c15
+		h = (h ^ pair[1]) * 0x9e3779b97f4a7c15
+	}
+	return uint32(h >> (64 - dictHashBits))
+}
+
+func dictEqual(a, b [][2]uint64, n int) bool {
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DictDecoder unpacks (skip, take) pairs packed by a DictEncoder.
+type DictDecoder struct {
+	Elements []byte
+	i        int
+	window   [][2]uint64
+	pos      int // Number of pairs decoded
+	literals int // Remaining literal pairs of the current token
+	copies   int // Remaining pairs to copy of the current token
+	distance int
+	err      error
+}
+
+// Next returns the next pair of skip, take values. Returns (0,0) in the case of
+// end-of-sequence.
+func (d *DictDecoder) Next() (skip, take uint64) {
+	if d.literals == 0 && d.copies == 0 {
+		if d.i >= len(d.Elements) {
+			return 0, 0
+		}
+		n, e := readVarint2(d.Elements, &d.i, defaultSplit)
+		if e == dictLiteral {
+			// Each literal pair takes at least two bytes.
+			if n >= uint64(len(d.Elements)-d.i)/2 {
+				return d.fail()
+			}
+			d.literals = int(n + 1)
+		} else {
+			distance := d.uvarint()
+			if n >= dictMaxCopy || distance >= uint64(d.pos) || distance >= dictWindow {
+				return d.fail()
+			}
+			d.copies = int(n + 1)
+			d.distance = int(distance + 1)
+		}
+	}
+	if d.window == nil {
+		d.window = make([][2]uint64, dictWindow)
+	}
+
+	var p [2]uint64
+	if d.literals > 0 {
+		d.literals--
+		p[0] = d.uvarint()
+		p[1] = d.uvarint()
+	} else {
+		d.copies--
+		p = d.window[(d.pos-d.distance)%dictWindow]
+	}
+	d.window[d.pos%dictWindow] = p
+	d.pos++
+	return p[0], p[1]
+}
+
+// EOS returns if the decoder is at the end of the sequence.
+func (d *DictDecoder) EOS() bool {
+	return d.literals == 0 && d.copies == 0 && d.i >= len(d.Elements)
+}
+
+// Err returns ErrCorruptFrame if decoding stopped at a token which can not
+// have been packed by a DictEncoder, such as one counting more literal pairs
+// than there are bytes left.
+func (d *DictDecoder) Err() error {
+	return d.err
+}
+
+// Reset resets the location of the decoder to the beginning of the sequence.
+func (d *DictDecoder) Reset() {
+	d.i, d.pos, d.literals, d.copies, d.err = 0, 0, 0, 0, nil
+}
+
+// fail ends decoding at a malformed token.
+func (d *DictDecoder) fail() (skip, take uint64) {
+	d.i, d.err = len(d.Elements), ErrCorruptFrame
+	return 0, 0
+}
+
+func (d *DictDecoder) uvarint() uint64 {
+	if d.i >= len(d.Elements) {
+		return 0
+	}
+	v, n := binary.Uvarint(d.Elements[d.i:])
+	if n <= 0 {
+		d.i = len(d.Elements)
+		return 0
+	}
+	d.i += n
+	return v
+}
@@ -0,0 +1,73 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_DictEncodeDecode(t *testing.T) {
+	for name, values := range codecTestCases {
+		t.Run(name, func(t *testing.T) {
+			var b []byte
+			testPairCodec(t, values, &DictEncoder{Elements: &b}, func() PairDecoder {
+				t.Logf("Encoded as %d bytes: %v", len(b), b)
+				return &DictDecoder{Elements: b}
+			})
+		})
+	}
+}
+
+func Test_DictMotif(t *testing.T) {
+	// A weekly schedule: a 14 pair motif repeated, with an irregular prefix,
+	// interruption and suffix.
+	motif := [][2]uint64{
+		{9, 8}, {15, 1}, {8, 8}, {16, 1}, {8, 8}, {16, 1}, {8, 8},
+		{16, 1}, {8, 8}, {16, 1}, {8, 4}, {44, 2}, {30, 6}, {50, 3},
+	}
+	values := [][2]uint64{{3, 1}, {7, 2}}
+	for i := 0; i < 1000; i++ {
+		values = append(values, motif...)
+		if i == 500 {
+			values = append(values, [2]uint64{1000, 1})
+		}
+	}
+	values = append(values, [2]uint64{5, 5}, [2]uint64{6, 6})
+
+	var b []byte
+	testPairCodec(t, values, &DictEncoder{Elements: &b}, func() PairDecoder {
+		return &DictDecoder{Elements: b}
+	})
+
+	var l List
+	e := l.Encode()
+	for _, p := range values {
+		e.Add(p[0], p[1])
+	}
+	t.Logf("Dict: %d bytes, varint: %d bytes", len(b), len(l))
+	if len(b) > 64 {
+		t.Errorf("Dict packing of repeated motif is %d bytes", len(b))
+	}
+}
+
+func Test_DictCorrupt(t *testing.T) {
+	for _, b := range [][]byte{
+		{byte(CodecDict), 6, 128, 128, 128, 128, 128, 64}, // Huge literal count
+		{byte(CodecDict), 3, 2, 1, 1},                     // Literals beyond the payload
+		{byte(CodecDict), 2, 1, 0},                        // Reference before the first pair
+		{byte(CodecDict), 6, 0, 1, 1, 129, 128, 16},       // Reference longer than dictMaxCopy
+	} {
+		if _, err := Unmarshal(b); err != ErrCorruptFrame {
+			t.Errorf("Unmarshal(%v) = %v, expected ErrCorruptFrame", b, err)
+		}
+	}
+
+	// Long runs of a motif are split into references of at most dictMaxCopy
+	// pairs.
+	values := make([][2]uint64, 3*dictMaxCopy)
+	for i := range values {
+		values[i] = [2]uint64{uint64(i%3) + 1, 1}
+	}
+	var b []byte
+	testPairCodec(t, values, &DictEncoder{Elements: &b}, func() PairDecoder {
+		return &DictDecoder{Elements: b}
+	})
+}
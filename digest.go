@@ -0,0 +1,204 @@
+package skiptake
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"math"
+	"sort"
+)
+
+// Digests of lists, so that replicas of near identical lists can find the
+// ranges of values in which they differ by exchanging hashes, rather than the
+// lists themselves.
+
+// ErrDigestMismatch is returned when comparing digests computed with
+// different chunk sizes or fanouts.
+var ErrDigestMismatch = errors.New("skiptake: digests have different parameters")
+
+// DigestNode is the hash of the members of a list within one chunk of values,
+// or within a group of chunks.
+type DigestNode struct {
+	Index uint64 // Position of the chunk within its level
+	Sum   [sha256.Size]byte
+}
+
+// Digest is a tree of hashes of a list. The leaves, Levels[0], hash the
+// members of each chunk of ChunkSize values. Each node of the level above
+// hashes Fanout nodes of the level below, up to a single root. Only chunks
+// holding members have nodes, and each level is sorted by Index.
+//
+// The node with Index i of level k covers the values from
+// i * ChunkSize * Fanout^k, for ChunkSize * Fanout^k values. Equal lists have
+// equal nodes, so two replicas can compare roots, then compare the children
+// of only those nodes which differ, to narrow down the chunks to be
+// synchronized. Eg:
+//
+//		local := l.Digest(1<<16, 16)
+//		diff, err := skiptake.DiffDigests(local, remote)
+//		send(skiptake.Intersection(l, diff))
+//
+type Digest struct {
+	ChunkSize uint64
+	Fanout    uint64
+	Levels    [][]DigestNode
+}
+
+// Digest returns the digest of the list, hashing chunks of chunkSize values,
+// grouped fanout at a time. A chunkSize of zero is taken as one, and a fanout
+// less than two as two.
+//
+// Computing the digest takes time proportional to the number of intervals of
+// the list, and the number of chunks they intersect.
+func (l List) Digest(chunkSize, fanout uint64) Digest {
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+	if fanout < 2 {
+		fanout = 2
+	}
+	d := Digest{ChunkSize: chunkSize, Fanout: fanout}
+	d.Levels = append(d.Levels, l.digestChunks(chunkSize))
+	for max := math.MaxUint64 / chunkSize; max > 0; max /= fanout {
+		d.Levels = append(d.Levels, digestParents(d.Levels[len(d.Levels)-1], fanout))
+	}
+	return d
+}
+
+// Root returns the hash of the root of the digest, or false if the list is
+// empty.
+func (d Digest) Root() ([sha256.Size]byte, bool) {
+	top := d.Levels[len(d.Levels)-1]
+	if len(top) == 0 {
+		return [sha256.Size]byte{}, false
+	}
+	return top[0].Sum, true
+}
+
+// Children returns the nodes of the level below the node with Index i of level
+// k.
+func (d Digest) Children(k int, i uint64) []DigestNode {
+	if k == 0 {
+		return nil
+	}
+	level := d.Levels[k-1]
+	j := sort.Search(len(level), func(j int) bool { return level[j].Index/d.Fanout >= i })
+	end := j
+	for end < len(level) && level[end].Index/d.Fanout == i {
+		end++
+	}
+	return level[j:end]
+}
+
+// DiffDigests returns the chunks of values in which the lists digested as a
+// and b may differ, as a list of every value they cover. Only the children of
+// nodes which differ are compared.
+//
+// Returns ErrDigestMismatch if the digests were computed with different chunk
+// sizes or fanouts.
+func DiffDigests(a, b Digest) (List, error) {
+	if a.ChunkSize != b.ChunkSize || a.Fanout != b.Fanout || len(a.Levels) != len(b.Levels) {
+		return nil, ErrDigestMismatch
+	}
+	top := len(a.Levels) - 1
+	var chunks []uint64
+	var walk func(k int, an, bn []DigestNode)
+	walk = func(k int, an, bn []DigestNode) {
+		for len(an) > 0 || len(bn) > 0 {
+			var i uint64
+			switch {
+			case len(bn) == 0 || (len(an) > 0 && an[0].Index < bn[0].Index):
+				i = an[0].Index
+				an = an[1:]
+			case len(an) == 0 || bn[0].Index < an[0].Index:
+				i = bn[0].Index
+				bn = bn[1:]
+			default:
+				i = an[0].Index
+				same := an[0].Sum == bn[0].Sum
+				an, bn = an[1:], bn[1:]
+				if same {
+					continue
+				}
+			}
+			if k == 0 {
+				chunks = append(chunks, i)
+				continue
+			}
+			walk(k-1, a.Children(k, i), b.Children(k, i))
+		}
+	}
+	walk(top, a.Levels[top], b.Levels[top])
+
+	result := Build(&List{})
+	for _, i := range chunks {
+		first := i * a.ChunkSize
+		last := math.MaxUint64 - first
+		if last >= a.ChunkSize {
+			last = a.ChunkSize - 1
+		}
+		result.interval(first, first+last)
+	}
+	return result.Finish(), nil
+}
+
+// digestChunks hashes the members of each chunk of chunkSize values holding
+// any. Each interval is hashed as its offset within the chunk and its length,
+// so the hash does not depend on how the list was encoded.
+func (l List) digestChunks(chunkSize uint64) []DigestNode {
+	var nodes []DigestNode
+	h := sha256.New()
+	var buf []byte
+	var chunk uint64
+	open := false
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		for {
+			i := first / chunkSize
+			if open && i != chunk {
+				nodes = appendDigest(nodes, h, chunk)
+			}
+			chunk, open = i, true
+			lo := i * chunkSize
+			end := last
+			if last-lo >= chunkSize {
+				end = lo + chunkSize - 1
+			}
+			buf = appendUvarint(appendUvarint(buf[:0], first-lo), end-first)
+			h.Write(buf)
+			if end == last {
+				break
+			}
+			first = end + 1
+		}
+	}
+	if open {
+		nodes = appendDigest(nodes, h, chunk)
+	}
+	return nodes
+}
+
+// digestParents hashes each group of fanout nodes of a level.
+func digestParents(level []DigestNode, fanout uint64) []DigestNode {
+	var nodes []DigestNode
+	h := sha256.New()
+	var buf []byte
+	for j := 0; j < len(level); {
+		i := level[j].Index / fanout
+		for ; j < len(level) && level[j].Index/fanout == i; j++ {
+			buf = appendUvarint(buf[:0], level[j].Index%fanout)
+			h.Write(buf)
+			h.Write(level[j].Sum[:])
+		}
+		nodes = appendDigest(nodes, h, i)
+	}
+	return nodes
+}
+
+// appendDigest appends a node of the sum of h, and resets h.
+func appendDigest(nodes []DigestNode, h hash.Hash, i uint64) []DigestNode {
+	n := DigestNode{Index: i}
+	h.Sum(n.Sum[:0])
+	h.Reset()
+	return append(nodes, n)
+}
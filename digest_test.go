@@ -0,0 +1,70 @@
+package skiptake
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func Test_Digest(t *testing.T) {
+	a := makeRange(intrv{5, 40}, intrv{100, 100}, intrv{1000, 5000})
+	d := a.Digest(16, 4)
+	if len(d.Levels) != 31 {
+		t.Errorf("Digest has %d levels, expected 31", len(d.Levels))
+	}
+	// Chunks 0-2, 6 and 62-312.
+	if len(d.Levels[0]) != 3+1+251 || d.Levels[0][3].Index != 6 {
+		t.Errorf("Digest has %d leaves", len(d.Levels[0]))
+	}
+	if top := d.Levels[len(d.Levels)-1]; len(top) != 1 || top[0].Index != 0 {
+		t.Errorf("Digest top level = %v", top)
+	}
+	if c := d.Children(1, 1); len(c) != 1 || c[0].Index != 6 {
+		t.Errorf("Children(1, 1) = %v", c)
+	}
+
+	// The digest does not depend on the encoding.
+	raw := FromRaw(5, 10, 0, 26, 59, 1, 899, 4001)
+	if ra, _ := raw.Digest(16, 4).Root(); ra != mustRoot(t, d) {
+		t.Errorf("Digest of raw encoding differs")
+	}
+	if _, ok := (List{}).Digest(16, 4).Root(); ok {
+		t.Errorf("Digest of empty list has a root")
+	}
+}
+
+func mustRoot(t *testing.T, d Digest) [32]byte {
+	root, ok := d.Root()
+	if !ok {
+		t.Fatal("Digest has no root")
+	}
+	return root
+}
+
+func Test_DiffDigests(t *testing.T) {
+	a := makeRange(intrv{5, 40}, intrv{100, 100}, intrv{1000, 5000}, intrv{math.MaxUint64 - 3, math.MaxUint64})
+	b := makeRange(intrv{5, 40}, intrv{1000, 2999}, intrv{3001, 5000}, intrv{1 << 40, 1 << 40}, intrv{math.MaxUint64 - 3, math.MaxUint64})
+	da, db := a.Digest(16, 4), b.Digest(16, 4)
+
+	diff, err := DiffDigests(da, db)
+	expected := makeRange(intrv{96, 111}, intrv{2992, 3007}, intrv{1 << 40, 1<<40 + 15})
+	if err != nil || !bytes.Equal(diff, expected) {
+		t.Errorf("DiffDigests() = %v, %v, expected %v", diff, err, expected)
+	}
+	// Each list restricted to the differing chunks, when exchanged,
+	// synchronizes the other.
+	synced := Union(Difference(a, diff), Intersection(b, diff))
+	if !bytes.Equal(synced, b) {
+		t.Errorf("Synchronized list differs")
+	}
+
+	if diff, err := DiffDigests(da, da); err != nil || len(diff) != 0 {
+		t.Errorf("DiffDigests() of equal digests = %v, %v", diff, err)
+	}
+	if diff, err := DiffDigests(da, List{}.Digest(16, 4)); err != nil || !bytes.Equal(diff, makeRange(intrv{0, 47}, intrv{96, 111}, intrv{992, 5007}, intrv{math.MaxUint64 - 15, math.MaxUint64})) {
+		t.Errorf("DiffDigests() with empty = %v, %v", diff, err)
+	}
+	if _, err := DiffDigests(da, b.Digest(32, 4)); err != ErrDigestMismatch {
+		t.Errorf("DiffDigests() of mismatched digests = %v", err)
+	}
+}
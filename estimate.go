@@ -0,0 +1,318 @@
+package skiptake
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Estimates of the sizes of lists from samples of their encoding, for query
+// planning where exact counts would cost a full decode.
+
+// estimateSection is how many bytes of a list are decoded in each sample.
+const estimateSection = 256
+
+// EstimateLen returns an estimate of Len(), decoding only about sampleBytes
+// bytes of the list. See EstimateLenBound().
+func (l List) EstimateLen(sampleBytes int) (uint64, error) {
+	n, _, err := l.EstimateLenBound(sampleBytes)
+	return n, err
+}
+
+// EstimateLenBound returns an estimate of Len(), decoding only about
+// sampleBytes bytes of the list, along with a bound on its error of three
+// standard errors, so the true length lies within n ± bound with high
+// confidence. With few samples, the bound is itself only a rough estimate.
+//
+// The start of the list is decoded, and the count of members per byte of the
+// rest is extrapolated from sections at random offsets, one from each of
+// equal strata. The sampling is seeded by the length of the list, so the
+// estimate for a list is always the same. Lists no longer than sampleBytes are
+// counted exactly, with a bound of zero.
+//
+// The varints of the sampled sections are checked as by Validate(), returning
+// ErrTruncated or ErrOverflow if malformed.
+func (l List) EstimateLenBound(sampleBytes int) (n, bound uint64, err error) {
+	if sampleBytes < 2*estimateSection {
+		sampleBytes = 2 * estimateSection
+	}
+	if len(l) <= sampleBytes {
+		if err := l.Validate(); err != nil {
+			return 0, 0, err
+		}
+		return l.Len(), 0, nil
+	}
+
+	prefix, p, err := l.sampleSection(0, estimateSection)
+	if err != nil {
+		return 0, 0, err
+	}
+	rng := rand.New(rand.NewSource(int64(len(l))))
+	strata := sampleBytes/estimateSection - 1
+	width := (len(l) - p) / strata
+	members := make([]float64, 0, strata)
+	sizes := make([]float64, 0, strata)
+	var m, b float64
+	for s := 0; s < strata; s++ {
+		start := p + s*width
+		if width > estimateSection {
+			start += rng.Intn(width/estimateSection) * estimateSection
+		}
+		k, size, err := l.sampleSection(start, start+estimateSection)
+		if err != nil {
+			return 0, 0, err
+		}
+		if size > 0 {
+			members = append(members, k)
+			sizes = append(sizes, float64(size))
+			m += k
+			b += float64(size)
+		}
+	}
+
+	rest := float64(len(l) - p)
+	if b == 0 {
+		return clampUint64(prefix), clampUint64(rest), nil
+	}
+	ratio := m / b
+	estimate := prefix + ratio*rest
+	if len(members) < 2 {
+		return clampUint64(estimate), clampUint64(ratio * rest), nil
+	}
+	// The variance of the ratio estimator of members per byte.
+	var ss float64
+	for i := range members {
+		r := members[i] - ratio*sizes[i]
+		ss += r * r
+	}
+	k := float64(len(members))
+	mean := b / k
+	stderr := math.Sqrt(ss/(k-1)/k) / mean
+	return clampUint64(estimate), clampUint64(3 * stderr * rest), nil
+}
+
+// sampleSection decodes the pairs of the list which start within
+// [start, end). Returns how many members they hold, and how many bytes they
+// span. Unless start is zero, decoding begins at the first pair found whose
+// take is not elided, so that it can be counted.
+func (l List) sampleSection(start, end int) (members float64, size int, err error) {
+	i := start
+	if i > 0 {
+		// Find the start of a varint, then of a skip directly followed by its
+		// take.
+		for i < end && l[i-1] >= 0x80 {
+			i++
+		}
+		for i < end {
+			j := i
+			if _, e := readVarint2(l, &j, defaultSplit); e == skipFlag && j < len(l) {
+				k := j
+				if _, e := readVarint2(l, &k, defaultSplit); e == takeFlag {
+					break
+				}
+			}
+			i = j
+		}
+	}
+	if i >= end {
+		return 0, 0, nil
+	}
+	d := Decoder{Elements: l, i: i}
+	for d.i < end && !d.EOS() {
+		_, take, count := d.NextRun()
+		members += float64(take) * float64(count)
+	}
+	for j := i; j < d.i; {
+		if err := checkVarint2(l, &j); err != nil {
+			return 0, 0, err
+		}
+	}
+	return members, d.i - i, nil
+}
+
+// clampUint64 converts f to a uint64, clamped to its range.
+func clampUint64(f float64) uint64 {
+	switch {
+	case f <= 0:
+		return 0
+	case f >= math.MaxUint64:
+		return math.MaxUint64
+	}
+	return uint64(f)
+}
+
+// SizeEstimate bounds the size of the result of a set operation.
+type SizeEstimate struct {
+	Min      uint64 // The result holds at least Min members
+	Max      uint64 // The result holds at most Max members
+	Expected uint64 // The expected size, if members are spread evenly
+}
+
+// EstimateUnionLen returns bounds on Union(lists...).Len(), without computing
+// the union. Each list is decoded once to find its length and bounds, which
+// is far cheaper than merging them.
+//
+// The expected size assumes the members of each list are independently and
+// evenly spread between its least and greatest members. It is always within
+// [Min, Max].
+func EstimateUnionLen(lists ...List) SizeEstimate {
+	lists, _ = distinct(lists)
+	sums := summarizeAll(lists)
+	var e SizeEstimate
+	lo, hi := uint64(math.MaxUint64), uint64(0)
+	for _, s := range sums {
+		if s.len > e.Min {
+			e.Min = s.len
+		}
+		if e.Max += s.len; e.Max < s.len {
+			e.Max = math.MaxUint64
+		}
+		if s.first < lo {
+			lo = s.first
+		}
+		if s.last > hi {
+			hi = s.last
+		}
+	}
+	if len(sums) == 0 {
+		return e
+	}
+	if w := hi - lo + 1; w != 0 && w < e.Max {
+		e.Max = w
+	}
+
+	// Sum, over each segment between the bounds of the lists, the chance
+	// that a value is a member of any of them.
+	var points []uint64
+	for _, s := range sums {
+		points = append(points, s.first, s.last)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	k := 0
+	for _, p := range points {
+		if k == 0 || p != points[k-1] {
+			points[k] = p
+			k++
+		}
+	}
+	points = points[:k]
+	var expected float64
+	for i, p := range points {
+		// Each point as a segment of its own, then the values between it and
+		// the next.
+		expected += 1 - absentAll(sums, p)
+		if i+1 < len(points) && points[i+1] > p+1 {
+			expected += float64(points[i+1]-p-1) * (1 - absentAll(sums, p+1))
+		}
+	}
+	e.Expected = clampRange(expected, e.Min, e.Max)
+	return e
+}
+
+// EstimateIntersectionLen returns bounds on Intersection(lists...).Len(),
+// without computing the intersection. See EstimateUnionLen().
+func EstimateIntersectionLen(lists ...List) SizeEstimate {
+	lists, _ = distinct(lists)
+	sums := summarizeAll(lists)
+	var e SizeEstimate
+	if len(sums) == 0 || len(sums) < len(lists) {
+		return e
+	}
+	lo, hi := uint64(0), uint64(math.MaxUint64)
+	e.Max = math.MaxUint64
+	for _, s := range sums {
+		if s.len < e.Max {
+			e.Max = s.len
+		}
+		if s.first > lo {
+			lo = s.first
+		}
+		if s.last < hi {
+			hi = s.last
+		}
+	}
+	if lo > hi || e.Max == 0 {
+		return SizeEstimate{}
+	}
+	w := float64(hi-lo) + 1
+	if w < float64(e.Max) {
+		e.Max = uint64(w)
+	}
+
+	// Each list holds at least its length less the values it spans outside
+	// the common range, within it. Those must overlap once they hold more
+	// than the range between them.
+	min := -float64(len(sums)-1) * w
+	expected := w
+	for _, s := range sums {
+		span := float64(s.last-s.first) + 1
+		if inside := float64(s.len) - (span - w); inside > 0 {
+			min += inside
+		}
+		expected *= s.density()
+	}
+	e.Min = clampRange(min, 0, e.Max)
+	e.Expected = clampRange(expected, e.Min, e.Max)
+	return e
+}
+
+// listSummary holds the length and bounds of a list, found in one pass.
+type listSummary struct {
+	len, first, last uint64
+}
+
+// density returns the fraction of the values between the bounds of the list
+// which are members.
+func (s listSummary) density() float64 {
+	return float64(s.len) / (float64(s.last-s.first) + 1)
+}
+
+// summarizeAll returns the summaries of each non-empty list.
+func summarizeAll(lists []List) []listSummary {
+	sums := make([]listSummary, 0, len(lists))
+	for _, l := range lists {
+		var s listSummary
+		var n uint64
+		for d := l.Decode(); !d.EOS(); {
+			skip, take, count := d.NextRun()
+			if take == 0 {
+				n += skip * count
+				continue
+			}
+			if s.len == 0 {
+				s.first = n + skip
+			}
+			n += (skip + take) * count
+			s.last = n - 1
+			s.len += take * count
+		}
+		if s.len > 0 {
+			sums = append(sums, s)
+		}
+	}
+	return sums
+}
+
+// absentAll returns the chance that v is a member of none of the summarized
+// lists.
+func absentAll(sums []listSummary, v uint64) float64 {
+	p := 1.0
+	for _, s := range sums {
+		if v >= s.first && v <= s.last {
+			p *= 1 - s.density()
+		}
+	}
+	return p
+}
+
+// clampRange rounds f to a uint64, clamped to [min, max].
+func clampRange(f float64, min, max uint64) uint64 {
+	v := clampUint64(math.Round(f))
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package skiptake
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// estimateTestList returns a list of about 100KB, dense in its first half and
+// sparse in its second.
+func estimateTestList() List {
+	r := rand.New(rand.NewSource(1))
+	b := Build(&List{})
+	v := uint64(0)
+	for k := 0; k < 40000; k++ {
+		if k < 20000 {
+			v += uint64(r.Intn(4)) + 1
+			b.Next(v)
+			b.Take(uint64(r.Intn(100)))
+		} else {
+			v += uint64(r.Intn(1<<20)) + 1
+			b.Next(v)
+			b.Take(uint64(r.Intn(3)))
+		}
+		v++
+	}
+	return b.Finish()
+}
+
+func Test_EstimateLen(t *testing.T) {
+	l := estimateTestList()
+	exact := l.Len()
+	for _, sample := range []int{1024, 8192, 32768} {
+		n, bound, err := l.EstimateLenBound(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := n - exact
+		if n < exact {
+			diff = exact - n
+		}
+		if diff > exact/100 || (sample > 1024 && diff > bound) || bound > exact/10 {
+			t.Errorf("Sample %d: EstimateLenBound() = %d ± %d, exact %d", sample, n, bound, exact)
+		}
+		if n2, _ := l.EstimateLen(sample); n2 != n {
+			t.Errorf("Sample %d: EstimateLen() = %d, expected %d", sample, n2, n)
+		}
+	}
+
+	small := makeRange(intrv{5, 10}, intrv{100, 200})
+	if n, bound, err := small.EstimateLenBound(1024); n != 107 || bound != 0 || err != nil {
+		t.Errorf("EstimateLenBound() of small list = %d ± %d, %v", n, bound, err)
+	}
+}
+
+func Test_EstimateLenMalformed(t *testing.T) {
+	l := estimateTestList()
+	overflow := append(List{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, l...)
+	if _, err := overflow.EstimateLen(4096); err != ErrOverflow {
+		t.Errorf("EstimateLen() of overflowing list = %v, expected %v", err, ErrOverflow)
+	}
+	if _, err := (List{0x80}).EstimateLen(4096); err != ErrTruncated {
+		t.Errorf("EstimateLen() of truncated list = %v, expected %v", err, ErrTruncated)
+	}
+}
+
+// randomDense returns a list holding each value of [lo, hi) with probability p.
+func randomDense(r *rand.Rand, lo, hi uint64, p float64) List {
+	b := Build(&List{})
+	for v := lo; v < hi; v++ {
+		if r.Float64() < p {
+			b.Next(v)
+		}
+	}
+	return b.Finish()
+}
+
+func checkEstimate(t *testing.T, name string, e SizeEstimate, actual uint64, tolerance float64) {
+	t.Helper()
+	if actual < e.Min || actual > e.Max || e.Expected < e.Min || e.Expected > e.Max {
+		t.Errorf("%s: %+v does not bound %d", name, e, actual)
+	}
+	if diff := float64(e.Expected) - float64(actual); diff > tolerance*float64(actual) || -diff > tolerance*float64(actual) {
+		t.Errorf("%s: expected %d, actual %d", name, e.Expected, actual)
+	}
+}
+
+func Test_EstimateUnionIntersectionLen(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a := randomDense(r, 0, 20000, 0.5)
+	b := randomDense(r, 5000, 30000, 0.2)
+	c := randomDense(r, 10000, 15000, 0.9)
+	far := makeRange(intrv{1 << 40, 1<<40 + 99})
+
+	cases := [][]List{{a, b}, {a, b, c}, {a, c}, {a, far}, {a}, {c, c}, {a, {}}}
+	for i, lists := range cases {
+		name := fmt.Sprintf("Case %d", i)
+		checkEstimate(t, name+" union", EstimateUnionLen(lists...), Union(lists...).Len(), 0.05)
+		checkEstimate(t, name+" intersection", EstimateIntersectionLen(lists...), Intersection(lists...).Len(), 0.2)
+	}
+
+	if e := EstimateUnionLen(a, far); e.Expected != a.Len()+100 || e.Min != a.Len() {
+		t.Errorf("EstimateUnionLen() of disjoint lists = %+v", e)
+	}
+	if e := EstimateIntersectionLen(a, far); e != (SizeEstimate{}) {
+		t.Errorf("EstimateIntersectionLen() of disjoint lists = %+v", e)
+	}
+	full := makeRange(intrv{0, 99})
+	if e := EstimateIntersectionLen(full, full); e != (SizeEstimate{100, 100, 100}) {
+		t.Errorf("EstimateIntersectionLen() of full ranges = %+v", e)
+	}
+	if e := EstimateUnionLen(); e != (SizeEstimate{}) {
+		t.Errorf("EstimateUnionLen() of nothing = %+v", e)
+	}
+}
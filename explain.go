@@ -0,0 +1,82 @@
+package skiptake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explain returns a human-readable dump of the packed encoding of the list, one
+// line per encoded pair, for debugging. Each line gives the byte offset of the
+// pair, the value and varint length of each of its skip, take and repeat
+// count, and the absolute interval(s) it produces. Takes which are not stored,
+// but reuse the previous take, are marked as elided. Eg:
+//
+//		FromRaw(5, 1, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 1, 4).Explain()
+//
+// returns
+//
+//		@0: skip 5 (1 byte), take 1 (elided) -> 5
+//		@1: skip 3 (1 byte), take 2 (1 byte), repeat x5 (1 byte) -> [9 - 10] ... [29 - 30]
+//		@4: skip 1 (1 byte), take 4 (1 byte) -> [32 - 35]
+//
+func (l List) Explain() string {
+	return l.ExplainWith(Options{})
+}
+
+// ExplainWith returns Explain() for a list encoded with the passed options.
+func (l List) ExplainWith(opts Options) string {
+	var b strings.Builder
+	var pos uint64
+	for d := l.DecodeWith(opts); !d.EOS(); {
+		offset := d.i
+		skip, take, count := d.NextRun()
+
+		fmt.Fprintf(&b, "@%d: ", offset)
+		tokens := l[offset:d.i]
+		i := 0
+		_, e := readVarint2(tokens, &i, opts.split())
+		n := i
+		if e == skipFlag {
+			fmt.Fprintf(&b, "skip %d (%s), ", skip, plural(n, "byte"))
+			if i < len(tokens) {
+				readVarint2(tokens, &i, opts.split())
+				fmt.Fprintf(&b, "take %d (%s)", take, plural(i-n, "byte"))
+			} else {
+				fmt.Fprintf(&b, "take %d (elided)", take)
+			}
+		} else {
+			fmt.Fprintf(&b, "skip 0 (elided), take %d (%s)", take, plural(n, "byte"))
+		}
+		if i < len(tokens) {
+			fmt.Fprintf(&b, ", repeat x%d (%s)", count, plural(len(tokens)-i, "byte"))
+		}
+
+		first := pos + skip
+		pos += (skip + take) * count
+		switch {
+		case take == 0:
+			b.WriteString(" -> empty")
+		case count == 1:
+			fmt.Fprintf(&b, " -> %s", formatInterval(first, pos-1))
+		default:
+			fmt.Fprintf(&b, " -> %s ... %s", formatInterval(first, first+take-1), formatInterval(pos-take, pos-1))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// formatInterval formats an interval as List.Format() does.
+func formatInterval(first, last uint64) string {
+	if last <= first {
+		return fmt.Sprintf("%d", first)
+	}
+	return fmt.Sprintf("[%d - %d]", first, last)
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,44 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Explain(t *testing.T) {
+	testCases := []struct {
+		list     List
+		expected string
+	}{
+		{List{}, ""},
+		{
+			FromRaw(5, 1, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 1, 4),
+			"@0: skip 5 (1 byte), take 1 (elided) -> 5\n" +
+				"@1: skip 3 (1 byte), take 2 (1 byte), repeat x5 (1 byte) -> [9 - 10] ... [29 - 30]\n" +
+				"@4: skip 1 (1 byte), take 4 (1 byte) -> [32 - 35]\n",
+		},
+		{
+			FromRaw(0, 3, 200, 0, 1, 3),
+			"@0: skip 0 (elided), take 3 (1 byte) -> [0 - 2]\n" +
+				"@1: skip 200 (2 bytes), take 0 (10 bytes) -> empty\n" +
+				"@13: skip 1 (1 byte), take 3 (1 byte) -> [204 - 206]\n",
+		},
+	}
+	for _, c := range testCases {
+		result := c.list.Explain()
+		t.Logf("%v:\n%s", []byte(c.list), result)
+		if result != c.expected {
+			t.Errorf("Explain() = %q, expected %q", result, c.expected)
+		}
+	}
+
+	opts := Options{Split: 3, ZeroSkips: true}
+	var l List
+	e := l.EncodeWith(opts)
+	e.Add(0, 1)
+	e.Add(0, 1)
+	result := l.ExplainWith(opts)
+	expected := "@0: skip 0 (elided), take 1 (1 byte) -> 0\n@1: skip 0 (1 byte), take 1 (elided) -> 1\n"
+	if result != expected {
+		t.Errorf("ExplainWith(%+v) = %q, expected %q", opts, result, expected)
+	}
+}
@@ -0,0 +1,106 @@
+package skiptake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultFormatValues is how many values the %d verb prints, unless a
+// precision is given.
+const defaultFormatValues = 64
+
+// Formatted is a List which implements the fmt.Formatter interface, for use
+// with the fmt print functions. (List itself cannot, as List.Format() is taken.)
+// The verbs are:
+//
+//		%v, %s  The list as ranges, as List.String(). A precision sets the maximum
+//		        length, as List.Format(), Eg %.40v. %+v prints all ranges.
+//		%d      The values of the list, up to 64 or the precision if given.
+//		%x, %X  The packed encoding in hexadecimal, as for a []byte.
+//		%#v     A Go expression which recreates the list.
+//
+// Eg:
+//
+//		fmt.Printf("%d\n", skiptake.Formatted(l))
+//
+type Formatted List
+
+// Format implements the fmt.Formatter interface.
+func (l Formatted) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		if verb == 'v' && f.Flag('#') {
+			l.formatGo(f)
+			return
+		}
+		maxLen := 120
+		if p, ok := f.Precision(); ok {
+			maxLen = p
+		} else if f.Flag('+') {
+			maxLen = -1
+		}
+		f.Write([]byte(List(l).Format(maxLen)))
+	case 'd':
+		l.formatValues(f)
+	case 'x', 'X':
+		fmt.Fprintf(f, formatDirective(f, verb), []byte(l))
+	default:
+		fmt.Fprintf(f, "%%!%c(skiptake.Formatted=%s)", verb, List(l).String())
+	}
+}
+
+// formatValues writes the values of the list, comma separated, up to the
+// precision or defaultFormatValues, followed by '...' if there are more.
+func (l Formatted) formatValues(f fmt.State) {
+	max, ok := f.Precision()
+	if !ok {
+		max = defaultFormatValues
+	}
+	var b strings.Builder
+	iter := List(l).Iterate()
+	for i, n := 0, iter.Next(); !iter.EOS(); i, n = i+1, iter.Next() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if i == max {
+			b.WriteString("...")
+			break
+		}
+		b.WriteString(strconv.FormatUint(n, 10))
+	}
+	f.Write([]byte(b.String()))
+}
+
+// formatGo writes a call to FromRaw which recreates the list.
+func (l Formatted) formatGo(f fmt.State) {
+	var b strings.Builder
+	b.WriteString("skiptake.FromRaw(")
+	for i, v := range List(l).GetRaw() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.FormatUint(v, 10))
+	}
+	b.WriteString(")")
+	f.Write([]byte(b.String()))
+}
+
+// formatDirective rebuilds the formatting directive held by f, for passing on
+// to another value.
+func formatDirective(f fmt.State, verb rune) string {
+	b := []byte{'%'}
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			b = append(b, byte(flag))
+		}
+	}
+	if w, ok := f.Width(); ok {
+		b = strconv.AppendInt(b, int64(w), 10)
+	}
+	if p, ok := f.Precision(); ok {
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(p), 10)
+	}
+	return string(append(b, string(verb)...))
+}
@@ -0,0 +1,125 @@
+package skiptake
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_Formatted(t *testing.T) {
+	l := makeRange(intrv{1, 4}, intrv{7, 7}, intrv{10, 12})
+	long := makeRange(intrv{0, 99})
+
+	testCases := []struct {
+		format   string
+		list     List
+		expected string
+	}{
+		{"%v", l, "[1 - 4], 7, [10 - 12]"},
+		{"%s", l, "[1 - 4], 7, [10 - 12]"},
+		{"%.10v", l, l.Format(10)},
+		{"%d", l, "1, 2, 3, 4, 7, 10, 11, 12"},
+		{"%.3d", l, "1, 2, 3, ..."},
+		{"%.0d", l, "..."},
+		{"%d", List{}, ""},
+		{"%x", FromRaw(5, 1, 3, 2), fmt.Sprintf("%x", []byte(FromRaw(5, 1, 3, 2)))},
+		{"% X", FromRaw(5, 1, 3, 2), fmt.Sprintf("% X", []byte(FromRaw(5, 1, 3, 2)))},
+		{"%#v", l, "skiptake.FromRaw(1, 4, 2, 1, 2, 3)"},
+		{"%#v", List{}, "skiptake.FromRaw()"},
+		{"%q", l, "%!q(skiptake.Formatted=[1 - 4], 7, [10 - 12])"},
+	}
+	for _, c := range testCases {
+		result := fmt.Sprintf(c.format, Formatted(c.list))
+		t.Logf("%s: %s", c.format, result)
+		if result != c.expected {
+			t.Errorf("Sprintf(%q) = %q, expected %q", c.format, result, c.expected)
+		}
+	}
+
+	d := fmt.Sprintf("%d", Formatted(long))
+	expected := fmt.Sprintf("%d", Formatted(makeRange(intrv{0, defaultFormatValues - 1}))) + ", ..."
+	if d != expected {
+		t.Errorf("Sprintf(%%d) of %d values = %q, expected %q", long.Len(), d, expected)
+	}
+	if s := fmt.Sprintf("%+v", Formatted(Union(long, makeRange(intrv{200, 200})))); s != "[0 - 99], 200" {
+		t.Errorf("Sprintf(%%+v) = %q", s)
+	}
+}
+
+func Test_FormatWith(t *testing.T) {
+	l := makeRange(intrv{2, 5}, intrv{7, 7}, intrv{9, 12}, intrv{30, 31})
+
+	testCases := []struct {
+		opts     FormatOptions
+		expected string
+	}{
+		{FormatOptions{}, "[2 - 5], 7, [9 - 12], [30 - 31]"},
+		{FormatOptions{RangeFormat: "%s-%s", Separator: ","}, "2-5,7,9-12,30-31"},
+		{FormatOptions{Hex: true, RangeFormat: "%s..%s"}, "0x2..0x5, 0x7, 0x9..0xc, 0x1e..0x1f"},
+		{FormatOptions{MaxRanges: 2}, "[2 - 5], 7..."},
+		{FormatOptions{MaxRanges: 2, Ellipsis: " (more)"}, "[2 - 5], 7 (more)"},
+		{FormatOptions{MaxRanges: 4}, "[2 - 5], 7, [9 - 12], [30 - 31]"},
+		{FormatOptions{MaxLen: 14, RangeFormat: "%s-%s", Separator: " "}, "2-5 7 9-12..."},
+	}
+	for _, c := range testCases {
+		result := l.FormatWith(c.opts)
+		t.Logf("%+v: %s", c.opts, result)
+		if result != c.expected {
+			t.Errorf("FormatWith(%+v) = %q, expected %q", c.opts, result, c.expected)
+		}
+	}
+
+	// Format is unchanged.
+	for maxLen, expected := range map[int]string{
+		-1: "[2 - 5], 7, [9 - 12], [30 - 31]",
+		0:  "",
+		10: "[2 - 5]",
+		20: "[2 - 5], 7...",
+		40: "[2 - 5], 7, [9 - 12], [30 - 31]",
+	} {
+		if result := l.Format(maxLen); result != expected {
+			t.Errorf("Format(%d) = %q, expected %q", maxLen, result, expected)
+		}
+	}
+}
+
+func Test_FormatSummarize(t *testing.T) {
+	// 2000 ranges of 2 values, encoded as a repeat.
+	b := Build(&List{})
+	for v := uint64(0); v < 10000; v += 5 {
+		b.Next(v)
+		b.Next(v + 1)
+	}
+	l := b.Finish()
+
+	testCases := []struct {
+		opts     FormatOptions
+		expected string
+	}{
+		{FormatOptions{MaxRanges: 2, Summarize: true}, "[0 - 1], [5 - 6]... +3,996 more in 1,998 ranges"},
+		{FormatOptions{MaxLen: 22, Summarize: true}, "[0 - 1], [5 - 6]... +3,996 more in 1,998 ranges"},
+		{FormatOptions{MaxRanges: 1999, Summarize: true, RangeFormat: "%s-%s", Ellipsis: " …"}, "-9991 … +2 more in 1 range"},
+		{FormatOptions{MaxRanges: 2000, Summarize: true}, l.FormatWith(FormatOptions{})},
+	}
+	for _, c := range testCases {
+		result := l.FormatWith(c.opts)
+		if !strings.HasSuffix(result, c.expected) {
+			t.Errorf("FormatWith(%+v) = %q, expected suffix %q", c.opts, result, c.expected)
+		}
+	}
+
+	// Zero skips and takes within the omitted part.
+	l = FromRaw(1, 1, 1, 2, 0, 3, 4, 0, 0, 1, 2, 5, 5, 0, 1, 1)
+	t.Logf("%v", l)
+	result := l.FormatWith(FormatOptions{MaxRanges: 1, Summarize: true})
+	if expected := "1... +12 more in 4 ranges"; result != expected {
+		t.Errorf("FormatWith() = %q, expected %q", result, expected)
+	}
+
+	for n, expected := range map[uint64]string{0: "0", 999: "999", 1000: "1,000", 123456: "123,456", 1234567: "1,234,567", math.MaxUint64: "18,446,744,073,709,551,615"} {
+		if result := groupDigits(n); result != expected {
+			t.Errorf("groupDigits(%d) = %q, expected %q", n, result, expected)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// A frame is a self-identifying serialization of a list, which records the
+// codec used to pack it. Frames can be concatenated, and read back one by one.
+// A frame is laid out as:
+//
+//		| codec ID (1 byte) | payload length (uvarint) | payload |
+//
+// The payload is the list packed with the codec. For CodecVarint, the payload
+// is identical to the List bytes.
+
+var (
+	// ErrUnknownCodec is returned when a frame names a codec that is not
+	// registered.
+	ErrUnknownCodec = errors.New("skiptake: unknown codec")
+
+	// ErrShortFrame is returned when a frame is truncated.
+	ErrShortFrame = errors.New("skiptake: short frame")
+
+	// ErrCorruptFrame is returned when the payload of a frame can not have
+	// been packed by its codec.
+	ErrCorruptFrame = errors.New("skiptake: corrupt frame payload")
+
+	// ErrTrailingData is returned by Unmarshal when bytes follow the frame.
+	ErrTrailingData = errors.New("skiptake: trailing data after frame")
+
+	// ErrStreamCodec is returned when a frame read from an io.Reader is
+	// packed with a codec other than CodecVarint, which can not be decoded a
+	// window at a time.
+	ErrStreamCodec = errors.New("skiptake: codec can not be streamed")
+)
+
+// AppendFrame appends the list l, packed with the codec registered as id, to
+// dst as a frame. Behaves like append(), and returns the extended slice.
+//
+// The list is packed directly into dst, so no intermediate buffer is
+// allocated.
+func AppendFrame(dst []byte, l List, id CodecID) ([]byte, error) {
+	if id == CodecVarint {
+		return l.AppendTo(dst), nil
+	}
+	c, ok := LookupCodec(id)
+	if !ok {
+		return dst, ErrUnknownCodec
+	}
+	dst = append(dst, byte(id))
+	start := len(dst)
+	e := c.NewEncoder(&dst)
+	if e, ok := e.(*Encoder); ok {
+		// The payload is a list of its own, beginning after the frame header.
+		e.start = start
+	}
+	d := l.Decode()
+	Transcode(e, &d)
+
+	// The payload length is only known once packed, so shift the payload up
+	// to make room for it.
+	var ar [binary.MaxVarintLen64]byte
+	k := binary.PutUvarint(ar[:], uint64(len(dst)-start))
+	dst = append(dst, ar[:k]...)
+	copy(dst[start+k:], dst[start:len(dst)-k])
+	copy(dst[start:], ar[:k])
+	return dst, nil
+}
+
+// AppendFrameBest appends the list l to dst as a frame packed with whichever
+// registered codec packs it smallest, and returns the extended slice along
+// with the ID of the codec chosen. Ties are broken by the lowest ID, so
+// CodecVarint is chosen unless another codec is strictly smaller.
+//
+// Each codec packs the list in turn, which costs a pass over the list per
+// codec. A codec is abandoned as soon as its packing grows larger than the
+// smallest so far. Codecs which pack runs of repeated pairs in one step, as
+// List does, are passed each run whole. Codecs which must be passed the pairs
+// of a run one by one are not tried for lists holding more than
+// frameBestPairsPerByte pairs per byte, as only long runs pack so densely.
+func AppendFrameBest(dst []byte, l List) ([]byte, CodecID) {
+	best, bestID := []byte(l), CodecVarint
+	manyPairs := pairsExceed(l, uint64(len(l))*frameBestPairsPerByte)
+	var buf []byte
+	for _, id := range registeredCodecs() {
+		c, ok := LookupCodec(id)
+		if !ok || id == CodecVarint {
+			continue
+		}
+		buf = buf[:0]
+		if packCodec(c, l, &buf, len(best), manyPairs) {
+			best, bestID = buf, id
+			buf = nil
+		}
+	}
+	if bestID == CodecVarint {
+		return l.AppendTo(dst), CodecVarint
+	}
+	dst = append(dst, byte(bestID))
+	dst = appendUvarint(dst, uint64(len(best)))
+	return append(dst, best...), bestID
+}
+
+// frameBestPairsPerByte is the most pairs per byte of a list for which
+// AppendFrameBest tries codecs which are passed pairs one by one.
+const frameBestPairsPerByte = 16
+
+// runEncoder is implemented by encoders which pack a run of identical pairs in
+// one step, such as *Encoder.
+type runEncoder interface {
+	addRun(skip, take, count uint64)
+}
+
+// addRun adds count copies of the pair.
+func (e *Encoder) addRun(skip, take, count uint64) {
+	e.Add(skip, take)
+	e.addRepeats(count - 1)
+}
+
+// pairsExceed returns true if l holds more than max pairs.
+func pairsExceed(l List, max uint64) bool {
+	var n uint64
+	for d := l.Decode(); !d.EOS(); {
+		_, _, count := d.NextRun()
+		if n += count; n > max || n < count {
+			return true
+		}
+	}
+	return false
+}
+
+// packCodec packs l into *buf with the codec c. Returns false if the packing
+// is not smaller than limit bytes, giving up once it has grown past it, or if
+// the codec can not pack runs in one step and manyPairs is set.
+func packCodec(c Codec, l List, buf *[]byte, limit int, manyPairs bool) bool {
+	e := c.NewEncoder(buf)
+	runs, ok := e.(runEncoder)
+	if !ok && manyPairs {
+		return false
+	}
+	for d := l.Decode(); !d.EOS(); {
+		if ok {
+			runs.addRun(d.NextRun())
+		} else {
+			e.Add(d.Next())
+		}
+		if len(*buf) >= limit {
+			return false
+		}
+	}
+	e.Flush()
+	return len(*buf) < limit
+}
+
+// AppendTo appends the list to dst as a frame packed with CodecVarint, which
+// can be read back with ReadFrame. Behaves like append(), and returns the
+// extended slice. Eg, to write many lists into one buffer:
+//
+//		buf = buf[:0]
+//		for _, l := range lists {
+//			buf = l.AppendTo(buf)
+//		}
+//
+func (l List) AppendTo(dst []byte) []byte {
+	dst = append(dst, byte(CodecVarint))
+	dst = appendUvarint(dst, uint64(len(l)))
+	return append(dst, l...)
+}
+
+// ReadFrame reads the frame at the start of b. Returns the list it holds in
+// the List packing, and the length of the frame in bytes.
+//
+// For frames packed with CodecVarint, the returned list shares memory with b.
+// A payload rejected by its codec's decoder returns the decoder's error, such
+// as ErrCorruptFrame.
+func ReadFrame(b []byte) (List, int, error) {
+	id, payload, n, err := splitFrame(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	if id == CodecVarint {
+		return List(payload), n, nil
+	}
+	c, ok := LookupCodec(id)
+	if !ok {
+		return nil, 0, ErrUnknownCodec
+	}
+	l := List{}
+	e := l.Encode()
+	d := c.NewDecoder(payload)
+	Transcode(&e, d)
+	if d, ok := d.(interface{ Err() error }); ok && d.Err() != nil {
+		return nil, 0, d.Err()
+	}
+	return l, n, nil
+}
+
+// FrameCodec returns the ID of the codec used by the frame at the start of b.
+func FrameCodec(b []byte) (CodecID, error) {
+	if len(b) == 0 {
+		return 0, ErrShortFrame
+	}
+	return CodecID(b[0]), nil
+}
+
+// Marshal returns the list l packed with the codec registered as id, as a
+// single frame.
+func Marshal(l List, id CodecID) ([]byte, error) {
+	return AppendFrame(nil, l, id)
+}
+
+// Unmarshal returns the list held by the single frame b, which may have been
+// packed with any registered codec.
+func Unmarshal(b []byte) (List, error) {
+	l, n, err := ReadFrame(b)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(b) {
+		return nil, ErrTrailingData
+	}
+	return l, nil
+}
+
+// splitFrame splits the frame at the start of b into its codec ID and payload.
+// Returns the length of the frame.
+func splitFrame(b []byte) (CodecID, []byte, int, error) {
+	if len(b) == 0 {
+		return 0, nil, 0, ErrShortFrame
+	}
+	size, k := binary.Uvarint(b[1:])
+	if k <= 0 || uint64(len(b)-1-k) < size {
+		return 0, nil, 0, ErrShortFrame
+	}
+	n := 1 + k + int(size)
+	return CodecID(b[0]), b[1+k : n], n, nil
+}
+
+func appendUvarint(dst []byte, v uint64) []byte {
+	var ar [binary.MaxVarintLen64]byte
+	return append(dst, ar[:binary.PutUvarint(ar[:], v)]...)
+}
@@ -0,0 +1,185 @@
+package skiptake
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func Test_FrameRoundTrip(t *testing.T) {
+	list := Create(0, 1, 2, 3, 10, 20, 21, 22, 1000, 0xfffffffffffffff0, 0xffffffffffffffff)
+	for _, id := range []CodecID{CodecVarint, CodecVarintZeroSkips, CodecGroup, CodecSimple8b, CodecDict} {
+		c, _ := LookupCodec(id)
+		t.Run(c.Name, func(t *testing.T) {
+			b, err := Marshal(list, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Framed as %d bytes: %v", len(b), b)
+			if got, _ := FrameCodec(b); got != id {
+				t.Errorf("FrameCodec() = %d, expected %d", got, id)
+			}
+			result, err := Unmarshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(result, list) {
+				t.Errorf("%v != %v", result, list)
+			}
+		})
+	}
+}
+
+func Test_FrameConcatenated(t *testing.T) {
+	lists := []List{Create(1, 2, 3), List{}, Create(7, 100)}
+	var b []byte
+	var err error
+	for i, l := range lists {
+		b, err = AppendFrame(b, l, CodecID(i%2)*CodecGroup)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, l := range lists {
+		result, n, err := ReadFrame(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(result, l) {
+			t.Errorf("%v != %v", result, l)
+		}
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		t.Errorf("%d bytes remaining", len(b))
+	}
+}
+
+func Test_FrameAppendTo(t *testing.T) {
+	// A list long enough for the payload length to need a multi-byte uvarint.
+	b := Build(&List{})
+	for v := uint64(0); v < 10000; v += 2 + v%7 {
+		b.Next(v)
+	}
+	long := b.Finish()
+	lists := []List{Create(0, 1, 2), List{}, long, Create(7, 100)}
+
+	var buf []byte
+	for _, l := range lists {
+		buf = l.AppendTo(buf)
+	}
+	for _, l := range lists {
+		result, n, err := ReadFrame(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(result, l) {
+			t.Errorf("%v != %v", result, l)
+		}
+		buf = buf[n:]
+	}
+
+	// Writing into a buffer with capacity does not allocate.
+	buf = make([]byte, 0, 2*len(long))
+	allocs := testing.AllocsPerRun(10, func() { buf = long.AppendTo(buf[:0]) })
+	if allocs != 0 {
+		t.Errorf("AppendTo() made %v allocations", allocs)
+	}
+
+	// Other codecs are packed in place after existing data.
+	for _, id := range []CodecID{CodecVarintZeroSkips, CodecGroup, CodecSimple8b, CodecDict} {
+		for _, l := range lists {
+			expected, _ := Marshal(l, id)
+			result, err := AppendFrame([]byte{1, 2, 3}, l, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result[3:]) != string(expected) || string(result[:3]) != "\x01\x02\x03" {
+				t.Errorf("AppendFrame(codec %d) = %v, expected %v", id, result, expected)
+			}
+		}
+	}
+}
+
+func Test_FrameErrors(t *testing.T) {
+	if _, err := Marshal(Create(1), CodecUser+1); err != ErrUnknownCodec {
+		t.Errorf("Marshal with unregistered codec: %v", err)
+	}
+	if _, err := Unmarshal([]byte{byte(CodecUser + 1), 0}); err != ErrUnknownCodec {
+		t.Errorf("Unmarshal with unregistered codec: %v", err)
+	}
+	if _, err := Unmarshal([]byte{byte(CodecVarint), 3, 1}); err != ErrShortFrame {
+		t.Errorf("Unmarshal truncated: %v", err)
+	}
+	if _, err := Unmarshal([]byte{byte(CodecVarint), 0, 1}); err != ErrTrailingData {
+		t.Errorf("Unmarshal trailing: %v", err)
+	}
+}
+
+func Test_AppendFrameBest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	pairs := [][2]uint64{{1000, 7}, {3, 200}, {50000, 1}, {9, 9}}
+	var few, small []uint64
+	for i := 0; i < 2000; i++ {
+		p := pairs[r.Intn(len(pairs))]
+		few = append(few, p[0], p[1])
+		small = append(small, uint64(r.Intn(3)+1), uint64(r.Intn(3)+1))
+	}
+	cases := []struct {
+		l        List
+		expected CodecID
+	}{
+		{FromRaw(few...), CodecDict},
+		{FromRaw(small...), CodecSimple8b},
+		{makeRange(intrv{0, 10}), CodecVarint},
+		{List{}, CodecVarint},
+	}
+	for i, c := range cases {
+		prefix := []byte("prefix")
+		frame, id := AppendFrameBest(prefix, c.l)
+		if id != c.expected {
+			t.Errorf("Case %d: AppendFrameBest() chose codec %d, expected %d", i, id, c.expected)
+		}
+		expected, _ := AppendFrame([]byte("prefix"), c.l, c.expected)
+		if !bytes.Equal(frame, expected) {
+			t.Errorf("Case %d: AppendFrameBest() frame differs from AppendFrame()", i)
+		}
+		l, n, err := ReadFrame(frame[len(prefix):])
+		if err != nil || n != len(frame)-len(prefix) || !equalUint64(l.Expand(), c.l.Expand()) {
+			t.Errorf("Case %d: frame read back as %v, %d, %v", i, l, n, err)
+		}
+	}
+
+	b := Build(&List{})
+	for _, v := range []uint64{3, 4, 5, 10} {
+		b.Next(v)
+	}
+	if frame, id := b.FinishFrame(nil); id != CodecVarint || !bytes.Equal(frame, Create(3, 4, 5, 10).AppendTo(nil)) {
+		t.Errorf("FinishFrame() = %v, %d", frame, id)
+	}
+}
+
+func Test_AppendFrameBestRepeats(t *testing.T) {
+	// A short list of 2^40 repeated pairs, as built by Downsample.
+	var l List
+	b := Build(&l)
+	b.progression(10, 3, 1<<40)
+	frame, id := b.FinishFrame(nil)
+	if len(frame) > len(l)+2 {
+		t.Errorf("FinishFrame() packed %d bytes as %d bytes with codec %d", len(l), len(frame), id)
+	}
+	r, err := Unmarshal(frame)
+	if err != nil || string(r) != string(l) {
+		t.Errorf("Unmarshal() = %v, %v, expected %v", r, err, l)
+	}
+
+	// Packed with List's zero skips variant, runs are transcoded whole both
+	// ways.
+	packed, err := Marshal(l, CodecVarintZeroSkips)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := Unmarshal(packed); err != nil || string(r) != string(l) {
+		t.Errorf("Unmarshal() of zero skips = %v, %v, expected %v", r, err, l)
+	}
+}
@@ -0,0 +1,115 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// The two-list set operations, Union2, Intersection2 and Difference, are the
+// most common, and are merged by fused kernels. Rather than reading intervals
+// through an Iterator and the Intervals interface, and writing them through a
+// Builder, the kernels read intervals straight from a Decoder with a span, and
+// write them straight to an Encoder with a spanWriter.
+
+// span reads the intervals of a packed list directly from its Decoder. The
+// current interval is [first, last], with first greater than last at
+// end-of-sequence. Like Iterator.NextSkipTake, zero skips and zero takes are
+// coalesced, along with any repeats of them in one step.
+//
+// To find where an interval ends, the pair following it must be read. That
+// pair is held for the next interval, rather than peeked at and read again.
+type span struct {
+	d     Decoder
+	n     uint64 // The value after the end of the last pair read
+	skip  uint64 // The pair read ahead
+	take  uint64
+	ahead bool
+	first uint64
+	last  uint64
+}
+
+// newSpan returns a span over l, which must be primed with next().
+func newSpan(l List) *span {
+	return &span{d: l.Decode()}
+}
+
+// read reads the next pair into skip and take. Returns false at
+// end-of-sequence.
+func (s *span) read() bool {
+	if s.ahead {
+		s.ahead = false
+		return true
+	}
+	if s.d.EOS() {
+		return false
+	}
+	s.skip, s.take = s.d.Next()
+	return true
+}
+
+// next reads the next interval.
+func (s *span) next() {
+	for {
+		if !s.read() {
+			s.first, s.last = math.MaxUint64, 0
+			return
+		}
+		if s.take > 0 {
+			s.n += s.skip
+			break
+		}
+		// Repeats of a zero take only lengthen the skip.
+		s.n += s.skip + s.skip*s.d.skipRepeats(s.d.repeat)
+	}
+	s.first = s.n
+	s.n += s.take
+	for s.read() {
+		if s.skip != 0 {
+			s.ahead = true
+			break
+		}
+		s.n += s.take + s.take*s.d.skipRepeats(s.d.repeat)
+	}
+	// n wraps to zero for an interval ending at math.MaxUint64.
+	s.last = s.n - 1
+}
+
+// spanWriter writes ascending intervals directly to an Encoder as skip-take
+// pairs, joining those that abut.
+type spanWriter struct {
+	e       Encoder
+	n       uint64 // The value after the end of the last pair written
+	first   uint64 // Pending interval
+	last    uint64
+	pending bool
+}
+
+// newSpanWriter returns a spanWriter which writes to l.
+func newSpanWriter(l *List) *spanWriter {
+	return &spanWriter{e: l.Encode()}
+}
+
+// interval adds the inclusive interval [first, last]. first must be greater
+// than all previous values.
+func (w *spanWriter) interval(first, last uint64) {
+	if w.pending && first == w.last+1 {
+		w.last = last
+		return
+	}
+	w.flush()
+	w.first, w.last, w.pending = first, last, true
+}
+
+// Finish writes any pending interval, and returns the list written.
+func (w *spanWriter) Finish() List {
+	w.flush()
+	w.e.Flush()
+	return *w.e.Elements
+}
+
+func (w *spanWriter) flush() {
+	if w.pending {
+		w.e.Add(w.first-w.n, w.last-w.first+1)
+		w.n = w.last + 1
+		w.pending = false
+	}
+}
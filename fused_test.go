@@ -0,0 +1,79 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Span(t *testing.T) {
+	lists := []List{
+		{},
+		Create(0),
+		makeRange(intrv{0, 9}, intrv{20, 29}),
+		FromRaw(3, 0, 4, 2, 0, 3, 5, 0, 0, 1),
+		FromRaw(0, 5, 0, 0, 2, 2),
+		makeRange(intrv{5, 9}, intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+	}
+	for _, l := range lists {
+		iter := l.Iterate()
+		s := newSpan(l)
+		for {
+			first, last := iter.NextInterval()
+			s.next()
+			if s.first != first || s.last != last {
+				t.Errorf("span of %v = [%d, %d], expected [%d, %d]", l.GetRaw(), s.first, s.last, first, last)
+				break
+			}
+			if first > last {
+				break
+			}
+		}
+
+		w := newSpanWriter(&List{})
+		s = newSpan(l)
+		for s.next(); s.first <= s.last; s.next() {
+			w.interval(s.first, s.last)
+		}
+		if result := w.Finish(); !Equal(result, l) {
+			t.Errorf("spanWriter wrote %v, expected %v", result, l)
+		}
+	}
+}
+
+func Test_SpanRepeats(t *testing.T) {
+	// Short lists holding 2^40 repeats of a zero take pair and of a zero skip
+	// pair, which must be coalesced without reading each repeat.
+	var skips List
+	e := skips.Encode()
+	e.Add(1, 0)
+	e.addRepeats(1<<40 - 1)
+	e.Add(1, 1)
+	var takes List
+	e = takes.Encode()
+	e.Add(5, 1)
+	e.Add(0, 1)
+	e.addRepeats(1<<40 - 1)
+	for _, l := range []List{skips, takes} {
+		if err := l.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := newSpan(skips)
+	if s.next(); s.first != 1<<40+1 || s.last != s.first {
+		t.Errorf("span of zero takes = [%d, %d]", s.first, s.last)
+	}
+	s = newSpan(takes)
+	if s.next(); s.first != 5 || s.last != 5+1<<40 {
+		t.Errorf("span of zero skips = [%d, %d]", s.first, s.last)
+	}
+
+	if u := Union(skips, Create(5)); !Equal(u, Create(5, 1<<40+1)) {
+		t.Errorf("Union() = %v", u.Expand())
+	}
+	if n := Intersection(takes, Create(1, 6, 1<<41)); !Equal(n, Create(6)) {
+		t.Errorf("Intersection() = %v", n.Expand())
+	}
+	if d := Difference(takes, makeRange(intrv{6, 1 << 41})); !Equal(d, Create(5)) {
+		t.Errorf("Difference() = %v", d.Expand())
+	}
+}
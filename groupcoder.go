@@ -0,0 +1,158 @@
+package skiptake
+
+import (
+	"encoding/binary"
+)
+
+// This file implements an alternative byte packing of (skip, take) pairs,
+// based on the 'group varint' scheme, also known as Stream VByte when the
+// control and data bytes are kept in separate streams.
+//
+// Rather than spending a continuation bit in every byte, values are packed in
+// groups of two pairs (four values). Each group begins with a control byte
+// holding a 2-bit width code for each value, followed by the values themselves
+// as little-endian integers of 1, 2, 4 or 8 bytes:
+//
+//		control: | take1 | skip1 | take0 | skip0 |   (2 bits each, low to high)
+//		data:    skip0 take0 skip1 take1
+//
+// The decoder learns the layout of the whole group from a single byte, and so
+// can decode four values without a data-dependent branch per byte. This trades
+// some size for decode throughput: small values cost one byte each as with
+// varints, but there is no take elision and no skip-1/take-1 bias, and a
+// group holds a control byte overhead.
+//
+// A list with an odd number of pairs ends in a group holding a single pair.
+// Its control byte has zero bits for the absent pair, and the decoder detects
+// it by the data ending after the first pair.
+
+var groupWidths = [4]int{1, 2, 4, 8}
+var groupMasks = [4]uint64{0xff, 0xffff, 0xffffffff, 0xffffffffffffffff}
+
+// groupCode returns the 2-bit width code needed to store v.
+func groupCode(v uint64) byte {
+	switch {
+	case v < 1<<8:
+		return 0
+	case v < 1<<16:
+		return 1
+	case v < 1<<32:
+		return 2
+	}
+	return 3
+}
+
+// GroupEncoder packs (skip, take) pairs into Elements using group varints.
+//
+// Pairs are buffered until a group is complete, so Flush must be called after
+// the final pair has been added.
+type GroupEncoder struct {
+	Elements *[]byte
+	pending  [2]uint64
+	buffered bool
+}
+
+// Add adds a new skip-take pair to the sequence.
+func (e *GroupEncoder) Add(skip, take uint64) {
+	if !e.buffered {
+		e.pending = [2]uint64{skip, take}
+		e.buffered = true
+		return
+	}
+	e.buffered = false
+	e.put([]uint64{e.pending[0], e.pending[1], skip, take})
+}
+
+// Flush writes out a buffered, unpaired final pair.
+func (e *GroupEncoder) Flush() {
+	if e.buffered {
+		e.buffered = false
+		e.put(e.pending[:])
+	}
+}
+
+func (e *GroupEncoder) put(values []uint64) {
+	var c byte
+	for j, v := range values {
+		c |= groupCode(v) << (2 * j)
+	}
+	out := append(*e.Elements, c)
+	var ar [8]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(ar[:], v)
+		out = append(out, ar[:groupWidths[groupCode(v)]]...)
+	}
+	*e.Elements = out
+}
+
+// GroupDecoder unpacks (skip, take) pairs packed by a GroupEncoder. A whole
+// group of pairs is decoded at once, and handed out by subsequent calls to
+// Next().
+type GroupDecoder struct {
+	Elements []byte
+	i        int
+	group    [2][2]uint64
+	n        int // Number of pairs decoded into group
+	k        int // Number of pairs of group returned
+}
+
+// Next returns the next pair of skip, take values. Returns (0,0) in the case of
+// end-of-sequence.
+func (d *GroupDecoder) Next() (skip, take uint64) {
+	if d.k == d.n {
+		if d.i >= len(d.Elements) {
+			return 0, 0
+		}
+		if d.fill(); d.n == 0 {
+			// A control byte with no data following.
+			return 0, 0
+		}
+	}
+	p := d.group[d.k]
+	d.k++
+	return p[0], p[1]
+}
+
+// EOS returns if the decoder is at the end of the sequence.
+func (d *GroupDecoder) EOS() bool {
+	return d.k == d.n && d.i >= len(d.Elements)
+}
+
+// Reset resets the location of the decoder to the beginning of the sequence.
+func (d *GroupDecoder) Reset() {
+	d.i, d.n, d.k = 0, 0, 0
+}
+
+// fill decodes the group at the current location.
+func (d *GroupDecoder) fill() {
+	c := d.Elements[d.i]
+	d.i++
+	d.n, d.k = 0, 0
+	for j := 0; j < 2 && d.i < len(d.Elements); j++ {
+		d.group[j][0] = d.read(c & 3)
+		d.group[j][1] = d.read((c >> 2) & 3)
+		c >>= 4
+		d.n++
+	}
+}
+
+// read reads a single little-endian value of the width given by code. When at
+// least 8 bytes remain, the value is read with a single load and masked. A
+// value truncated by the end of the data reads only the bytes present.
+func (d *GroupDecoder) read(code byte) uint64 {
+	b := d.Elements[d.i:]
+	if len(b) >= 8 {
+		d.i += groupWidths[code]
+		return binary.LittleEndian.Uint64(b) & groupMasks[code]
+	}
+	n := groupWidths[code]
+	if n > len(b) {
+		n = len(b)
+	}
+	d.i += n
+	var v uint64
+	for k := 0; k < n; k++ {
+		v |= uint64(b[k]) << (8 * k)
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_GroupEncodeDecode(t *testing.T) {
+	for name, values := range codecTestCases {
+		t.Run(name, func(t *testing.T) {
+			var b []byte
+			testPairCodec(t, values, &GroupEncoder{Elements: &b}, func() PairDecoder {
+				t.Logf("Encoded as %d bytes: %v", len(b), b)
+				return &GroupDecoder{Elements: b}
+			})
+		})
+	}
+}
+
+func Test_GroupWidths(t *testing.T) {
+	var b []byte
+	e := GroupEncoder{Elements: &b}
+	e.Add(1, 0x100)
+	e.Add(0x10000, 0x100000000)
+	e.Flush()
+
+	// One control byte, then values of 1, 2, 4 and 8 bytes.
+	if len(b) != 1+1+2+4+8 {
+		t.Errorf("Encoded length %d, expected %d: %v", len(b), 1+1+2+4+8, b)
+	}
+	if b[0] != 0xe4 {
+		t.Errorf("Control byte %#x != %#x", b[0], 0xe4)
+	}
+}
+
+func Test_GroupDecodeTruncated(t *testing.T) {
+	// A group whose data ends within a value decodes the bytes present,
+	// without reading past the end.
+	for _, b := range [][]byte{{0x09, 1, 0, 1, 2, 1, 1}, {0xff, 1, 2, 3}, {0xff}} {
+		d := GroupDecoder{Elements: b}
+		for n := 0; !d.EOS(); n++ {
+			if n > len(b) {
+				t.Fatalf("Decoding %v did not end", b)
+			}
+			d.Next()
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package skiptake
+
+import (
+	"math"
+	"sort"
+)
+
+// Set operations on plain slices of inclusive [first, last] intervals, so
+// that ranges held by a caller need not first be encoded into temporary
+// lists.
+
+// UnionIntervals returns a list of the values within any of the inclusive
+// [first, last] intervals of ivs. The intervals may be in any order, and may
+// overlap. An interval with first greater than last is empty. Eg:
+//
+//		l := skiptake.UnionIntervals([][2]uint64{{100, 199}, {0, 9}, {150, 300}})
+//
+// ivs is not modified.
+func UnionIntervals(ivs [][2]uint64) List {
+	b := Build(&List{})
+	for _, iv := range mergeIntervals(ivs) {
+		b.interval(iv[0], iv[1])
+	}
+	return b.Finish()
+}
+
+// IntersectIntervals returns a list of the members of a within any of the
+// inclusive [first, last] intervals of ivs, as UnionIntervals().
+func IntersectIntervals(a List, ivs [][2]uint64) List {
+	merged := mergeIntervals(ivs)
+	if len(merged) == 0 {
+		return List{}
+	}
+	iter := a.Iterate()
+	b := Build(&List{})
+	intersection(&b, sourceAll([]Intervals{&iter, &sliceIntervals{ivs: merged}}), merged[len(merged)-1][1])
+	return b.Finish()
+}
+
+// sliceIntervals yields the intervals of a sorted slice of disjoint intervals
+// as Intervals.
+type sliceIntervals struct {
+	ivs [][2]uint64
+}
+
+// NextInterval returns the next interval of the slice. Returns
+// (math.MaxUint64, 0) at end of stream.
+func (s *sliceIntervals) NextInterval() (first, last uint64) {
+	if len(s.ivs) == 0 {
+		return math.MaxUint64, 0
+	}
+	iv := s.ivs[0]
+	s.ivs = s.ivs[1:]
+	return iv[0], iv[1]
+}
+
+// mergeIntervals returns the non-empty intervals of ivs sorted by first
+// value, with those which overlap or abut merged.
+func mergeIntervals(ivs [][2]uint64) [][2]uint64 {
+	merged := make([][2]uint64, 0, len(ivs))
+	for _, iv := range ivs {
+		if iv[0] <= iv[1] {
+			merged = append(merged, iv)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i][0] < merged[j][0] })
+	k := 0
+	for _, iv := range merged {
+		switch {
+		case k == 0 || (merged[k-1][1] != math.MaxUint64 && iv[0] > merged[k-1][1]+1):
+			merged[k] = iv
+			k++
+		case iv[1] > merged[k-1][1]:
+			merged[k-1][1] = iv[1]
+		}
+	}
+	return merged[:k]
+}
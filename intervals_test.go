@@ -0,0 +1,41 @@
+package skiptake
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_UnionIntervalSlices(t *testing.T) {
+	ivs := [][2]uint64{{100, 199}, {0, 9}, {150, 300}, {10, 10}, {50, 40}, {302, 302}}
+	orig := append([][2]uint64{}, ivs...)
+	expected := makeRange(intrv{0, 10}, intrv{100, 300}, intrv{302, 302})
+	if l := UnionIntervals(ivs); string(l) != string(expected) {
+		t.Fatalf("expected %v, got %v", expected.Expand(), l.Expand())
+	}
+	for i := range ivs {
+		if ivs[i] != orig[i] {
+			t.Fatal("intervals modified")
+		}
+	}
+	if l := UnionIntervals(nil); l == nil || !l.IsEmpty() {
+		t.Fatal("expected empty list")
+	}
+	if l := UnionIntervals([][2]uint64{{math.MaxUint64 - 1, math.MaxUint64}, {math.MaxUint64, math.MaxUint64}}); !equalUint64(l.Expand(), []uint64{math.MaxUint64 - 1, math.MaxUint64}) {
+		t.Fatalf("unexpected list at end of range %v", l.Expand())
+	}
+}
+
+func Test_IntersectIntervalSlices(t *testing.T) {
+	a := makeRange(intrv{0, 20}, intrv{100, 200}, intrv{500, 600})
+	ivs := [][2]uint64{{150, 520}, {10, 12}, {15, 11}, {13, 14}}
+	expected := Intersection(a, UnionIntervals(ivs))
+	if l := IntersectIntervals(a, ivs); string(l) != string(expected) {
+		t.Fatalf("expected %v, got %v", expected.Expand(), l.Expand())
+	}
+	if l := IntersectIntervals(a, nil); l == nil || !l.IsEmpty() {
+		t.Fatal("expected empty list")
+	}
+	if l := IntersectIntervals(List{}, ivs); !l.IsEmpty() {
+		t.Fatal("expected empty list")
+	}
+}
@@ -0,0 +1,148 @@
+// Package ipset holds sets of IPv4 addresses as skip-take lists, with each
+// address as its 32-bit value. Sets built from addresses and CIDR blocks can be
+// combined with the set algebra of skiptake, and rendered back as the minimal
+// list of CIDR blocks covering them. Eg, to minimize a firewall rule:
+//
+//		allow, _ := ipset.ParseCIDRs("10.0.0.0/24", "10.0.1.0/24", "10.0.2.5/32")
+//		deny, _ := ipset.ParseCIDRs("10.0.0.128/25")
+//		rule := ipset.CIDRStrings(skiptake.Difference(allow, deny))
+//		// [10.0.0.0/25 10.0.1.0/24 10.0.2.5/32]
+//
+package ipset
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"net"
+	"sort"
+
+	"github.com/arthurt/skiptake"
+)
+
+// ErrNotIPv4 is returned when an address or network is not IPv4.
+var ErrNotIPv4 = errors.New("ipset: not an IPv4 address")
+
+// maxAddr is the number of IPv4 addresses, one past the largest.
+const maxAddr = 1 << 32
+
+// Uint32 returns the value of an IPv4 address. ok is false if ip is not IPv4.
+func Uint32(ip net.IP) (v uint32, ok bool) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(ip4), true
+}
+
+// IP returns the IPv4 address with value v.
+func IP(v uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, v)
+	return ip
+}
+
+// FromAddrs returns the set of the addresses, which may be in any order and
+// may repeat.
+func FromAddrs(addrs ...net.IP) (skiptake.List, error) {
+	values := make([]uint64, len(addrs))
+	for i, ip := range addrs {
+		v, ok := Uint32(ip)
+		if !ok {
+			return nil, ErrNotIPv4
+		}
+		values[i] = uint64(v)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	b := skiptake.Build(&skiptake.List{})
+	for _, v := range values {
+		b.Next(v) // Repeats are ignored
+	}
+	return b.Finish(), nil
+}
+
+// FromRange returns the set of the addresses from first to last, inclusive.
+func FromRange(first, last net.IP) (skiptake.List, error) {
+	f, ok := Uint32(first)
+	l, ok2 := Uint32(last)
+	if !ok || !ok2 {
+		return nil, ErrNotIPv4
+	}
+	if f > l {
+		return skiptake.List{}, nil
+	}
+	return skiptake.FromRaw(uint64(f), uint64(l-f)+1), nil
+}
+
+// FromNets returns the set of the addresses in any of the networks.
+func FromNets(nets ...*net.IPNet) (skiptake.List, error) {
+	lists := make([]skiptake.List, len(nets))
+	for i, n := range nets {
+		v, ok := Uint32(n.IP)
+		ones, size := n.Mask.Size()
+		if !ok || size != 32 {
+			return nil, ErrNotIPv4
+		}
+		block := uint64(1) << uint(32-ones)
+		lists[i] = skiptake.FromRaw(uint64(v)&^(block-1), block)
+	}
+	return skiptake.Union(lists...), nil
+}
+
+// ParseCIDRs returns the set of the addresses in any of the CIDR blocks, such
+// as "192.0.2.0/24". A bare address is taken as a block of one.
+func ParseCIDRs(cidrs ...string) (skiptake.List, error) {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, s := range cidrs {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, err
+			}
+			n = &net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}
+		}
+		nets[i] = n
+	}
+	return FromNets(nets...)
+}
+
+// ToNets returns the minimal list of CIDR blocks, in order, which hold exactly
+// the members of the set. Members which are not IPv4 addresses, of 2^32 or
+// more, are ignored.
+func ToNets(l skiptake.List) []*net.IPNet {
+	nets := []*net.IPNet{}
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last && first < maxAddr; first, last = iter.NextInterval() {
+		if last >= maxAddr {
+			last = maxAddr - 1
+		}
+		// The largest aligned block at first which does not pass last.
+		for first <= last {
+			size := uint64(maxAddr)
+			if first != 0 {
+				size = 1 << uint(bits.TrailingZeros64(first))
+			}
+			for size > last-first+1 {
+				size >>= 1
+			}
+			nets = append(nets, &net.IPNet{
+				IP:   IP(uint32(first)),
+				Mask: net.CIDRMask(32-bits.TrailingZeros64(size), 32),
+			})
+			first += size
+		}
+	}
+	return nets
+}
+
+// CIDRStrings returns ToNets() as strings, such as "192.0.2.0/24".
+func CIDRStrings(l skiptake.List) []string {
+	nets := ToNets(l)
+	s := make([]string, len(nets))
+	for i, n := range nets {
+		s[i] = n.String()
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package ipset
+
+import (
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/arthurt/skiptake"
+)
+
+func Test_FromAddrs(t *testing.T) {
+	l, err := FromAddrs(net.ParseIP("10.0.0.2"), net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"), net.IPv4(192, 168, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(CIDRStrings(l), " "); s != "10.0.0.1/32 10.0.0.2/32 192.168.1.1/32" {
+		t.Errorf("CIDRStrings() = %s", s)
+	}
+	if l.Len() != 3 || l.NumIntervals() != 2 {
+		t.Errorf("Len() = %d, NumIntervals() = %d", l.Len(), l.NumIntervals())
+	}
+	if _, err := FromAddrs(net.ParseIP("2001:db8::1")); err != ErrNotIPv4 {
+		t.Errorf("FromAddrs(IPv6) error = %v", err)
+	}
+}
+
+func Test_FromRange(t *testing.T) {
+	l, err := FromRange(net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.17"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "10.0.0.3/32 10.0.0.4/30 10.0.0.8/29 10.0.0.16/31"
+	if s := strings.Join(CIDRStrings(l), " "); s != expected {
+		t.Errorf("CIDRStrings() = %s, expected %s", s, expected)
+	}
+	if l, _ := FromRange(net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.1")); !l.IsEmpty() {
+		t.Errorf("FromRange() of reversed range = %v", l)
+	}
+}
+
+func Test_ParseCIDRs(t *testing.T) {
+	allow, err := ParseCIDRs("10.0.0.0/24", "10.0.1.0/24", "10.0.2.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deny, err := ParseCIDRs("10.0.0.128/25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "10.0.0.0/25 10.0.1.0/24 10.0.2.5/32"
+	if s := strings.Join(CIDRStrings(skiptake.Difference(allow, deny)), " "); s != expected {
+		t.Errorf("CIDRStrings() = %s, expected %s", s, expected)
+	}
+
+	// Adjacent and overlapping blocks merge, and host bits are masked off
+	l, _ := ParseCIDRs("192.168.0.0/24", "192.168.1.7/24", "192.168.0.64/26")
+	if s := strings.Join(CIDRStrings(l), " "); s != "192.168.0.0/23" {
+		t.Errorf("CIDRStrings() = %s, expected 192.168.0.0/23", s)
+	}
+
+	if _, err := ParseCIDRs("10.0.0.0/33"); err == nil {
+		t.Error("ParseCIDRs(10.0.0.0/33) succeeded")
+	}
+	if _, err := ParseCIDRs("2001:db8::/32"); err != ErrNotIPv4 {
+		t.Errorf("ParseCIDRs(IPv6) error = %v", err)
+	}
+}
+
+func Test_ToNets(t *testing.T) {
+	all, _ := ParseCIDRs("0.0.0.0/0")
+	if s := strings.Join(CIDRStrings(all), " "); s != "0.0.0.0/0" {
+		t.Errorf("CIDRStrings(all) = %s", s)
+	}
+	if all.Len() != 1<<32 {
+		t.Errorf("Len() = %d", all.Len())
+	}
+
+	// Members past the IPv4 range are ignored
+	l := skiptake.FromRaw(1<<32-2, 10)
+	if s := strings.Join(CIDRStrings(l), " "); s != "255.255.255.254/31" {
+		t.Errorf("CIDRStrings() = %s", s)
+	}
+
+	if nets := ToNets(skiptake.List{}); len(nets) != 0 {
+		t.Errorf("ToNets() of empty set = %v", nets)
+	}
+
+	// Round trip, checking that blocks are minimal: no two can merge.
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var cidrs []string
+		for k := 0; k < 1+r.Intn(10); k++ {
+			n := net.IPNet{IP: IP(r.Uint32()), Mask: net.CIDRMask(8+r.Intn(25), 32)}
+			cidrs = append(cidrs, n.String())
+		}
+		l, err := ParseCIDRs(cidrs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nets := ToNets(l)
+		back, _ := FromNets(nets...)
+		if back.String() != l.String() {
+			t.Fatalf("FromNets(ToNets(%v)) = %v", cidrs, nets)
+		}
+		for k := 1; k < len(nets); k++ {
+			a, b := nets[k-1], nets[k]
+			if a.Mask.String() == b.Mask.String() {
+				ones, _ := a.Mask.Size()
+				av, _ := Uint32(a.IP)
+				bv, _ := Uint32(b.IP)
+				block := uint32(1) << uint(32-ones)
+				if ones > 0 && av+block == bv && av&(block<<1-1) == 0 {
+					t.Fatalf("ToNets() blocks %v and %v could merge", a, b)
+				}
+			}
+		}
+	}
+}
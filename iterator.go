@@ -11,6 +11,7 @@ type Iterator struct {
 	skipSum uint64 // How many integers before n were not part of the subsequeunce
 	take    uint64 // Remaining take count in the current interval
 	n       uint64 // Current sub-sequence value
+	fill    func() // If set, called before each read of the Decoder by NextSkipTake
 }
 
 // Reset resets the iterator to it's inital state at the beginning of the list.
@@ -51,23 +52,28 @@ func (t Iterator) EOS() bool {
 func (t *Iterator) NextSkipTake() (skip, take uint64) {
 	// Coalesce zero takes
 	for take == 0 {
-		if t.Decoder.EOS() {
+		if t.refill(); t.Decoder.EOS() {
 			t.n = math.MaxUint64
 			t.take = math.MaxUint64
 			return 0, 0
 		}
 		nskip, ntake := t.Decoder.Next()
+		if ntake == 0 {
+			// Repeats of a zero take only lengthen the skip, so are stepped
+			// over in one step.
+			nskip += nskip * t.Decoder.skipRepeats(t.Decoder.repeat)
+		}
 		skip += nskip
 		take = ntake
 	}
-	// Coalesce zero skips
-	for !t.Decoder.EOS() {
+	// Coalesce zero skips, along with any repeats of them
+	for t.refill(); !t.Decoder.EOS(); t.refill() {
 		nskip := t.Decoder.PeekSkip()
 		if nskip != 0 {
 			break
 		}
-		_, ntake := t.Decoder.Next()
-		take += ntake
+		_, ntake, count := t.Decoder.NextRun()
+		take += ntake * count
 	}
 	t.skipSum += skip
 	t.n += t.take + skip
@@ -75,6 +81,14 @@ func (t *Iterator) NextSkipTake() (skip, take uint64) {
 	return
 }
 
+// refill calls fill, if set, so that a Decoder over a window of a list, such
+// as that of readerIntervals, holds the next pair whole.
+func (t *Iterator) refill() {
+	if t.fill != nil {
+		t.fill()
+	}
+}
+
 // NextInterval fetches the next interval range in the expanded sequence. The
 // values returned are inclusive, that is both first and last are members of
 // the subsequence. In the case of a single-element interval, first and last
@@ -113,6 +127,70 @@ func (t *Iterator) Next() uint64 {
 	return result
 }
 
+// NextOK returns the next value in the subsequence, and true, or false at
+// end-of-sequence. Unlike Next(), a member of math.MaxUint64 can not be
+// mistaken for end-of-sequence, and once at end-of-sequence, NextOK keeps
+// returning false. Eg:
+//
+//		for v, ok := iter.NextOK(); ok; v, ok = iter.NextOK() {
+//			...
+//		}
+//
+func (t *Iterator) NextOK() (uint64, bool) {
+	if t.EOS() {
+		return math.MaxUint64, false
+	}
+	v := t.Next()
+	return v, !t.EOS()
+}
+
+// NextIntervalOK returns the next interval, as NextInterval(), and true, or
+// false at end-of-sequence. Eg:
+//
+//		for first, last, ok := iter.NextIntervalOK(); ok; first, last, ok = iter.NextIntervalOK() {
+//			...
+//		}
+//
+func (t *Iterator) NextIntervalOK() (first, last uint64, ok bool) {
+	first, last = t.NextInterval()
+	return first, last, first <= last
+}
+
+// IntervalSeeker is implemented by sources of intervals which can skip ahead
+// to the intervals holding a value, such as *Iterator. SeekInterval returns
+// the first interval not yet returned whose last value is at least v, and
+// advances past it, as NextInterval() would. Returns (math.MaxUint64, 0) at end
+// of stream.
+type IntervalSeeker interface {
+	Intervals
+	SeekInterval(v uint64) (first, last uint64)
+}
+
+// SeekInterval returns the first interval not yet returned whose last value is
+// at least v, and advances past it. The intervals before it within runs of
+// repeated pairs are stepped over in one step, rather than decoded one by
+// one. Returns (math.MaxUint64, 0) in the case of end of stream.
+func (t *Iterator) SeekInterval(v uint64) (first, last uint64) {
+	for {
+		if d := t.Decoder; d.repeat > 1 && d.lastSkip > 0 && d.lastTake+d.opts.takeBias() != 0 {
+			// Within a run of repeated pairs, as in Seek(). Jump over whole
+			// pairs which end before v, leaving the last repeat to be read
+			// normally in case zero skips follow it.
+			skip, take := d.lastSkip, d.lastTake+d.opts.takeBias()
+			if end := t.n + t.take; v > end {
+				if m := d.skipRepeats(min64(d.repeat-1, (v-end)/(skip+take))); m > 0 {
+					t.skipSum += m * skip
+					t.n += t.take + m*skip + (m-1)*take
+					t.take = take
+				}
+			}
+		}
+		if first, last = t.NextInterval(); first > last || last >= v {
+			return first, last
+		}
+	}
+}
+
 // Seek seeks to the i'th position in the subsequence. Returns the subsequence
 // value at position i as skip, and the count of how many following sequential
 // values as take. These values are identical to what would be the first
@@ -136,6 +214,19 @@ func (t *Iterator) Seek(pos uint64) (uint64, uint64) {
 			t.take = math.MaxUint64
 			return 0, 0
 		}
+		if d := t.Decoder; d.repeat > 1 && d.lastSkip > 0 && d.lastTake+d.opts.takeBias() != 0 {
+			// Within a run of repeated pairs. Jump over whole pairs that end
+			// before pos, leaving the last repeat to be read normally in case
+			// zero skips follow it.
+			skip, take := d.lastSkip, d.lastTake+d.opts.takeBias()
+			if m := d.skipRepeats(min64(d.repeat-1, (pos-takeSum)/take)); m > 0 {
+				t.skipSum += m * skip
+				t.n += t.take + m*skip + (m-1)*take
+				t.take = take
+				takeSum += m * take
+				continue
+			}
+		}
 		_, take := t.NextSkipTake()
 		takeSum += take
 	}
@@ -159,3 +250,28 @@ func (t *Iterator) Interval() (first uint64, last uint64) {
 	}
 	return t.n, t.n + t.take - 1
 }
+
+// IntervalRemaining returns the part of the current interval not yet returned
+// by Next(), that is from the value the following call to Next() returns to
+// the end of the interval, inclusive. Unlike Interval(), it never advances the
+// iterator.
+//
+// Returns (math.MaxUint64, 0) if the current interval has been consumed, at
+// EOS, or before the first call to Next(), NextSkipTake() or Seek(). Eg:
+//
+//		iter.Seek(10)
+//		first, last := iter.IntervalRemaining() // first is the 10th value
+//
+func (t *Iterator) IntervalRemaining() (first uint64, last uint64) {
+	if t.take == 0 || t.EOS() {
+		return math.MaxUint64, 0
+	}
+	return t.n, t.n + t.take - 1
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
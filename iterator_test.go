@@ -1,6 +1,10 @@
 package skiptake
 
-import "testing"
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
 
 func Test_SkipTake_Seek(t *testing.T) {
 
@@ -143,3 +147,193 @@ func Test_SkipTake_IterMixNext(t *testing.T) {
 
 	expectUint64(t, iter.Next(), 40)
 }
+
+func Test_SkipTake_SeekRepeat(t *testing.T) {
+	b := Build(&List{})
+	values := []uint64{}
+	for v := uint64(3); v < 1000; v += 7 {
+		values = append(values, v, v+1, v+2)
+	}
+	for v := uint64(2000); v < 2100; v++ {
+		values = append(values, v)
+	}
+	for _, v := range values {
+		b.Next(v)
+	}
+	list := b.Finish()
+	t.Logf("%d values encoded as %d bytes", len(values), len(list))
+
+	expectUint64(t, list.Len(), uint64(len(values)))
+
+	iter := list.Iterate()
+	for _, pos := range []int{0, 1, 2, 3, 100, 101, 50, 300, 420, 421, 426, len(values) - 1} {
+		skip, _ := iter.Seek(uint64(pos))
+		expectUint64(t, skip, values[pos])
+		expectUint64(t, iter.Next(), values[pos])
+		if pos+1 < len(values) {
+			expectUint64(t, iter.Next(), values[pos+1])
+		}
+	}
+}
+
+func Test_SkipTake_IterateInto(t *testing.T) {
+	lists := []List{
+		makeRange(intrv{0, 9}, intrv{20, 29}),
+		Create(5, 7, 9),
+		List{},
+		makeRange(intrv{100, 199}),
+	}
+
+	var iter Iterator
+	for _, l := range lists {
+		l.IterateInto(&iter)
+		result := []uint64{}
+		for n := iter.Next(); !iter.EOS(); n = iter.Next() {
+			result = append(result, n)
+		}
+		if !equalUint64(result, l.Expand()) {
+			t.Errorf("%v != %v", result, l.Expand())
+		}
+	}
+
+	// Reinitialized mid-iteration
+	lists[0].IterateInto(&iter)
+	iter.Seek(15)
+	lists[1].IterateInto(&iter)
+	expectUint64(t, iter.Next(), 5)
+
+	var sum uint64
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, l := range lists {
+			l.IterateInto(&iter)
+			for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+				sum += last - first + 1
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("IterateInto() made %v allocations", allocs)
+	}
+
+	var d Decoder
+	lists[1].DecodeInto(&d)
+	skip, take := d.Next()
+	expectUint64(t, skip, 5)
+	expectUint64(t, take, 1)
+}
+
+func Test_SkipTake_IntervalRemaining(t *testing.T) {
+	list := makeRange(intrv{0, 4}, intrv{10, 14})
+	iter := list.Iterate()
+
+	expectRemaining := func(first, last uint64) {
+		t.Helper()
+		f, l := iter.IntervalRemaining()
+		if f != first || l != last {
+			t.Errorf("IntervalRemaining() = (%d, %d), expected (%d, %d)", f, l, first, last)
+		}
+	}
+
+	// Not started, and not advanced by asking.
+	expectRemaining(math.MaxUint64, 0)
+	expectUint64(t, iter.Next(), 0)
+	expectRemaining(1, 4)
+
+	expectUint64(t, iter.Next(), 1)
+	expectUint64(t, iter.Next(), 2)
+	expectUint64(t, iter.Next(), 3)
+	expectRemaining(4, 4)
+	expectUint64(t, iter.Next(), 4)
+	expectRemaining(math.MaxUint64, 0)
+
+	iter.NextSkipTake()
+	expectRemaining(10, 14)
+
+	iter.Seek(7)
+	expectRemaining(12, 14)
+	expectUint64(t, iter.Next(), 12)
+	expectRemaining(13, 14)
+
+	iter.Seek(10)
+	expectRemaining(math.MaxUint64, 0)
+}
+
+func Test_SkipTake_NextOK(t *testing.T) {
+	list := makeRange(intrv{3, 5}, intrv{math.MaxUint64 - 1, math.MaxUint64})
+	var values []uint64
+	iter := list.Iterate()
+	for v, ok := iter.NextOK(); ok; v, ok = iter.NextOK() {
+		values = append(values, v)
+	}
+	if !equalUint64(values, list.Expand()) {
+		t.Errorf("NextOK() returned %v, expected %v", values, list.Expand())
+	}
+	if _, ok := iter.NextOK(); ok {
+		t.Errorf("NextOK() continued after end-of-sequence")
+	}
+
+	var intervals []intrv
+	iter.Reset()
+	for first, last, ok := iter.NextIntervalOK(); ok; first, last, ok = iter.NextIntervalOK() {
+		intervals = append(intervals, intrv{uint(first), uint(last)})
+	}
+	if expected := []intrv{{3, 5}, {math.MaxUint64 - 1, math.MaxUint64}}; !equalIntrv(intervals, expected) {
+		t.Errorf("NextIntervalOK() returned %v, expected %v", intervals, expected)
+	}
+
+	empty := List{}.Iterate()
+	if _, ok := empty.NextOK(); ok {
+		t.Errorf("NextOK() of empty list returned a value")
+	}
+}
+
+func Test_SkipTake_SeekInterval(t *testing.T) {
+	var list List
+	b := Build(&list)
+	b.progression(3, 7, 1000)
+	b.interval(8000, 8010)
+	b.progression(9000, 3, 50)
+	list = b.Finish()
+
+	var intervals [][2]uint64
+	iter := list.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		intervals = append(intervals, [2]uint64{first, last})
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		iter := list.Iterate()
+		k := 0 // Index of the next interval expected
+		for v := uint64(r.Intn(20)); ; v += uint64(r.Intn(1 << uint(r.Intn(12)))) {
+			for k < len(intervals) && intervals[k][1] < v {
+				k++
+			}
+			first, last := iter.SeekInterval(v)
+			if k == len(intervals) {
+				if first <= last {
+					t.Fatalf("SeekInterval(%d) = [%d, %d], expected end of stream", v, first, last)
+				}
+				break
+			}
+			if first != intervals[k][0] || last != intervals[k][1] {
+				t.Fatalf("SeekInterval(%d) = [%d, %d], expected %v", v, first, last, intervals[k])
+			}
+			k++
+		}
+	}
+
+	// Runs of repeated pairs are stepped over without decoding each.
+	var long List
+	b = Build(&long)
+	b.progression(10, 3, 1<<40)
+	b.interval(1<<44, 1<<44+5)
+	long = b.Finish()
+	iter = long.Iterate()
+	if first, last := iter.SeekInterval(1 << 41); first != 1<<41+2 || last != first {
+		t.Errorf("SeekInterval() = [%d, %d]", first, last)
+	}
+	if first, last := iter.SeekInterval(1 << 44); first != 1<<44 || last != 1<<44+5 {
+		t.Errorf("SeekInterval() = [%d, %d]", first, last)
+	}
+}
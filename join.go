@@ -0,0 +1,121 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// Join yields the values present in all of a set of sources of intervals,
+// found incrementally. Unlike Intersection(), no output list is built: each
+// call advances the sources only as far as the next match, so a Join can
+// drive queries which stop early, such as those with a limit. Eg:
+//
+//		a, b := la.Iterate(), lb.Iterate()
+//		j := skiptake.NewJoin(&a, &b)
+//		for v := j.Next(); !j.EOS() && len(found) < limit; v = j.Next() {
+//			found = append(found, v)
+//		}
+//
+// The sources leapfrog one another: each source is advanced past its
+// intervals lying wholly before the latest start among the others, so
+// intervals which cannot match are never compared with every source. Sources
+// which are IntervalSeekers, such as *Iterator, are advanced with
+// SeekInterval(), which steps over runs of repeated pairs in one step. Other
+// intervals are still decoded one by one, as a List can only be decoded from
+// its start.
+type Join struct {
+	cur    []cursor
+	seek   []IntervalSeeker // The seekable source of each cursor, or nil
+	target uint64 // Lowest value not yet yielded
+	first  uint64 // Remaining values of the current match, for Next()
+	last   uint64
+	primed bool
+	done   bool // The last match reached math.MaxUint64
+	eos    bool
+}
+
+// NewJoin returns a Join over the values present in all of the sources. A Join
+// of no sources is empty.
+func NewJoin(sources ...Intervals) *Join {
+	j := &Join{cur: sourceAll(sources), seek: make([]IntervalSeeker, len(sources)), first: math.MaxUint64}
+	for i, src := range sources {
+		j.seek[i], _ = src.(IntervalSeeker)
+	}
+	return j
+}
+
+// NextInterval returns the next interval of values present in all of the
+// sources. Returns (math.MaxUint64, 0) at end of stream.
+//
+// Intervals abutting in one source but split in another are returned
+// separately, so intervals may abut one another.
+func (j *Join) NextInterval() (first, last uint64) {
+	if !j.primed {
+		j.primed = true
+		if len(j.cur) == 0 {
+			j.eos = true
+		}
+		for i := range j.cur {
+			j.cur[i].next()
+		}
+	}
+	if j.done {
+		j.eos = true
+	}
+	for !j.eos {
+		lo, hi := j.target, uint64(math.MaxUint64)
+		for i := range j.cur {
+			c := &j.cur[i]
+			if c.first <= c.last && c.last < j.target {
+				if j.seek[i] != nil {
+					c.first, c.last = j.seek[i].SeekInterval(j.target)
+				}
+				for c.first <= c.last && c.last < j.target {
+					c.next()
+				}
+			}
+			if c.first > c.last {
+				j.eos = true
+				return math.MaxUint64, 0
+			}
+			if c.first > lo {
+				lo = c.first
+			}
+			if c.last < hi {
+				hi = c.last
+			}
+		}
+		if lo > hi {
+			j.target = lo
+			continue
+		}
+		j.target = hi + 1
+		j.done = hi == math.MaxUint64
+		return lo, hi
+	}
+	return math.MaxUint64, 0
+}
+
+// Next returns the next value present in all of the sources. Returns
+// math.MaxUint64 at end of stream, which is also a legitimate value, so use
+// EOS() to differentiate.
+func (j *Join) Next() uint64 {
+	if j.first > j.last {
+		j.first, j.last = j.NextInterval()
+		if j.first > j.last {
+			return math.MaxUint64
+		}
+	}
+	v := j.first
+	if v == j.last {
+		j.first, j.last = math.MaxUint64, 0
+	} else {
+		j.first++
+	}
+	return v
+}
+
+// EOS returns true once Next() has returned all values. As for Iterator, EOS
+// is only true after a call to Next() reaches the end of stream.
+func (j *Join) EOS() bool {
+	return j.eos && j.first > j.last
+}
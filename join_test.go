@@ -0,0 +1,118 @@
+package skiptake
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// countingIntervals counts the intervals read from a source.
+type countingIntervals struct {
+	src Intervals
+	n   int
+}
+
+func (c *countingIntervals) NextInterval() (first, last uint64) {
+	c.n++
+	return c.src.NextInterval()
+}
+
+func Test_Join(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		lists := make([]List, 1+r.Intn(4))
+		sources := make([]Intervals, len(lists))
+		for k := range lists {
+			var values []uint64
+			for v := uint64(r.Intn(5)); v < 500; v += 1 + uint64(r.Intn(1<<uint(r.Intn(5)))) {
+				values = append(values, v)
+			}
+			lists[k] = Create(values...)
+			iter := lists[k].Iterate()
+			sources[k] = &iter
+		}
+		expected := Intersection(lists...).Expand()
+
+		var result []uint64
+		j := NewJoin(sources...)
+		for v := j.Next(); !j.EOS(); v = j.Next() {
+			result = append(result, v)
+		}
+		if !equalUint64(result, expected) {
+			t.Fatalf("Join() = %v, expected %v", result, expected)
+		}
+		if !j.EOS() || j.Next() != math.MaxUint64 {
+			t.Fatal("Join not at EOS after end")
+		}
+	}
+}
+
+func Test_Join_NextInterval(t *testing.T) {
+	a := makeRange(intrv{0, 10}, intrv{20, 30}, intrv{40, 50}).Iterate()
+	b := makeRange(intrv{5, 25}, intrv{28, 45}).Iterate()
+	j := NewJoin(&a, &b)
+	expected := []intrv{{5, 10}, {20, 25}, {28, 30}, {40, 45}}
+	for _, e := range expected {
+		if first, last := j.NextInterval(); first != uint64(e[0]) || last != uint64(e[1]) {
+			t.Errorf("NextInterval() = [%d, %d], expected %v", first, last, e)
+		}
+	}
+	if first, last := j.NextInterval(); first <= last {
+		t.Errorf("NextInterval() = [%d, %d], expected EOS", first, last)
+	}
+
+	if first, last := NewJoin().NextInterval(); first <= last {
+		t.Errorf("NewJoin() of no sources = [%d, %d]", first, last)
+	}
+
+	// Matches reaching math.MaxUint64
+	c := FromRaw(math.MaxUint64-2, 3).Iterate()
+	d := FromRaw(math.MaxUint64-1, 2).Iterate()
+	j = NewJoin(&c, &d)
+	var result []uint64
+	for v := j.Next(); !j.EOS(); v = j.Next() {
+		result = append(result, v)
+	}
+	if !equalUint64(result, []uint64{math.MaxUint64 - 1, math.MaxUint64}) {
+		t.Errorf("Join() = %v", result)
+	}
+}
+
+func Test_Join_Incremental(t *testing.T) {
+	// Taking the first matches reads only as far as them.
+	a := Create(1, 3, 5, 7, 9, 11, 13, 15).Iterate()
+	b := Create(2, 3, 4, 9, 10, 11, 12, 13, 14, 15).Iterate()
+	ca := &countingIntervals{src: &a}
+	cb := &countingIntervals{src: &b}
+	j := NewJoin(ca, cb)
+	if v := j.Next(); v != 3 {
+		t.Errorf("Next() = %d, expected 3", v)
+	}
+	if ca.n != 2 || cb.n != 1 {
+		t.Errorf("read %d and %d intervals, expected 2 and 1", ca.n, cb.n)
+	}
+	if v := j.Next(); v != 9 {
+		t.Errorf("Next() = %d, expected 9", v)
+	}
+	if ca.n != 5 || cb.n != 2 {
+		t.Errorf("read %d and %d intervals, expected 5 and 2", ca.n, cb.n)
+	}
+}
+
+func Test_Join_Seek(t *testing.T) {
+	// A join of a short list with one of 2^40 repeated pairs seeks over the
+	// runs, rather than stepping through each interval.
+	var long List
+	b := Build(&long)
+	b.progression(10, 3, 1<<40)
+	long = b.Finish()
+	a, c := long.Iterate(), Create(1, 13, 1<<41, 1<<41+2, 1<<42).Iterate()
+	j := NewJoin(&a, &c)
+	var result []uint64
+	for v := j.Next(); !j.EOS(); v = j.Next() {
+		result = append(result, v)
+	}
+	if expected := []uint64{13, 1<<41 + 2}; !equalUint64(result, expected) {
+		t.Errorf("Join() = %v, expected %v", result, expected)
+	}
+}
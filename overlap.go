@@ -0,0 +1,168 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// OverlapIterator yields the runs of consecutive members of a list which
+// intersect a range of values, as returned by List.OverlappingRuns(). It is
+// returned by value and holds its own Decoder, so iterating allocates nothing.
+// Eg, to draw the selection within a viewport:
+//
+//		runs := list.OverlappingRuns(top, bottom, true)
+//		for first, last := runs.NextInterval(); first <= last; first, last = runs.NextInterval() {
+//			...
+//		}
+//
+type OverlapIterator struct {
+	d       Decoder
+	n       uint64 // Value following the decoded pairs
+	skip    uint64 // Pairs remaining of the current run
+	take    uint64
+	count   uint64
+	first   uint64 // Next interval, if pending
+	last    uint64
+	lo, hi  uint64
+	clip    bool
+	done    bool
+	pending bool
+}
+
+// OverlappingRuns returns an OverlapIterator over the runs of the list which
+// intersect [lo, hi], inclusive. If clip is true, the runs are clipped to
+// [lo, hi]. Otherwise, they are returned whole.
+//
+// The runs before lo are stepped over without iterating them: runs of repeated
+// skip-take pairs are skipped arithmetically, as by Bounds().
+func (l List) OverlappingRuns(lo, hi uint64, clip bool) OverlapIterator {
+	o := OverlapIterator{d: l.Decode(), clip: clip}
+	o.seek(lo, hi)
+	return o
+}
+
+// Reset restarts the iterator over the runs intersecting a new range [lo, hi]
+// of the same list.
+func (o *OverlapIterator) Reset(lo, hi uint64) {
+	o.d.Reset()
+	o.n, o.count, o.pending, o.done = 0, 0, false, false
+	o.seek(lo, hi)
+}
+
+// seek steps over the pairs of the list which lie wholly before lo. A run
+// which ends just before lo, and so may continue past it, is left pending.
+func (o *OverlapIterator) seek(lo, hi uint64) {
+	o.lo, o.hi = lo, hi
+	if lo > hi {
+		o.done = true
+		return
+	}
+	var contFirst, contLast uint64 // The interval ending at the last skipped pair
+	var cont bool
+	for {
+		if o.count == 0 {
+			if o.d.EOS() {
+				break
+			}
+			o.skip, o.take, o.count = o.d.NextRun()
+			continue
+		}
+		period := o.skip + o.take
+		span := o.count * period
+		if lo-o.n < span {
+			// The run reaches lo. Step over its pairs before lo, unless it
+			// is one interval.
+			if j := (lo - o.n) / period; o.skip > 0 && o.take > 0 && j > 0 {
+				o.n += j * period
+				o.count -= j
+				cont = false
+			}
+			break
+		}
+		switch {
+		case o.take == 0:
+			cont = cont && o.skip == 0
+		case o.skip == 0 && cont && contLast+1 == o.n:
+			contLast = o.n + span - 1
+		case o.skip == 0:
+			contFirst, contLast, cont = o.n, o.n+span-1, true
+		default:
+			contFirst, contLast, cont = o.n+span-o.take, o.n+span-1, true
+		}
+		o.n += span
+		o.count = 0
+	}
+	if cont && contLast+1 == o.n {
+		o.first, o.last, o.pending = contFirst, contLast, true
+	}
+}
+
+// nextPair returns the interval of the next pair with a non-zero take. A run
+// of pairs with zero skips is returned as one interval.
+func (o *OverlapIterator) nextPair() (first, last uint64, ok bool) {
+	for o.count == 0 || o.take == 0 {
+		o.n += o.count * o.skip
+		o.count = 0
+		if o.d.EOS() {
+			return 0, 0, false
+		}
+		o.skip, o.take, o.count = o.d.NextRun()
+	}
+	if o.skip == 0 {
+		first = o.n
+		o.n += o.count * o.take
+		o.count = 0
+		return first, o.n - 1, true
+	}
+	first = o.n + o.skip
+	o.n = first + o.take
+	o.count--
+	return first, o.n - 1, true
+}
+
+// nextRun returns the next run of consecutive members, coalescing pairs which
+// abut.
+func (o *OverlapIterator) nextRun() (first, last uint64, ok bool) {
+	if !o.pending {
+		if o.first, o.last, o.pending = o.nextPair(); !o.pending {
+			return 0, 0, false
+		}
+	}
+	first, last = o.first, o.last
+	for {
+		f, l, ok := o.nextPair()
+		if !ok {
+			o.pending = false
+			return first, last, true
+		}
+		if f != last+1 {
+			o.first, o.last = f, l
+			return first, last, true
+		}
+		last = l
+	}
+}
+
+// NextInterval returns the next run intersecting the range, inclusive, clipped
+// to it if requested. Returns (math.MaxUint64, 0) at end of stream.
+func (o *OverlapIterator) NextInterval() (first, last uint64) {
+	for !o.done {
+		f, l, ok := o.nextRun()
+		if !ok || f > o.hi {
+			o.done = true
+			break
+		}
+		if l < o.lo {
+			continue
+		}
+		if o.clip {
+			if f < o.lo {
+				f = o.lo
+			}
+			if l > o.hi {
+				l = o.hi
+			}
+		}
+		return f, l
+	}
+	return math.MaxUint64, 0
+}
@@ -0,0 +1,133 @@
+package skiptake
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// overlapping returns the intervals of the list intersecting [lo, hi], found
+// by iterating them all.
+func overlapping(l List, lo, hi uint64, clip bool) []intrv {
+	result := []intrv{}
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if last < lo || first > hi {
+			continue
+		}
+		if clip {
+			first, last = max64(first, lo), min64(last, hi)
+		}
+		result = append(result, intrv{uint(first), uint(last)})
+	}
+	return result
+}
+
+func max64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func collectOverlap(o *OverlapIterator) []intrv {
+	result := []intrv{}
+	for first, last := o.NextInterval(); first <= last; first, last = o.NextInterval() {
+		result = append(result, intrv{uint(first), uint(last)})
+	}
+	return result
+}
+
+func equalIntrv(a, b []intrv) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_OverlappingRuns(t *testing.T) {
+	list := makeRange(intrv{0, 3}, intrv{10, 20}, intrv{30, 30}, intrv{40, 50})
+	cases := []struct {
+		lo, hi   uint64
+		clip     bool
+		expected []intrv
+	}{
+		{12, 35, false, []intrv{{10, 20}, {30, 30}}},
+		{12, 35, true, []intrv{{12, 20}, {30, 30}}},
+		{21, 29, false, []intrv{}},
+		{3, 10, false, []intrv{{0, 3}, {10, 20}}},
+		{3, 10, true, []intrv{{3, 3}, {10, 10}}},
+		{45, math.MaxUint64, true, []intrv{{45, 50}}},
+		{0, math.MaxUint64, false, []intrv{{0, 3}, {10, 20}, {30, 30}, {40, 50}}},
+		{51, 100, false, []intrv{}},
+		{20, 10, false, []intrv{}},
+	}
+	for _, c := range cases {
+		o := list.OverlappingRuns(c.lo, c.hi, c.clip)
+		if result := collectOverlap(&o); !equalIntrv(result, c.expected) {
+			t.Errorf("OverlappingRuns(%d, %d, %t) = %v, expected %v", c.lo, c.hi, c.clip, result, c.expected)
+		}
+	}
+}
+
+func Test_OverlappingRuns_Raw(t *testing.T) {
+	// Runs of repeated pairs, and pairs with zero skips and takes, which
+	// must be coalesced into whole runs.
+	lists := []List{
+		FromRaw(2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3),
+		FromRaw(0, 5, 0, 3, 2, 1, 0, 4, 5, 0, 0, 2, 1, 1),
+		FromRaw(5, 2, 0, 2, 0, 2, 0, 2, 0, 2, 3, 1),
+		FromRaw(1, 1, 1, 1, 1, 1, 1, 1, 0, 4, 1, 1),
+	}
+	for _, l := range lists {
+		for lo := uint64(0); lo < 40; lo++ {
+			for hi := lo; hi < 42; hi++ {
+				for _, clip := range []bool{false, true} {
+					o := l.OverlappingRuns(lo, hi, clip)
+					if result, expected := collectOverlap(&o), overlapping(l, lo, hi, clip); !equalIntrv(result, expected) {
+						t.Fatalf("%x OverlappingRuns(%d, %d, %t) = %v, expected %v", []byte(l), lo, hi, clip, result, expected)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_OverlappingRuns_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var values []uint64
+		for v := uint64(r.Intn(5)); v < 2000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(6)))) {
+			values = append(values, v)
+		}
+		l := Create(values...)
+		o := l.OverlappingRuns(0, 0, false)
+		for k := 0; k < 20; k++ {
+			lo := uint64(r.Intn(2100))
+			hi := lo + uint64(r.Intn(300))
+			clip := r.Intn(2) == 0
+			o.clip = clip
+			o.Reset(lo, hi)
+			if result, expected := collectOverlap(&o), overlapping(l, lo, hi, clip); !equalIntrv(result, expected) {
+				t.Fatalf("OverlappingRuns(%d, %d, %t) = %v, expected %v", lo, hi, clip, result, expected)
+			}
+		}
+	}
+}
+
+func Test_OverlappingRuns_Allocs(t *testing.T) {
+	list := makeRange(intrv{0, 3}, intrv{10, 20}, intrv{30, 30}, intrv{40, 50})
+	allocs := testing.AllocsPerRun(100, func() {
+		o := list.OverlappingRuns(12, 45, true)
+		for first, last := o.NextInterval(); first <= last; first, last = o.NextInterval() {
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("OverlappingRuns() allocated %v times", allocs)
+	}
+}
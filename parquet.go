@@ -0,0 +1,172 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Export and import of selections in the RLE/bit-packing hybrid encoding of
+// Apache Parquet, at a bit width of 1. This is the encoding of boolean
+// definition levels and of boolean data pages, so a selection over the rows of
+// a column chunk can be handed to a Parquet writer as is. The encoded data is
+// a sequence of runs, each one of:
+//
+//		RLE:        | uvarint(count << 1)     | value (1 byte) |
+//		bit-packed: | uvarint(groups << 1 | 1) | groups bytes  |
+//
+// Bit-packed runs hold groups of 8 rows, one bit per row, least significant
+// bit first. The optional 4-byte length prefix used by data page v1 is not
+// written or expected.
+
+var (
+	// ErrShortRLE is returned when RLE/bit-packed data ends before the
+	// requested number of rows.
+	ErrShortRLE = errors.New("skiptake: short RLE data")
+
+	// ErrRLEValue is returned when an RLE run repeats a value other than 0 or
+	// 1.
+	ErrRLEValue = errors.New("skiptake: RLE value not 0 or 1")
+)
+
+const (
+	// maxRLERun is the longest RLE run written, so that its header fits
+	// readers which decode it as a 32-bit integer.
+	maxRLERun = 1<<30 - 1
+
+	// maxPackedGroups is the most groups of 8 rows written in one bit-packed
+	// run, so that its header fits in one byte.
+	maxPackedGroups = 63
+)
+
+// rleWriter writes runs of rows, choosing between RLE and bit-packed runs.
+type rleWriter struct {
+	dst    []byte
+	packed [maxPackedGroups]byte
+	nbits  int // Rows pending in packed
+}
+
+// run writes n rows of value v.
+func (w *rleWriter) run(v byte, n uint64) {
+	// Complete any partial group, so that the pending rows can be flushed.
+	if k := uint64(-w.nbits & 7); n >= k+8 {
+		w.bits(v, k)
+		w.flush()
+		w.rle(v, n-k)
+		return
+	}
+	w.bits(v, n)
+}
+
+// bits adds n rows of value v to the pending bit-packed run.
+func (w *rleWriter) bits(v byte, n uint64) {
+	for n > 0 {
+		k := uint64(len(w.packed)*8 - w.nbits)
+		if k > n {
+			k = n
+		}
+		if v != 0 {
+			setBits(w.packed[:], uint64(w.nbits), uint64(w.nbits)+k-1)
+		}
+		w.nbits += int(k)
+		n -= k
+		if w.nbits == len(w.packed)*8 {
+			w.flush()
+		}
+	}
+}
+
+// rle writes n rows of value v as RLE runs.
+func (w *rleWriter) rle(v byte, n uint64) {
+	for n > 0 {
+		k := n
+		if k > maxRLERun {
+			k = maxRLERun
+		}
+		w.dst = appendUvarint(w.dst, k<<1)
+		w.dst = append(w.dst, v)
+		n -= k
+	}
+}
+
+// flush writes the pending rows as a bit-packed run, padding the last group
+// with zeros.
+func (w *rleWriter) flush() {
+	if w.nbits == 0 {
+		return
+	}
+	groups := (w.nbits + 7) / 8
+	w.dst = appendUvarint(w.dst, uint64(groups)<<1|1)
+	w.dst = append(w.dst, w.packed[:groups]...)
+	w.packed = [maxPackedGroups]byte{}
+	w.nbits = 0
+}
+
+// AppendParquet appends the list as a selection of length rows to dst, in the
+// Parquet RLE/bit-packing hybrid encoding with a bit width of 1. Row i is 1 if
+// i is a member. Members of length or more are ignored. Behaves like append(),
+// and returns the extended slice.
+//
+// Runs of 8 or more rows are written as RLE runs, straight from the skip-take
+// pairs, and shorter ones are bit-packed, so the list is never expanded.
+func (l List) AppendParquet(dst []byte, length uint64) []byte {
+	w := rleWriter{dst: dst}
+	var row uint64
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last && first < length; first, last = iter.NextInterval() {
+		if last >= length {
+			last = length - 1
+		}
+		w.run(0, first-row)
+		w.run(1, last-first+1)
+		row = last + 1
+	}
+	w.run(0, length-row)
+	w.flush()
+	return w.dst
+}
+
+// ReadParquet returns the list of rows which are 1 in the first length rows of
+// b, in the Parquet RLE/bit-packing hybrid encoding with a bit width of 1.
+// Returns the number of bytes read, which may be fewer than len(b) as the
+// encoding does not record its length.
+func ReadParquet(b []byte, length uint64) (List, int, error) {
+	l := Build(&List{})
+	var i int
+	for row := uint64(0); row < length; {
+		header, k := binary.Uvarint(b[i:])
+		if k <= 0 {
+			return nil, 0, ErrShortRLE
+		}
+		i += k
+		count := header >> 1
+		if header&1 == 0 {
+			if i >= len(b) {
+				return nil, 0, ErrShortRLE
+			}
+			v := b[i]
+			i++
+			if v > 1 {
+				return nil, 0, ErrRLEValue
+			}
+			if count > length-row {
+				count = length - row
+			}
+			if v == 1 && count > 0 {
+				l.interval(row, row+count-1)
+			}
+			row += count
+			continue
+		}
+		if count > uint64(len(b)-i) {
+			return nil, 0, ErrShortRLE
+		}
+		n := count * 8
+		if n > length-row {
+			n = length - row
+		}
+		appendBitmap(&l, b[i:i+int(count)], 0, int(n), row)
+		i += int(count)
+		row += n
+	}
+	return l.Finish(), i, nil
+}
@@ -0,0 +1,103 @@
+package skiptake
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func Test_AppendParquet(t *testing.T) {
+	cases := []struct {
+		list     List
+		length   uint64
+		expected string
+	}{
+		// All rows unselected, as one RLE run
+		{List{}, 100, "\xc8\x01\x00"},
+		// RLE runs either side of a long interval
+		{makeRange(intrv{10, 29}), 40, "\x14\x00\x28\x01\x14\x00"},
+		// Short runs bit-packed, padded to a whole group
+		{makeRange(intrv{0, 0}, intrv{3, 5}), 10, "\x05\x39\x00"},
+		// A partial group completed before an RLE run
+		{makeRange(intrv{1, 1}, intrv{4, 19}), 20, "\x03\xf2\x18\x01"},
+		// Members past the length are ignored
+		{makeRange(intrv{2, 100}), 8, "\x03\xfc"},
+		{List{}, 0, ""},
+	}
+	for _, c := range cases {
+		if b := c.list.AppendParquet(nil, c.length); string(b) != c.expected {
+			t.Errorf("AppendParquet(%v, %d) = %x, expected %x", c.list.Expand(), c.length, b, c.expected)
+		}
+	}
+
+	// Long runs are split
+	list := makeRange(intrv{5, 1 << 31})
+	b := list.AppendParquet(nil, 1<<32)
+	if len(b) != 28 {
+		t.Errorf("AppendParquet() = %x, expected one bit-packed and 5 RLE runs", b)
+	}
+	l, _, err := ReadParquet(b, 1<<32)
+	if first, last, _ := l.Bounds(); err != nil || first != 5 || last != 1<<31 || l.NumIntervals() != 1 {
+		t.Errorf("ReadParquet() = %v, %v", l, err)
+	}
+}
+
+func Test_ParquetRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		var values []uint64
+		length := uint64(r.Intn(3000))
+		for v := uint64(r.Intn(20)); v < length+10; v++ {
+			values = append(values, v)
+			// Mix short runs and gaps with long ones
+			if r.Intn(4) == 0 {
+				v += uint64(r.Intn(1 << uint(r.Intn(10))))
+			}
+		}
+		list := Create(values...)
+		b := list.AppendParquet([]byte{0xee}, length)
+		if b[0] != 0xee {
+			t.Fatal("AppendParquet() overwrote dst")
+		}
+		l, n, err := ReadParquet(b[1:], length)
+		if err != nil || n != len(b)-1 {
+			t.Fatalf("ReadParquet() = %d, %v, expected %d bytes", n, err, len(b)-1)
+		}
+		expectValues(t, l.Expand(), list.Slice(0, list.Rank(length)).Expand())
+	}
+}
+
+func Test_ReadParquet(t *testing.T) {
+	// Runs spanning more rows than requested, followed by other data
+	l, n, err := ReadParquet([]byte("\x05\x39\x00\x28\x01\xff"), 20)
+	if err != nil || n != 5 {
+		t.Fatalf("ReadParquet() = %d, %v", n, err)
+	}
+	expectValues(t, l.Expand(), []uint64{0, 3, 4, 5, 16, 17, 18, 19})
+
+	// A bit-packed run longer than one group, ending part way through
+	l, n, err = ReadParquet([]byte("\x05\xff\x81"), 9)
+	if err != nil || n != 3 {
+		t.Fatalf("ReadParquet() = %d, %v", n, err)
+	}
+	expectValues(t, l.Expand(), []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8})
+
+	errs := []struct {
+		b   string
+		err error
+	}{
+		{"", ErrShortRLE},
+		{"\x28", ErrShortRLE},
+		{"\x05\x39", ErrShortRLE},
+		{"\x08\x01", ErrShortRLE},
+		{"\x80", ErrShortRLE},
+		{"\x28\x02", ErrRLEValue},
+	}
+	for _, c := range errs {
+		if _, _, err := ReadParquet([]byte(c.b), 10); err != c.err {
+			t.Errorf("ReadParquet(%x) error = %v, expected %v", c.b, err, c.err)
+		}
+	}
+	if l, n, err := ReadParquet(nil, 0); err != nil || n != 0 || !l.IsEmpty() {
+		t.Errorf("ReadParquet(nil, 0) = %v, %d, %v", l, n, err)
+	}
+}
@@ -0,0 +1,237 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	// ErrBadPatch is returned when unmarshalling a malformed patch.
+	ErrBadPatch = errors.New("skiptake: malformed patch")
+
+	// ErrPatchMismatch is returned when applying a patch to a list other than
+	// the one it was created from.
+	ErrPatchMismatch = errors.New("skiptake: patch does not match list")
+)
+
+// Patch describes the changes between two versions of a list, as the values
+// added to and removed from the old version. Base is the Fingerprint() of the
+// old version, which the patch can only be applied to.
+type Patch struct {
+	Base    uint64
+	Added   List
+	Removed List
+}
+
+// Diff returns the patch which changes the list old into the list new. Both
+// lists are walked once, computing the added and removed values and the
+// fingerprint of old together.
+func Diff(old, new List) Patch {
+	oi, ni := old.Iterate(), new.Iterate()
+	f := &fingerprinter{src: &oi, h: newFingerprintHash()}
+	ab, rb := Build(&List{}), Build(&List{})
+	delta(&ab, &rb, &cursor{src: f}, &cursor{src: &ni})
+	return Patch{
+		Base:    f.h.Sum64(),
+		Added:   ab.Finish(),
+		Removed: rb.Finish(),
+	}
+}
+
+// Apply returns the new version of a list, given the old version and the
+// patch created from them by Diff. The result is built in a single pass over
+// the old list and the patch.
+//
+// Returns ErrPatchMismatch if the patch was not created from old, that is old
+// does not match the patch fingerprint, removed values are not all members of
+// old, or added values are already members.
+func Apply(old List, p Patch) (List, error) {
+	oi, ri, ai := old.Iterate(), p.Removed.Iterate(), p.Added.Iterate()
+	f := &fingerprinter{src: &oi, h: newFingerprintHash()}
+	pieces := newRemoveIntervals(f, &ri)
+	added := cursor{src: &ai}
+	added.next()
+
+	b := Build(&List{})
+	pf, pl := pieces.NextInterval()
+	for pf <= pl || added.first <= added.last {
+		if added.first <= added.last && (pf > pl || added.first < pf) {
+			if pf <= pl && added.last >= pf {
+				return nil, ErrPatchMismatch
+			}
+			b.interval(added.first, added.last)
+			added.next()
+		} else {
+			if added.first <= added.last && added.first <= pl {
+				return nil, ErrPatchMismatch
+			}
+			b.interval(pf, pl)
+			pf, pl = pieces.NextInterval()
+		}
+	}
+	if pieces.err != nil || f.h.Sum64() != p.Base {
+		return nil, ErrPatchMismatch
+	}
+	return b.Finish(), nil
+}
+
+// removeIntervals yields the intervals of a with the values of b removed,
+// where all values of b must be members of a.
+type removeIntervals struct {
+	a   cursor
+	b   cursor
+	err error
+}
+
+func newRemoveIntervals(a, b Intervals) *removeIntervals {
+	r := &removeIntervals{a: cursor{src: a}, b: cursor{src: b}}
+	r.a.next()
+	r.b.next()
+	return r
+}
+
+func (r *removeIntervals) NextInterval() (first, last uint64) {
+	a, b := &r.a, &r.b
+	for a.first <= a.last {
+		if b.first > b.last || b.first > a.last {
+			// No removal within this interval.
+			first, last = a.first, a.last
+			a.next()
+			return
+		}
+		if b.first < a.first || b.last > a.last {
+			// Removal of a non-member.
+			break
+		}
+		first = a.first
+		if b.last == a.last {
+			a.next()
+		} else {
+			a.first = b.last + 1
+		}
+		bf := b.first
+		b.next()
+		if bf > first {
+			return first, bf - 1
+		}
+	}
+	if b.first <= b.last {
+		r.err = ErrPatchMismatch
+	}
+	return math.MaxUint64, 0
+}
+
+// Marshal returns the patch serialized as:
+//
+//		| base (8 bytes, little-endian) | removed size (uvarint) |
+//		| removed list | added list |
+//
+func (p Patch) Marshal() []byte {
+	b := make([]byte, 8, 8+binary.MaxVarintLen64+len(p.Removed)+len(p.Added))
+	binary.LittleEndian.PutUint64(b, p.Base)
+	b = appendUvarint(b, uint64(len(p.Removed)))
+	b = append(b, p.Removed...)
+	return append(b, p.Added...)
+}
+
+// UnmarshalPatch returns the patch serialized in b by Patch.Marshal(). The lists
+// of the returned patch share memory with b.
+func UnmarshalPatch(b []byte) (Patch, error) {
+	if len(b) < 8 {
+		return Patch{}, ErrBadPatch
+	}
+	base := binary.LittleEndian.Uint64(b)
+	b = b[8:]
+	size, k := binary.Uvarint(b)
+	if k <= 0 || uint64(len(b)-k) < size {
+		return Patch{}, ErrBadPatch
+	}
+	p := Patch{
+		Base:    base,
+		Removed: List(b[k : k+int(size)]),
+		Added:   List(b[k+int(size):]),
+	}
+	if p.Removed.Validate() != nil || p.Added.Validate() != nil {
+		return Patch{}, ErrBadPatch
+	}
+	return p, nil
+}
+
+// Conflicts describes edits made by both sides of a three-way merge which
+// touch each other, as returned by Merge3.
+type Conflicts struct {
+	// Values is every value spanned by conflicting edits. Each conflict is an
+	// interval added by one side together with an interval removed by the
+	// other side which it overlaps or abuts.
+	Values List
+}
+
+// Empty returns true if there were no conflicts.
+func (c Conflicts) Empty() bool {
+	return len(c.Values) == 0
+}
+
+// Merge3 merges two lists, ours and theirs, edited independently from a
+// common base list. The result holds the values of base, with the values added
+// by either side added, and the values removed by either side removed.
+//
+// As a value can only be added by a side if it is not in base, and only
+// removed if it is, the two sides never disagree on a single value. However,
+// an interval added by one side that abuts an interval removed by the other,
+// such as one side extending a range while the other shrinks it from the same
+// end, usually means the edits were made with conflicting intent. These are
+// reported as conflicts, for the caller to review. They are merged like any
+// other edit.
+func Merge3(base, ours, theirs List) (List, Conflicts) {
+	oa, or := Delta(base, ours)
+	ta, tr := Delta(base, theirs)
+	merged := Union(Difference(base, Union(or, tr)), oa, ta)
+	return merged, Conflicts{Values: Union(touching(oa, tr), touching(ta, or))}
+}
+
+// touching returns the union of each interval of a with each interval of b
+// that it overlaps or abuts.
+func touching(a, b List) List {
+	ai, bi := a.Iterate(), b.Iterate()
+	ac, bc := cursor{src: &ai}, cursor{src: &bi}
+	ac.next()
+	bc.next()
+
+	result := Build(&List{})
+	var pf, pl uint64
+	pending := false
+	for ac.first <= ac.last && bc.first <= bc.last {
+		if (ac.last == math.MaxUint64 || bc.first <= ac.last+1) && (bc.last == math.MaxUint64 || ac.first <= bc.last+1) {
+			first, last := ac.first, ac.last
+			if bc.first < first {
+				first = bc.first
+			}
+			if bc.last > last {
+				last = bc.last
+			}
+			// Successive conflicts may share an interval, so are merged
+			// before being added.
+			switch {
+			case !pending:
+				pf, pl, pending = first, last, true
+			case pl == math.MaxUint64 || first <= pl+1:
+				if last > pl {
+					pl = last
+				}
+			default:
+				result.interval(pf, pl)
+				pf, pl = first, last
+			}
+		}
+		if ac.last <= bc.last {
+			ac.next()
+		} else {
+			bc.next()
+		}
+	}
+	if pending {
+		result.interval(pf, pl)
+	}
+	return result.Finish()
+}
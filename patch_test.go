@@ -0,0 +1,155 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Patch(t *testing.T) {
+	old := makeRange(intrv{0, 99}, intrv{200, 299}, intrv{1000, 1000})
+	new := makeRange(intrv{0, 49}, intrv{60, 99}, intrv{200, 349}, intrv{2000, 2001})
+
+	p := Diff(old, new)
+	t.Logf("Added: %v", p.Added)
+	t.Logf("Removed: %v", p.Removed)
+
+	if expected := makeRange(intrv{300, 349}, intrv{2000, 2001}); !Equal(p.Added, expected) {
+		t.Errorf("Added %v != %v", p.Added, expected)
+	}
+	if expected := makeRange(intrv{50, 59}, intrv{1000, 1000}); !Equal(p.Removed, expected) {
+		t.Errorf("Removed %v != %v", p.Removed, expected)
+	}
+
+	b := p.Marshal()
+	t.Logf("Marshalled as %d bytes", len(b))
+	result, err := UnmarshalPatch(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(result.Added, p.Added) || !Equal(result.Removed, p.Removed) {
+		t.Errorf("%v != %v", result, p)
+	}
+
+	if _, err := UnmarshalPatch([]byte{5, 1}); err != ErrBadPatch {
+		t.Errorf("UnmarshalPatch() of truncated patch: %v", err)
+	}
+}
+
+func Test_PatchApply(t *testing.T) {
+	versions := []List{
+		List{},
+		makeRange(intrv{0, 99}, intrv{200, 299}, intrv{1000, 1000}),
+		makeRange(intrv{0, 49}, intrv{60, 99}, intrv{200, 349}, intrv{2000, 2001}),
+		makeRange(intrv{0, 59}, intrv{100, 199}, intrv{2001, 2001}),
+		makeRange(intrv{0, 0xffffffffffffffff}),
+		Create(0xffffffffffffffff),
+		List{},
+	}
+	for i := 1; i < len(versions); i++ {
+		old, new := versions[i-1], versions[i]
+		p := Diff(old, new)
+		result, err := Apply(old, p)
+		if err != nil {
+			t.Errorf("Apply(%v, %v): %v", old, p, err)
+			continue
+		}
+		if !Equal(result, new) {
+			t.Errorf("Apply(%v, %v) = %v, expected %v", old, p, result, new)
+		}
+	}
+}
+
+func Test_PatchApplyMismatch(t *testing.T) {
+	old := makeRange(intrv{0, 99}, intrv{200, 299})
+	new := makeRange(intrv{0, 49}, intrv{200, 349})
+	p := Diff(old, new)
+
+	// Different base
+	if _, err := Apply(Create(1, 2, 3), p); err != ErrPatchMismatch {
+		t.Errorf("Apply() to different list: %v", err)
+	}
+
+	// Removal of a non-member
+	bad := p
+	bad.Removed = makeRange(intrv{50, 120})
+	if _, err := Apply(old, bad); err != ErrPatchMismatch {
+		t.Errorf("Apply() removing non-members: %v", err)
+	}
+	bad.Removed = Union(p.Removed, Create(5000))
+	if _, err := Apply(old, bad); err != ErrPatchMismatch {
+		t.Errorf("Apply() removing trailing non-members: %v", err)
+	}
+
+	// Addition of a member
+	bad = p
+	bad.Added = Union(p.Added, Create(10))
+	if _, err := Apply(old, bad); err != ErrPatchMismatch {
+		t.Errorf("Apply() adding members: %v", err)
+	}
+}
+
+func Test_Fingerprint(t *testing.T) {
+	a := FromRaw(1, 2, 0, 3, 5, 1)
+	b := Create(1, 2, 3, 4, 5, 11)
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Errorf("Fingerprints differ for lists with the same values: %v, %v", a, b)
+	}
+	if Fingerprint(a) == Fingerprint(Create(1, 2, 3, 4, 5, 12)) {
+		t.Errorf("Fingerprints match for lists with different values")
+	}
+}
+
+func Test_Merge3(t *testing.T) {
+	base := makeRange(intrv{0, 99}, intrv{200, 299}, intrv{500, 599})
+
+	t.Run("Independent", func(t *testing.T) {
+		ours := makeRange(intrv{0, 49}, intrv{200, 299}, intrv{500, 599}, intrv{1000, 1009})
+		theirs := makeRange(intrv{0, 99}, intrv{200, 249}, intrv{260, 299}, intrv{500, 599}, intrv{2000, 2000})
+		merged, conflicts := Merge3(base, ours, theirs)
+		expected := makeRange(intrv{0, 49}, intrv{200, 249}, intrv{260, 299}, intrv{500, 599}, intrv{1000, 1009}, intrv{2000, 2000})
+		if !Equal(merged, expected) {
+			t.Errorf("Merge3() = %v, expected %v", merged, expected)
+		}
+		if !conflicts.Empty() {
+			t.Errorf("Unexpected conflicts %v", conflicts.Values)
+		}
+	})
+
+	t.Run("SameEdit", func(t *testing.T) {
+		edited := makeRange(intrv{0, 99}, intrv{500, 649})
+		merged, conflicts := Merge3(base, edited, edited)
+		if !Equal(merged, edited) {
+			t.Errorf("Merge3() = %v, expected %v", merged, edited)
+		}
+		if !conflicts.Empty() {
+			t.Errorf("Unexpected conflicts %v", conflicts.Values)
+		}
+	})
+
+	t.Run("Conflicting", func(t *testing.T) {
+		// Ours extends [500, 599] upwards, while theirs shrinks it from the top.
+		// Ours shrinks [0, 99] from the top, while theirs extends it upwards.
+		ours := makeRange(intrv{0, 89}, intrv{200, 299}, intrv{500, 619})
+		theirs := makeRange(intrv{0, 109}, intrv{200, 299}, intrv{500, 579})
+		merged, conflicts := Merge3(base, ours, theirs)
+		t.Logf("Conflicts: %v", conflicts.Values)
+
+		expected := makeRange(intrv{0, 89}, intrv{100, 109}, intrv{200, 299}, intrv{500, 579}, intrv{600, 619})
+		if !Equal(merged, expected) {
+			t.Errorf("Merge3() = %v, expected %v", merged, expected)
+		}
+		if expected := makeRange(intrv{90, 109}, intrv{580, 619}); !Equal(conflicts.Values, expected) {
+			t.Errorf("Conflicts %v, expected %v", conflicts.Values, expected)
+		}
+	})
+
+	t.Run("SharedInterval", func(t *testing.T) {
+		// A single addition by ours abuts two removals by theirs.
+		base := makeRange(intrv{0, 9}, intrv{20, 29})
+		ours := makeRange(intrv{0, 29})
+		theirs := makeRange(intrv{0, 8}, intrv{21, 29})
+		_, conflicts := Merge3(base, ours, theirs)
+		if expected := makeRange(intrv{9, 20}); !Equal(conflicts.Values, expected) {
+			t.Errorf("Conflicts %v, expected %v", conflicts.Values, expected)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package skiptake
+
+import (
+	"sync"
+)
+
+// Pooling of scratch state, so that servers performing constant set algebra do
+// not generate garbage on every request.
+
+// maxPooledList is the largest capacity of list buffer kept by a pooled
+// Builder. Larger buffers are left to the garbage collector, rather than
+// pinned by the pool.
+const maxPooledList = 64 << 10
+
+var builderPool = sync.Pool{
+	New: func() interface{} { return &Builder{} },
+}
+
+// AcquireBuilder returns a Builder from a pool, which builds into a reused
+// buffer. The Builder must be returned with ReleaseBuilder once its list is no
+// longer needed. Eg:
+//
+//		b := skiptake.AcquireBuilder()
+//		for _, v := range values {
+//			b.Next(v)
+//		}
+//		w.Write(b.Finish())
+//		skiptake.ReleaseBuilder(b)
+//
+func AcquireBuilder() *Builder {
+	b := builderPool.Get().(*Builder)
+	l := b.Encoder.Elements
+	if l == nil {
+		l = &List{}
+	}
+	*b = Build(l)
+	return b
+}
+
+// ReleaseBuilder returns a Builder acquired with AcquireBuilder to the pool.
+// The list returned by its Finish shares the Builder's buffer, so must not be
+// used after release. Copy the list first to keep it.
+func ReleaseBuilder(b *Builder) {
+	if l := b.Encoder.Elements; l != nil && cap(*l) > maxPooledList {
+		b.Encoder.Elements = nil
+	}
+	builderPool.Put(b)
+}
+
+// mergeScratch holds the cursors, and the Iterators and Decoders behind them,
+// used to merge many lists.
+type mergeScratch struct {
+	dec  []Decoder
+	iter []Iterator
+	cur  []cursor
+}
+
+var mergePool = sync.Pool{
+	New: func() interface{} { return &mergeScratch{} },
+}
+
+// acquireMerge returns cursors over each of the passed lists from a pool, like
+// iterateAll(). The scratch must be returned with release().
+func acquireMerge(lists []List) *mergeScratch {
+	s := mergePool.Get().(*mergeScratch)
+	if cap(s.cur) < len(lists) {
+		s.dec = make([]Decoder, len(lists))
+		s.iter = make([]Iterator, len(lists))
+		s.cur = make([]cursor, len(lists))
+	}
+	s.dec, s.iter, s.cur = s.dec[:len(lists)], s.iter[:len(lists)], s.cur[:len(lists)]
+	for i := range lists {
+		lists[i].DecodeInto(&s.dec[i])
+		s.iter[i] = Iterator{Decoder: &s.dec[i]}
+		s.cur[i] = cursor{src: &s.iter[i], seeker: &s.iter[i]}
+	}
+	return s
+}
+
+// bySelectivity orders the cursors by the length in bytes of their lists,
+// shortest first, as an estimate of how few intervals they hold. Intersecting
+// in this order lets the sparsest list propose candidate intervals, which the
+// denser lists seek to, stepping over runs of repeated pairs between them. The
+// length costs nothing to find, whereas counting intervals would cost a pass
+// over every list. The cursors must not have been advanced.
+func (s *mergeScratch) bySelectivity() {
+	// An insertion sort, as it is stable and allocates nothing.
+	for i := 1; i < len(s.cur); i++ {
+		for j := i; j > 0 && s.size(j) < s.size(j-1); j-- {
+			s.cur[j], s.cur[j-1] = s.cur[j-1], s.cur[j]
+		}
+	}
+}
+
+// size returns the length in bytes of the list of the i'th cursor.
+func (s *mergeScratch) size(i int) int {
+	return len(s.cur[i].src.(*Iterator).Decoder.Elements)
+}
+
+// release returns the scratch to the pool, dropping its references to the
+// merged lists.
+func (s *mergeScratch) release() {
+	for i := range s.dec {
+		s.dec[i] = Decoder{}
+	}
+	mergePool.Put(s)
+}
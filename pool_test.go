@@ -0,0 +1,63 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_AcquireBuilder(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		b := AcquireBuilder()
+		for v := uint64(10); v < 20; v++ {
+			b.Next(v * uint64(i+1))
+		}
+		l := append(List{}, b.Finish()...)
+		ReleaseBuilder(b)
+
+		expected := []uint64{}
+		for v := uint64(10); v < 20; v++ {
+			expected = append(expected, v*uint64(i+1))
+		}
+		if !equalUint64(l.Expand(), expected) {
+			t.Errorf("%v != %v", l.Expand(), expected)
+		}
+	}
+
+	// Large buffers are not kept.
+	b := AcquireBuilder()
+	for i, v := uint64(0), uint64(0); i < maxPooledList; i++ {
+		v += 2 + i%7
+		b.Next(v)
+	}
+	b.Finish()
+	ReleaseBuilder(b)
+	if b.Encoder.Elements != nil {
+		t.Errorf("Released builder kept a buffer of %d bytes", cap(*b.Encoder.Elements))
+	}
+}
+
+func Test_PooledMerge(t *testing.T) {
+	lists := []List{
+		makeRange(intrv{0, 9}, intrv{20, 29}),
+		makeRange(intrv{5, 24}),
+		makeRange(intrv{8, 21}, intrv{40, 50}),
+	}
+	// Warm the pool
+	Union(lists...)
+	Intersection(lists...)
+
+	// Only the result is allocated.
+	allocs := testing.AllocsPerRun(100, func() { Union(lists...) })
+	if allocs > 2 {
+		t.Errorf("Union() made %v allocations", allocs)
+	}
+	allocs = testing.AllocsPerRun(100, func() { Intersection(lists...) })
+	if allocs > 2 {
+		t.Errorf("Intersection() made %v allocations", allocs)
+	}
+
+	// Merges of different sizes reuse the scratch.
+	testUnion(t, makeRange(intrv{0, 29}, intrv{40, 50}).Expand(), lists...)
+	testIntersection(t, makeRange(intrv{8, 9}, intrv{20, 21}).Expand(), lists...)
+	testUnion(t, makeRange(intrv{0, 29}).Expand(), lists[:2]...)
+	testUnion(t, makeRange(intrv{0, 29}, intrv{40, 50}).Expand(), append(lists, lists[0], lists[1])...)
+}
@@ -0,0 +1,121 @@
+// Package posting treats skip-take lists as the posting lists of a search
+// index, and evaluates boolean queries over them document at a time. Eg, to
+// find the documents holding both terms but not a third:
+//
+//		q := posting.AndNot(
+//			posting.And(posting.NewCursor(cat), posting.NewCursor(hat)),
+//			posting.NewCursor(bat),
+//		)
+//		for doc := q.Next(); doc != posting.NoMoreDocs; doc = q.Next() {
+//			...
+//		}
+//
+// Queries are themselves Cursors, so can be nested freely. Matching documents
+// are streamed one at a time, and the posting lists are stepped over interval
+// by interval with NextGEQ, never expanded.
+package posting
+
+import (
+	"math"
+
+	"github.com/arthurt/skiptake"
+)
+
+// NoMoreDocs is the document ID returned by a Cursor once it is exhausted. As
+// for the end of stream of skiptake.Intervals, math.MaxUint64 can therefore
+// not be used as a document ID.
+const NoMoreDocs = math.MaxUint64
+
+// Cursor steps through the document IDs of a posting list, or of the matches
+// of a query, in ascending order. A new Cursor is positioned before its first
+// document.
+type Cursor interface {
+	// Doc returns the current document ID. Returns NoMoreDocs once the cursor
+	// is exhausted, and is undefined before the first call to Next or
+	// NextGEQ.
+	Doc() uint64
+
+	// Next advances to the next document and returns its ID, or NoMoreDocs.
+	Next() uint64
+
+	// NextGEQ advances to the first document whose ID is at least target and
+	// returns its ID, or NoMoreDocs. The cursor never moves backwards, so if
+	// the current document is already at least target, it is returned.
+	NextGEQ(target uint64) uint64
+}
+
+// ListCursor is a Cursor over the members of a skiptake.List.
+type ListCursor struct {
+	iter  skiptake.Iterator
+	doc   uint64
+	last  uint64 // Last value of the current interval
+	begun bool
+}
+
+// NewCursor returns a Cursor over the members of the list.
+func NewCursor(l skiptake.List) *ListCursor {
+	return &ListCursor{iter: l.Iterate()}
+}
+
+// Doc returns the current document ID.
+func (c *ListCursor) Doc() uint64 {
+	return c.doc
+}
+
+// Next advances to the next member of the list.
+func (c *ListCursor) Next() uint64 {
+	if c.begun && c.doc < c.last {
+		c.doc++
+		return c.doc
+	}
+	return c.nextInterval()
+}
+
+// NextGEQ advances to the first member of the list which is at least target.
+// Whole intervals lying before target are stepped over without expansion.
+func (c *ListCursor) NextGEQ(target uint64) uint64 {
+	if c.begun && c.doc >= target {
+		return c.doc
+	}
+	if !c.begun || c.last < target {
+		for c.nextInterval(); c.doc != NoMoreDocs && c.last < target; c.nextInterval() {
+		}
+	}
+	if c.doc < target {
+		c.doc = target
+	}
+	return c.doc
+}
+
+// nextInterval moves to the first member of the next interval of the list.
+func (c *ListCursor) nextInterval() uint64 {
+	c.begun = true
+	first, last := c.iter.NextInterval()
+	if first > last {
+		c.doc, c.last = NoMoreDocs, NoMoreDocs
+	} else {
+		c.doc, c.last = first, last
+	}
+	return c.doc
+}
+
+// Collect builds a skiptake.List of the remaining documents of the cursor. Eg:
+//
+//		matches := posting.Collect(posting.And(cursors...))
+//
+func Collect(c Cursor) skiptake.List {
+	b := skiptake.Build(&skiptake.List{})
+	for doc := c.Next(); doc != NoMoreDocs; doc = c.Next() {
+		b.Next(doc)
+	}
+	return b.Finish()
+}
+
+// Count returns the number of remaining documents of the cursor.
+func Count(c Cursor) uint64 {
+	var n uint64
+	for doc := c.Next(); doc != NoMoreDocs; doc = c.Next() {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package posting
+
+import (
+	"testing"
+
+	"github.com/arthurt/skiptake"
+	"github.com/arthurt/skiptake/skiptaketest"
+)
+
+func Test_ListCursor(t *testing.T) {
+	l := skiptaketest.FromIntervals([][2]uint64{{1, 3}, {10, 10}, {20, 29}})
+	c := NewCursor(l)
+	var docs []uint64
+	for doc := c.Next(); doc != NoMoreDocs; doc = c.Next() {
+		docs = append(docs, doc)
+	}
+	skiptaketest.AssertEquivalent(t, l, docs)
+	if c.Doc() != NoMoreDocs || c.Next() != NoMoreDocs {
+		t.Errorf("exhausted cursor at %d", c.Doc())
+	}
+}
+
+func Test_ListCursor_NextGEQ(t *testing.T) {
+	l := skiptaketest.FromIntervals([][2]uint64{{1, 3}, {10, 10}, {20, 29}})
+	c := NewCursor(l)
+	steps := []struct {
+		target, expected uint64
+	}{
+		{0, 1},
+		{0, 1}, // Never moves backwards
+		{2, 2},
+		{4, 10},
+		{11, 20},
+		{25, 25},
+		{25, 25},
+		{30, NoMoreDocs},
+		{0, NoMoreDocs},
+	}
+	for _, s := range steps {
+		if doc := c.NextGEQ(s.target); doc != s.expected || c.Doc() != doc {
+			t.Errorf("NextGEQ(%d) = %d, Doc() = %d, expected %d", s.target, doc, c.Doc(), s.expected)
+		}
+	}
+
+	// Mixed with Next
+	c = NewCursor(l)
+	if doc := c.NextGEQ(3); doc != 3 {
+		t.Errorf("NextGEQ(3) = %d, expected 3", doc)
+	}
+	if doc := c.Next(); doc != 10 {
+		t.Errorf("Next() = %d, expected 10", doc)
+	}
+	if doc := c.NextGEQ(29); doc != 29 {
+		t.Errorf("NextGEQ(29) = %d, expected 29", doc)
+	}
+	if doc := c.Next(); doc != NoMoreDocs {
+		t.Errorf("Next() = %d, expected NoMoreDocs", doc)
+	}
+
+	if doc := NewCursor(skiptake.List{}).NextGEQ(5); doc != NoMoreDocs {
+		t.Errorf("NextGEQ() of empty list = %d", doc)
+	}
+}
+
+func Test_CollectCount(t *testing.T) {
+	l := skiptaketest.FromIntervals([][2]uint64{{1, 3}, {10, 10}, {20, 29}})
+	skiptaketest.AssertEquivalent(t, Collect(NewCursor(l)), l.Expand())
+	if n := Count(NewCursor(l)); n != 14 {
+		t.Errorf("Count() = %d, expected 14", n)
+	}
+
+	// Only the remaining documents
+	c := NewCursor(l)
+	c.NextGEQ(10)
+	skiptaketest.AssertEquivalent(t, Collect(c), l.Expand()[4:])
+}
@@ -0,0 +1,158 @@
+package posting
+
+// Boolean queries over Cursors, evaluated document at a time.
+
+// andCursor matches the documents present in all of its sub-cursors.
+type andCursor struct {
+	subs  []Cursor
+	doc   uint64
+	begun bool
+}
+
+// And returns a Cursor over the documents present in all of the cursors. The
+// cursors leapfrog one another with NextGEQ, so the documents of one cursor
+// which the others lack are mostly stepped over. They are probed in the order
+// passed, so the rarest should be passed first. And of no cursors matches
+// nothing.
+func And(cursors ...Cursor) Cursor {
+	return &andCursor{subs: cursors}
+}
+
+func (c *andCursor) Doc() uint64 {
+	return c.doc
+}
+
+func (c *andCursor) Next() uint64 {
+	if c.begun && c.doc == NoMoreDocs {
+		return c.doc
+	}
+	if len(c.subs) == 0 {
+		return c.match(NoMoreDocs)
+	}
+	return c.match(c.subs[0].Next())
+}
+
+func (c *andCursor) NextGEQ(target uint64) uint64 {
+	if c.begun && c.doc >= target {
+		return c.doc
+	}
+	if len(c.subs) == 0 {
+		return c.match(NoMoreDocs)
+	}
+	return c.match(c.subs[0].NextGEQ(target))
+}
+
+// match advances the sub-cursors to the first document, from target, which
+// they all hold.
+func (c *andCursor) match(target uint64) uint64 {
+	c.begun = true
+	for agreed := false; !agreed && target != NoMoreDocs; {
+		agreed = true
+		for _, s := range c.subs {
+			if d := s.NextGEQ(target); d != target {
+				target, agreed = d, false
+				break
+			}
+		}
+	}
+	c.doc = target
+	return c.doc
+}
+
+// orCursor matches the documents present in any of its sub-cursors.
+type orCursor struct {
+	subs  []Cursor
+	doc   uint64
+	begun bool
+}
+
+// Or returns a Cursor over the documents present in any of the cursors. Each
+// step compares the current document of every cursor, so Or suits queries of
+// a modest number of terms. Or of no cursors matches nothing.
+func Or(cursors ...Cursor) Cursor {
+	return &orCursor{subs: cursors}
+}
+
+func (c *orCursor) Doc() uint64 {
+	return c.doc
+}
+
+func (c *orCursor) Next() uint64 {
+	for _, s := range c.subs {
+		if !c.begun || (s.Doc() == c.doc && c.doc != NoMoreDocs) {
+			s.Next()
+		}
+	}
+	return c.lowest()
+}
+
+func (c *orCursor) NextGEQ(target uint64) uint64 {
+	if c.begun && c.doc >= target {
+		return c.doc
+	}
+	for _, s := range c.subs {
+		if !c.begun || s.Doc() < target {
+			s.NextGEQ(target)
+		}
+	}
+	return c.lowest()
+}
+
+// lowest moves to the lowest current document of the sub-cursors.
+func (c *orCursor) lowest() uint64 {
+	c.begun = true
+	c.doc = NoMoreDocs
+	for _, s := range c.subs {
+		if d := s.Doc(); d < c.doc {
+			c.doc = d
+		}
+	}
+	return c.doc
+}
+
+// andNotCursor matches the documents of one cursor absent from another.
+type andNotCursor struct {
+	include Cursor
+	exclude Cursor
+	doc     uint64
+	begun   bool
+}
+
+// AndNot returns a Cursor over the documents of include which are not in
+// exclude. exclude is only advanced as far as the documents of include, with
+// NextGEQ. Eg, to negate a query within all documents up to n:
+//
+//		q := posting.AndNot(posting.NewCursor(skiptake.FromRaw(0, n+1)), q)
+//
+func AndNot(include, exclude Cursor) Cursor {
+	return &andNotCursor{include: include, exclude: exclude}
+}
+
+func (c *andNotCursor) Doc() uint64 {
+	return c.doc
+}
+
+func (c *andNotCursor) Next() uint64 {
+	if c.begun && c.doc == NoMoreDocs {
+		return c.doc
+	}
+	return c.match(c.include.Next())
+}
+
+func (c *andNotCursor) NextGEQ(target uint64) uint64 {
+	if c.begun && c.doc >= target {
+		return c.doc
+	}
+	return c.match(c.include.NextGEQ(target))
+}
+
+// match advances include from its document d to the first document which
+// exclude lacks.
+func (c *andNotCursor) match(d uint64) uint64 {
+	c.begun = true
+	for d != NoMoreDocs && c.exclude.NextGEQ(d) == d {
+		d = c.include.Next()
+	}
+	c.doc = d
+	return c.doc
+}
@@ -0,0 +1,113 @@
+package posting
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/arthurt/skiptake"
+	"github.com/arthurt/skiptake/skiptaketest"
+)
+
+func cursors(lists []skiptake.List) []Cursor {
+	c := make([]Cursor, len(lists))
+	for i, l := range lists {
+		c[i] = NewCursor(l)
+	}
+	return c
+}
+
+func randomLists(r *rand.Rand, n int) []skiptake.List {
+	lists := make([]skiptake.List, n)
+	for i := range lists {
+		g := skiptaketest.NewGenerator(r, 0.05+r.Float64()*0.5, 1+r.Float64()*8)
+		lists[i] = g.List(r.Intn(200))
+	}
+	return lists
+}
+
+func Test_And(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		lists := randomLists(r, 1+r.Intn(4))
+		skiptaketest.AssertEquivalent(t, Collect(And(cursors(lists)...)), skiptake.Intersection(lists...).Expand())
+	}
+	if doc := And().Next(); doc != NoMoreDocs {
+		t.Errorf("And().Next() = %d", doc)
+	}
+}
+
+func Test_Or(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		lists := randomLists(r, 1+r.Intn(4))
+		skiptaketest.AssertEquivalent(t, Collect(Or(cursors(lists)...)), skiptake.Union(lists...).Expand())
+	}
+	if doc := Or().Next(); doc != NoMoreDocs {
+		t.Errorf("Or().Next() = %d", doc)
+	}
+}
+
+func Test_AndNot(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 100; i++ {
+		lists := randomLists(r, 2)
+		skiptaketest.AssertEquivalent(t, Collect(AndNot(NewCursor(lists[0]), NewCursor(lists[1]))), skiptake.Difference(lists[0], lists[1]).Expand())
+	}
+}
+
+func Test_NestedQueries(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 100; i++ {
+		lists := randomLists(r, 5)
+		// (a AND (b OR c)) AND NOT (d AND e)
+		q := AndNot(
+			And(NewCursor(lists[0]), Or(NewCursor(lists[1]), NewCursor(lists[2]))),
+			And(NewCursor(lists[3]), NewCursor(lists[4])),
+		)
+		expected := skiptake.Difference(
+			skiptake.Intersection(lists[0], skiptake.Union(lists[1], lists[2])),
+			skiptake.Intersection(lists[3], lists[4]),
+		)
+		skiptaketest.AssertEquivalent(t, Collect(q), expected.Expand())
+	}
+}
+
+func Test_QueryNextGEQ(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		lists := randomLists(r, 3)
+		queries := []struct {
+			q        Cursor
+			expected skiptake.List
+		}{
+			{And(cursors(lists)...), skiptake.Intersection(lists...)},
+			{Or(cursors(lists)...), skiptake.Union(lists...)},
+			{AndNot(NewCursor(lists[0]), NewCursor(lists[1])), skiptake.Difference(lists[0], lists[1])},
+		}
+		for _, qe := range queries {
+			// Jump through the matches, checking each against the expected
+			// members.
+			expected := NewCursor(qe.expected)
+			var target uint64
+			for {
+				doc := qe.q.NextGEQ(target)
+				if e := expected.NextGEQ(target); doc != e {
+					t.Fatalf("NextGEQ(%d) = %d, expected %d", target, doc, e)
+				}
+				if doc == NoMoreDocs {
+					break
+				}
+				if next, e := qe.q.Next(), expected.Next(); next != e {
+					t.Fatalf("Next() after %d = %d, expected %d", doc, next, e)
+				}
+				target = qe.q.Doc() + uint64(r.Intn(50))
+			}
+		}
+	}
+}
+
+func Test_Negation(t *testing.T) {
+	l := skiptaketest.FromIntervals([][2]uint64{{1, 3}, {10, 10}})
+	all := NewCursor(skiptake.FromRaw(0, 13))
+	skiptaketest.AssertEquivalent(t, Collect(AndNot(all, NewCursor(l))), []uint64{0, 4, 5, 6, 7, 8, 9, 11, 12})
+}
@@ -0,0 +1,148 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// Progress reporting for long-running operations over large lists.
+
+// Progress receives periodic reports from a long-running operation. pairs is
+// the number of skip-take pairs processed so far, and bytes the number of
+// bytes of encoded input consumed, of total. A final report is made once the
+// operation is complete, with bytes equal to total. Eg:
+//
+//		l.ValidateWithProgress(func(pairs uint64, bytes, total int) {
+//			log.Printf("validated %d pairs, %d%%", pairs, bytes*100/total)
+//		})
+//
+// Pairs in runs of repeated pairs are each counted.
+type Progress func(pairs uint64, bytes, total int)
+
+// progressInterval is how many pairs are processed between reports.
+const progressInterval = 1 << 16
+
+// progress tracks the decoders of an operation, and reports their progress.
+type progress struct {
+	fn    Progress
+	decs  []*Decoder
+	total int
+	pairs uint64
+	next  uint64 // Pairs at which to next report
+}
+
+func newProgress(fn Progress) *progress {
+	return &progress{fn: fn, next: progressInterval}
+}
+
+// track adds a decoder to those reported on.
+func (p *progress) track(d *Decoder) {
+	p.decs = append(p.decs, d)
+	p.total += len(d.Elements)
+}
+
+// decode returns a Decoder of l which is tracked.
+func (p *progress) decode(l List) *Decoder {
+	d := l.Decode()
+	p.track(&d)
+	return &d
+}
+
+// add counts n pairs as processed, reporting if due.
+func (p *progress) add(n uint64) {
+	if p.pairs += n; p.pairs >= p.next {
+		p.report()
+		p.next = p.pairs + progressInterval
+	}
+}
+
+// report calls the Progress function with the current position.
+func (p *progress) report() {
+	var bytes int
+	for _, d := range p.decs {
+		bytes += d.i
+	}
+	p.fn(p.pairs, bytes, p.total)
+}
+
+// progressIntervals counts each interval read from an Iterator as a pair.
+// Iterators coalesce pairs with zero skips or takes, which lists built by this
+// package do not hold, so intervals and pairs are normally one and the same.
+type progressIntervals struct {
+	src *Iterator
+	p   *progress
+}
+
+func (t *progressIntervals) NextInterval() (first, last uint64) {
+	first, last = t.src.NextInterval()
+	if first <= last {
+		t.p.add(1)
+	}
+	return
+}
+
+// iterate returns cursors over the lists, like iterateAll(), whose progress
+// is tracked.
+func (p *progress) iterate(lists []List) []cursor {
+	cur := iterateAll(lists)
+	wrapped := make([]progressIntervals, len(cur))
+	for i := range cur {
+		iter := cur[i].src.(*Iterator)
+		p.track(iter.Decoder)
+		wrapped[i] = progressIntervals{src: iter, p: p}
+		cur[i].src, cur[i].seeker = &wrapped[i], nil
+	}
+	return cur
+}
+
+// ExpandWithProgress returns Expand(), reporting progress to fn.
+func (l List) ExpandWithProgress(fn Progress) []uint64 {
+	p := newProgress(fn)
+	output := make([]uint64, 0, l.Len())
+	src := p.iterate([]List{l})[0].src
+	for first, last := src.NextInterval(); first <= last; first, last = src.NextInterval() {
+		for v := first; ; v++ {
+			output = append(output, v)
+			if v == last {
+				break
+			}
+		}
+	}
+	p.report()
+	return output
+}
+
+// UnionWithProgress returns Union(lists...), reporting progress to fn.
+func UnionWithProgress(fn Progress, lists ...List) List {
+	p := newProgress(fn)
+	b := Build(&List{})
+	union(&b, p.iterate(lists))
+	p.report()
+	return b.Finish()
+}
+
+// IntersectionWithProgress returns Intersection(lists...), reporting progress
+// to fn. The intersection stops once any list is exhausted, so the final report
+// may be of fewer bytes than the total.
+func IntersectionWithProgress(fn Progress, lists ...List) List {
+	p := newProgress(fn)
+	b := Build(&List{})
+	intersection(&b, p.iterate(lists), math.MaxUint64)
+	p.report()
+	return b.Finish()
+}
+
+// DifferenceWithProgress returns Difference(a, b), reporting progress to fn.
+func DifferenceWithProgress(fn Progress, a, b List) List {
+	p := newProgress(fn)
+	cur := p.iterate([]List{a, b})
+	result := Build(&List{})
+	result.AddIntervals(newDifferenceIntervals(cur[0].src, cur[1].src))
+	p.report()
+	return result.Finish()
+}
+
+// ValidateWithProgress returns Validate(), reporting progress to fn as the
+// pairs of the list are checked.
+func (l List) ValidateWithProgress(fn Progress) error {
+	return l.validate(newProgress(fn))
+}
@@ -0,0 +1,104 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+// progressLog records the reports made to a Progress.
+type progressLog struct {
+	pairs []uint64
+	bytes []int
+	total int
+}
+
+func (r *progressLog) report(pairs uint64, bytes, total int) {
+	r.pairs = append(r.pairs, pairs)
+	r.bytes = append(r.bytes, bytes)
+	r.total = total
+}
+
+// check checks that the reports advance, and end with the whole input.
+func (r *progressLog) check(t *testing.T, name string, reports int, pairs uint64, total int) {
+	t.Helper()
+	if len(r.pairs) != reports {
+		t.Errorf("%s reported %d times, expected %d", name, len(r.pairs), reports)
+		return
+	}
+	for i := 1; i < len(r.pairs); i++ {
+		if r.pairs[i] < r.pairs[i-1] || r.bytes[i] < r.bytes[i-1] {
+			t.Errorf("%s reports went backwards: %v, %v", name, r.pairs, r.bytes)
+		}
+	}
+	last := len(r.pairs) - 1
+	if r.pairs[last] != pairs || r.bytes[last] != total || r.total != total {
+		t.Errorf("%s final report = %d pairs, %d of %d bytes, expected %d pairs, %d bytes", name, r.pairs[last], r.bytes[last], r.total, pairs, total)
+	}
+}
+
+// sparseList returns a list of n isolated values, with gaps alternating in
+// length so that no pairs repeat.
+func sparseList(n int) List {
+	values := make([]uint64, n)
+	for i := range values {
+		values[i] = uint64(3*i + i%2)
+	}
+	return Create(values...)
+}
+
+func Test_ExpandWithProgress(t *testing.T) {
+	l := sparseList(200000)
+	var r progressLog
+	result := l.ExpandWithProgress(r.report)
+	if !equalUint64(result, l.Expand()) {
+		t.Error("ExpandWithProgress() != Expand()")
+	}
+	pairs := l.NumIntervals()
+	r.check(t, "ExpandWithProgress()", int(pairs/progressInterval)+1, pairs, len(l))
+}
+
+func Test_SetOperationsWithProgress(t *testing.T) {
+	a := sparseList(100000)
+	b := Complement(a).Slice(0, 100000)
+	pairs := a.NumIntervals() + b.NumIntervals()
+
+	var r progressLog
+	if u := UnionWithProgress(r.report, a, b); string(u) != string(Union(a, b)) {
+		t.Error("UnionWithProgress() != Union()")
+	}
+	r.check(t, "UnionWithProgress()", int(pairs/progressInterval)+1, pairs, len(a)+len(b))
+
+	r = progressLog{}
+	if d := DifferenceWithProgress(r.report, a, b); string(d) != string(Difference(a, b)) {
+		t.Error("DifferenceWithProgress() != Difference()")
+	}
+	r.check(t, "DifferenceWithProgress()", int(pairs/progressInterval)+1, pairs, len(a)+len(b))
+
+	r = progressLog{}
+	c := makeRange(intrv{0, 1000000})
+	if i := IntersectionWithProgress(r.report, a, c); string(i) != string(Intersection(a, c)) {
+		t.Error("IntersectionWithProgress() != Intersection()")
+	}
+	if len(r.pairs) < 2 {
+		t.Errorf("IntersectionWithProgress() reported %d times", len(r.pairs))
+	}
+}
+
+func Test_ValidateWithProgress(t *testing.T) {
+	l := sparseList(200000)
+	var r progressLog
+	if err := l.ValidateWithProgress(r.report); err != nil {
+		t.Fatal(err)
+	}
+	r.check(t, "ValidateWithProgress()", 200000/progressInterval+1, 200000, len(l))
+
+	// Runs of repeated pairs are counted pair by pair
+	r = progressLog{}
+	if err := FromRaw(1, 1, 1, 1, 1, 1).ValidateWithProgress(r.report); err != nil {
+		t.Fatal(err)
+	}
+	r.check(t, "ValidateWithProgress()", 1, 3, len(FromRaw(1, 1, 1, 1, 1, 1)))
+
+	if err := FromRaw(0, 1<<63, 0, 1<<63, 1, 1).ValidateWithProgress(func(uint64, int, int) {}); err != ErrRange {
+		t.Errorf("ValidateWithProgress() error = %v, expected %v", err, ErrRange)
+	}
+}
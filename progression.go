@@ -0,0 +1,62 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// Progression is a virtual arithmetic progression of values, {Base +
+// k*Stride : k < N}. It implements Intervals, so it can take part in set
+// operations without ever being expanded or built into a List. Eg, every third
+// slot of a week of minutes:
+//
+//		mask := &Progression{Base: 0, Stride: 3, N: 7 * 24 * 60 / 3}
+//		result := IntersectionOf(mask, &iter)
+//
+// A Progression is consumed as it is read. Use Reset() to read it again.
+//
+// Stride must be at least one, unless N is at most one, and the last value,
+// Base + (N-1)*Stride, must not overflow.
+type Progression struct {
+	Base   uint64
+	Stride uint64
+	N      uint64
+	k      uint64 // Number of values read
+}
+
+// Len returns how many values are in the progression.
+func (p *Progression) Len() uint64 {
+	return p.N
+}
+
+// Contains returns true if v is a member of the progression.
+func (p *Progression) Contains(v uint64) bool {
+	if p.N == 0 || v < p.Base {
+		return false
+	}
+	if p.Stride == 0 {
+		return v == p.Base
+	}
+	d := v - p.Base
+	return d%p.Stride == 0 && d/p.Stride < p.N
+}
+
+// Reset resets the progression to be read again from its first value.
+func (p *Progression) Reset() {
+	p.k = 0
+}
+
+// NextInterval returns the next interval of the progression. With a stride of
+// one, the whole progression is a single interval. Returns (math.MaxUint64, 0)
+// in the case of end of stream.
+func (p *Progression) NextInterval() (first, last uint64) {
+	if p.k >= p.N {
+		return math.MaxUint64, 0
+	}
+	if p.Stride == 1 {
+		p.k = p.N
+		return p.Base, p.Base + p.N - 1
+	}
+	first = p.Base + p.k*p.Stride
+	p.k++
+	return first, first
+}
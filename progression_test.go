@@ -0,0 +1,49 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Progression(t *testing.T) {
+	cases := []Progression{
+		{Base: 0, Stride: 3, N: 10},
+		{Base: 5, Stride: 1, N: 10},
+		{Base: 7, Stride: 2, N: 1},
+		{Base: 7, Stride: 0, N: 1},
+		{Base: 7, Stride: 4, N: 0},
+		{Base: 0xffffffffffffff00, Stride: 0x10, N: 0x10},
+	}
+	for _, p := range cases {
+		p := p
+		expected := []uint64{}
+		for k := uint64(0); k < p.N; k++ {
+			expected = append(expected, p.Base+k*p.Stride)
+		}
+		result := UnionOf(&p)
+		if !equalUint64(result.Expand(), expected) {
+			t.Errorf("Progression %+v = %v, expected %v", p, result.Expand(), expected)
+		}
+		expectUint64(t, p.Len(), result.Len())
+
+		for _, v := range []uint64{0, 1, 3, 5, 6, 7, 14, 27, 30, 0xffffffffffffff10, 0xfffffffffffffff0, 0xffffffffffffffff} {
+			if p.Contains(v) != result.Contains(v) {
+				t.Errorf("Progression %+v Contains(%d) = %v", p, v, p.Contains(v))
+			}
+		}
+
+		p.Reset()
+		if again := UnionOf(&p); !Equal(again, result) {
+			t.Errorf("Progression after Reset() = %v, expected %v", again, result)
+		}
+	}
+}
+
+func Test_ProgressionIntersection(t *testing.T) {
+	list := makeRange(intrv{10, 20}, intrv{100, 105})
+	iter := list.Iterate()
+	mask := &Progression{Base: 0, Stride: 3, N: 1000}
+	result := IntersectionOf(mask, &iter)
+	if expected := Create(12, 15, 18, 102, 105); !Equal(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+}
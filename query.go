@@ -0,0 +1,605 @@
+package skiptake
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Queries on the values of a List.
+
+// Bounds returns the smallest and largest members of the list. ok is false if
+// the list is empty. Only the skip-take pairs are decoded, and runs of repeated
+// pairs are stepped over arithmetically, so this is cheaper than iterating.
+func (l List) Bounds() (first, last uint64, ok bool) {
+	var n uint64
+	for d := l.Decode(); !d.EOS(); {
+		skip, take, count := d.NextRun()
+		if take == 0 {
+			n += count * skip
+			continue
+		}
+		if !ok {
+			first, ok = n+skip, true
+		}
+		n += count * (skip + take)
+		last = n - 1
+	}
+	return
+}
+
+// First returns the smallest member of the list. ok is false if the list is
+// empty. Only the pairs up to the first member are decoded, and runs of
+// repeated pairs are stepped over, as for Bounds().
+func (l List) First() (first uint64, ok bool) {
+	var n uint64
+	for d := l.Decode(); !d.EOS(); {
+		skip, take, count := d.NextRun()
+		if take > 0 {
+			return n + skip, true
+		}
+		n += count * skip
+	}
+	return 0, false
+}
+
+// Last returns the largest member of the list. ok is false if the list is
+// empty. The whole list is scanned, but as for Bounds(), runs of repeated
+// pairs are stepped over rather than decoded.
+func (l List) Last() (last uint64, ok bool) {
+	_, last, ok = l.Bounds()
+	return
+}
+
+// IsEmpty returns true if the list has no members. A list can be non-zero in
+// length yet empty, such as one holding only pairs with takes of zero, so
+// len(l) == 0 is not a reliable test.
+func (l List) IsEmpty() bool {
+	_, ok := l.First()
+	return !ok
+}
+
+// NumIntervals returns how many intervals of consecutive values are in the
+// list, as returned by Iterator.NextInterval().
+func (l List) NumIntervals() uint64 {
+	var n uint64
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		n++
+	}
+	return n
+}
+
+// CountRunsAtLeast returns how many runs of consecutive values, as returned by
+// Iterator.NextInterval(), hold at least k values. Together with
+// NumIntervals(), this measures how fragmented the list is.
+func (l List) CountRunsAtLeast(k uint64) uint64 {
+	if k == 0 {
+		k = 1
+	}
+	var n uint64
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if last-first >= k-1 {
+			n++
+		}
+	}
+	return n
+}
+
+// LongestRun returns the longest run of consecutive values in the list, as
+// returned by Iterator.NextInterval(), along with its length. The earliest is
+// returned if several are longest. n is zero if the list is empty. A run of
+// all 2^64 values reports n as math.MaxUint64.
+func (l List) LongestRun() (first, last, n uint64) {
+	iter := l.Iterate()
+	for f, t := iter.NextInterval(); f <= t; f, t = iter.NextInterval() {
+		if n == 0 || t-f > last-first {
+			first, last = f, t
+			n = t - f + 1
+			if n == 0 {
+				n = math.MaxUint64
+			}
+		}
+	}
+	return
+}
+
+// LargestGap returns the largest gap between two runs of the list, that is
+// the longest run of non-members lying between the first and last members,
+// along with its length. The earliest is returned if several are largest. n
+// is zero if the list holds fewer than two runs.
+func (l List) LargestGap() (first, last, n uint64) {
+	g := l.Gaps()
+	for f, t, k := g.NextGap(); k > 0; f, t, k = g.NextGap() {
+		if k > n {
+			first, last, n = f, t, k
+		}
+	}
+	return
+}
+
+// Contains returns true if v is a member of the list.
+func (l List) Contains(v uint64) bool {
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if v <= last {
+			return v >= first
+		}
+	}
+	return false
+}
+
+// NearestRun returns the run of consecutive values of the list containing v,
+// as returned by Iterator.NextInterval(), or failing that the run nearest to v
+// on either side. distance is how far v lies outside the run, zero if it is
+// contained. Ties are broken in favour of the earlier run. ok is false if the
+// list is empty.
+func (l List) NearestRun(v uint64) (first, last, distance uint64, ok bool) {
+	iter := l.Iterate()
+	for f, t := iter.NextInterval(); f <= t; f, t = iter.NextInterval() {
+		if t < v {
+			first, last, ok = f, t, true
+			continue
+		}
+		if f <= v {
+			return f, t, 0, true
+		}
+		if !ok || f-v < v-last {
+			return f, t, f - v, true
+		}
+		break
+	}
+	if ok {
+		distance = v - last
+	}
+	return
+}
+
+// ContainsMany reports for each of the passed values whether it is a member of
+// the list. The values should be in ascending order, in which case the list is
+// walked only once. Out of order values restart the walk.
+func (l List) ContainsMany(sorted []uint64) []bool {
+	result := make([]bool, len(sorted))
+	iter := l.Iterate()
+	first, last := iter.NextInterval()
+	for i, v := range sorted {
+		if i > 0 && v < sorted[i-1] {
+			iter.Reset()
+			first, last = iter.NextInterval()
+		}
+		for first <= last && last < v {
+			first, last = iter.NextInterval()
+		}
+		result[i] = first <= last && v >= first
+	}
+	return result
+}
+
+// ContainsCursor answers membership queries against a list with arguments in
+// ascending order, as returned by List.ContainsCursor(). It keeps its place
+// between queries, so a sequence of them walks the list only once. Eg, to join
+// a stream of sorted keys:
+//
+//		c := list.ContainsCursor()
+//		for _, k := range keys {
+//			if c.Contains(k) {
+//				...
+//			}
+//		}
+//
+// Runs of repeated skip-take pairs are stepped over arithmetically. A query
+// less than the previous one restarts the walk from the start of the list.
+type ContainsCursor struct {
+	d     Decoder
+	n     uint64 // First value of the current pair
+	skip  uint64
+	take  uint64
+	count uint64 // Pairs remaining of the current run
+	prev  uint64
+}
+
+// ContainsCursor returns a ContainsCursor over the list.
+func (l List) ContainsCursor() ContainsCursor {
+	return ContainsCursor{d: l.Decode()}
+}
+
+// Contains returns true if v is a member of the list.
+func (c *ContainsCursor) Contains(v uint64) bool {
+	if v < c.prev {
+		c.Reset()
+	}
+	c.prev = v
+	for {
+		if c.count == 0 {
+			if c.d.EOS() {
+				return false
+			}
+			c.skip, c.take, c.count = c.d.NextRun()
+			continue
+		}
+		period := c.skip + c.take
+		if period < c.skip {
+			// The pair ends at math.MaxUint64, so holds every value from c.n.
+			return v-c.n >= c.skip
+		}
+		// The run may end at math.MaxUint64, so compare in pairs rather than
+		// values to avoid overflow.
+		j := uint64(math.MaxUint64)
+		if period != 0 {
+			j = (v - c.n) / period
+		}
+		if j >= c.count {
+			c.n += c.count * period
+			c.count = 0
+			continue
+		}
+		// Step to the pair holding v.
+		c.n += j * period
+		c.count -= j
+		return v-c.n >= c.skip
+	}
+}
+
+// Reset restarts the cursor from the start of the list.
+func (c *ContainsCursor) Reset() {
+	c.d.Reset()
+	c.n, c.count, c.prev = 0, 0, 0
+}
+
+// Rank returns the number of members of the list which are less than v. For a
+// member v, this is its position in the expanded sequence.
+func (l List) Rank(v uint64) uint64 {
+	return l.RankMany([]uint64{v})[0]
+}
+
+// RankMany returns Rank() for each of the passed values. The values should be
+// in ascending order, in which case the list is walked only once. Out of order
+// values restart the walk.
+func (l List) RankMany(sorted []uint64) []uint64 {
+	result := make([]uint64, len(sorted))
+	iter := l.Iterate()
+	var before uint64 // Count of members in intervals before the current one
+	first, last := iter.NextInterval()
+	for i, v := range sorted {
+		if i > 0 && v < sorted[i-1] {
+			iter.Reset()
+			before = 0
+			first, last = iter.NextInterval()
+		}
+		for first <= last && last < v {
+			before += last - first + 1
+			first, last = iter.NextInterval()
+		}
+		result[i] = before
+		if first <= last && v > first {
+			result[i] += v - first
+		}
+	}
+	return result
+}
+
+// ValuesAt returns up to limit members of the list, starting with the member
+// at position offset in the expanded sequence. Only the returned values are
+// expanded.
+func (l List) ValuesAt(offset, limit uint64) []uint64 {
+	result := []uint64{}
+	iter := l.Iterate()
+	if _, take := iter.Seek(offset); take == 0 {
+		return result
+	}
+	for n := iter.Next(); !iter.EOS() && uint64(len(result)) < limit; n = iter.Next() {
+		result = append(result, n)
+	}
+	return result
+}
+
+// Slice returns a new List of up to limit members of the list, starting with
+// the member at position offset in the expanded sequence. This is the List
+// equivalent of ValuesAt(), but built from whole intervals without expanding
+// them.
+func (l List) Slice(offset, limit uint64) List {
+	b := Build(&List{})
+	iter := l.Iterate()
+	if _, take := iter.Seek(offset); take == 0 {
+		return b.Finish()
+	}
+	for first, last := iter.Interval(); first <= last && limit > 0; first, last = iter.NextInterval() {
+		if last-first >= limit {
+			last = first + limit - 1
+		}
+		b.interval(first, last)
+		limit -= last - first + 1
+	}
+	return b.Finish()
+}
+
+// Downsample returns a new List of every n-th member of the list, by rank:
+// the members at positions 0, n, 2n and so on of the expanded sequence. An n
+// of zero is taken as one. Eg, a rendering of a huge selection at a low zoom:
+//
+//		preview := list.Downsample(1000)
+//
+// The members kept from each interval form an arithmetic progression, which
+// is encoded as a run of repeated skip-take pairs in constant time, so the
+// cost is proportional to the number of intervals rather than members.
+func (l List) Downsample(n uint64) List {
+	if n == 0 {
+		n = 1
+	}
+	b := Build(&List{})
+	var rank uint64 // Position of the first member of the current interval
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		offset := (n - rank%n) % n
+		if offset <= last-first {
+			b.progression(first+offset, n, (last-first-offset)/n+1)
+		}
+		rank += last - first + 1
+	}
+	return b.Finish()
+}
+
+// ThinByDistance returns a new List of the members of the list kept greedily
+// at least d apart: the smallest member is kept, and then each member at least
+// d greater than the last kept. A d of zero or one keeps every member.
+//
+// As for Downsample(), the members kept from each interval are added as an
+// arithmetic progression, so an interval of L members starting past the last
+// kept member contributes ⌈L/d⌉ members without being expanded.
+func (l List) ThinByDistance(d uint64) List {
+	if d == 0 {
+		d = 1
+	}
+	b := Build(&List{})
+	var next uint64 // Smallest value which may be kept
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if last < next {
+			continue
+		}
+		if first < next {
+			first = next
+		}
+		n := (last-first)/d + 1
+		b.progression(first, d, n)
+		kept := first + (n-1)*d
+		if kept+d < kept {
+			break // No further member can be d away
+		}
+		next = kept + d
+	}
+	return b.Finish()
+}
+
+// FilterModulo returns a new List of the members of the list congruent to r
+// modulo m, such as the share of one of m workers partitioning by value. r is
+// taken modulo m. An m of zero keeps only r itself, if it is a member.
+//
+// As for Downsample(), the members kept from each interval are added as an
+// arithmetic progression of stride m, without being expanded.
+func (l List) FilterModulo(m, r uint64) List {
+	b := Build(&List{})
+	if m == 0 {
+		if l.Contains(r) {
+			b.Next(r)
+		}
+		return b.Finish()
+	}
+	r %= m
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		// The distance from first to the next member of the class, found
+		// without overflow for an m near math.MaxUint64.
+		offset := r - first%m
+		if r < first%m {
+			offset = m - (first%m - r)
+		}
+		if offset <= last-first {
+			b.progression(first+offset, m, (last-first-offset)/m+1)
+		}
+	}
+	return b.Finish()
+}
+
+// PartitionByModulo splits the list into m lists, where list k holds the
+// members congruent to k modulo m, as FilterModulo(m, k) would. The list is
+// read only once: each interval adds a progression to each of the residue
+// classes it reaches, so the cost per interval is at most m. Returns nil if m
+// is zero.
+func (l List) PartitionByModulo(m uint64) []List {
+	if m == 0 {
+		return nil
+	}
+	parts := make([]List, m)
+	b := make([]Builder, m)
+	for k := range b {
+		b[k] = Build(&parts[k])
+	}
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		classes := m
+		if last-first < m {
+			classes = last - first + 1
+		}
+		for i := uint64(0); i < classes; i++ {
+			v := first + i
+			b[v%m].progression(v, m, (last-v)/m+1)
+		}
+	}
+	for k := range b {
+		b[k].Finish()
+	}
+	return parts
+}
+
+// SplitParts divides the list into p lists of consecutive members by rank,
+// whose lengths differ by at most one, such as to hand balanced work to p
+// workers. Intervals are split where they straddle the boundary between two
+// parts. If the list has fewer than p members, the later parts are empty.
+// Returns nil if p is zero.
+func (l List) SplitParts(p int) []List {
+	if p <= 0 {
+		return nil
+	}
+	n := l.Len()
+	size, extra := n/uint64(p), n%uint64(p) // The first extra parts have one more
+	parts := make([]List, p)
+	part := 0
+	b := Build(&parts[0])
+	need := size
+	if extra > 0 {
+		need++
+	}
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		for first <= last {
+			for need == 0 {
+				b.Finish()
+				part++
+				b = Build(&parts[part])
+				need = size
+				if uint64(part) < extra {
+					need++
+				}
+			}
+			k := last - first + 1
+			if k > need || k == 0 {
+				k = need
+			}
+			b.interval(first, first+k-1)
+			need -= k
+			if first+k-1 == last {
+				break
+			}
+			first += k
+		}
+	}
+	b.Finish()
+	for part++; part < p; part++ {
+		parts[part] = List{}
+	}
+	return parts
+}
+
+// Sample returns k members of the list chosen uniformly at random without
+// replacement, in ascending order. If the list has k or fewer members, all of
+// them are returned.
+//
+// Ranks are drawn first, and the list is then walked once with Seek(), so
+// only the sampled values are expanded. This makes it suitable for estimating
+// properties of lists far too large to expand. Eg:
+//
+//		r := rand.New(rand.NewSource(seed))
+//		values := list.Sample(1000, r)
+//
+func (l List) Sample(k int, r *rand.Rand) []uint64 {
+	n := l.Len()
+	if k <= 0 {
+		return []uint64{}
+	}
+	if uint64(k) >= n {
+		return l.Expand()
+	}
+
+	// Floyd's algorithm for choosing k distinct ranks from [0, n)
+	chosen := make(map[uint64]struct{}, k)
+	ranks := make([]uint64, 0, k)
+	for j := n - uint64(k); j < n; j++ {
+		rank := randUint64n(r, j+1)
+		if _, dup := chosen[rank]; dup {
+			rank = j
+		}
+		chosen[rank] = struct{}{}
+		ranks = append(ranks, rank)
+	}
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+
+	result := make([]uint64, len(ranks))
+	iter := l.Iterate()
+	for i, rank := range ranks {
+		result[i], _ = iter.Seek(rank)
+	}
+	return result
+}
+
+// randUint64n returns a uniform random value in [0, n).
+func randUint64n(r *rand.Rand, n uint64) uint64 {
+	if n <= math.MaxInt64 {
+		return uint64(r.Int63n(int64(n)))
+	}
+	// n is at least half the range, so rejection is cheap
+	for {
+		if v := r.Uint64(); v < n {
+			return v
+		}
+	}
+}
+
+// Quantile returns the member of the list at rank ⌈q·Len()⌉, counting from
+// one, so that q of 0.5 is the median and q of 1 the largest member. q is
+// clamped to [0, 1], with 0 returning the smallest member. Returns
+// math.MaxUint64 for an empty list.
+func (l List) Quantile(q float64) uint64 {
+	return l.Quantiles(q)[0]
+}
+
+// Quantiles returns Quantile() for each of the passed fractions. The length of
+// the list is counted once from its skip-take runs, without expansion, and the
+// members are then found with a single forward walk of Seek().
+func (l List) Quantiles(qs ...float64) []uint64 {
+	result := make([]uint64, len(qs))
+	n := l.Len()
+	if n == 0 {
+		for i := range result {
+			result[i] = math.MaxUint64
+		}
+		return result
+	}
+
+	order := make([]int, len(qs))
+	pos := make([]uint64, len(qs))
+	for i, q := range qs {
+		order[i] = i
+		switch r := math.Ceil(q * float64(n)); {
+		case r >= float64(n):
+			pos[i] = n - 1
+		case r > 1:
+			pos[i] = uint64(r) - 1
+		}
+	}
+	sort.Slice(order, func(i, j int) bool { return pos[order[i]] < pos[order[j]] })
+
+	iter := l.Iterate()
+	for _, i := range order {
+		result[i], _ = iter.Seek(pos[i])
+	}
+	return result
+}
+
+// Coverage returns how many members of the list fall in each of the bins of
+// binWidth values dividing [lo, hi], inclusive. Bin i holds the values from
+// lo + i·binWidth, and the last bin is cut short at hi if binWidth does not
+// divide the range. Runs are clipped against the bin boundaries, and those
+// outside [lo, hi] are stepped over as by OverlappingRuns(), so no members are
+// expanded. Returns nil if binWidth is zero or lo > hi.
+func (l List) Coverage(binWidth, lo, hi uint64) []uint64 {
+	if binWidth == 0 || lo > hi {
+		return nil
+	}
+	bins := make([]uint64, (hi-lo)/binWidth+1)
+	runs := l.OverlappingRuns(lo, hi, true)
+	for first, last := runs.NextInterval(); first <= last; first, last = runs.NextInterval() {
+		i, j := (first-lo)/binWidth, (last-lo)/binWidth
+		if i == j {
+			bins[i] += last - first + 1
+			continue
+		}
+		bins[i] += (i+1)*binWidth - (first - lo)
+		for k := i + 1; k < j; k++ {
+			bins[k] = binWidth
+		}
+		bins[j] += (last - lo) - j*binWidth + 1
+	}
+	return bins
+}
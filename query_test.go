@@ -0,0 +1,595 @@
+package skiptake
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func Test_Contains(t *testing.T) {
+	list := makeRange(intrv{0, 2}, intrv{10, 10}, intrv{20, 29}, intrv{0xfffffffffffffffe, 0xffffffffffffffff})
+	members := list.Expand()
+	for _, v := range []uint64{0, 1, 2, 3, 9, 10, 11, 19, 20, 25, 29, 30, 0xfffffffffffffffd, 0xfffffffffffffffe, 0xffffffffffffffff} {
+		_, expected := searchUint64(members, v)
+		if list.Contains(v) != expected {
+			t.Errorf("Contains(%d) = %v", v, !expected)
+		}
+	}
+	if (List{}).Contains(0) {
+		t.Errorf("Empty list contains 0")
+	}
+}
+
+func Test_NearestRun(t *testing.T) {
+	list := makeRange(intrv{5, 7}, intrv{10, 10}, intrv{20, 29}, intrv{0xfffffffffffffffe, 0xffffffffffffffff})
+	cases := []struct {
+		v, first, last, distance uint64
+	}{
+		{0, 5, 7, 5},
+		{5, 5, 7, 0},
+		{8, 5, 7, 1},
+		{9, 10, 10, 1},
+		{10, 10, 10, 0},
+		{15, 10, 10, 5}, // A tie goes to the earlier run
+		{16, 20, 29, 4},
+		{25, 20, 29, 0},
+		{1000, 20, 29, 971},
+		{0xfffffffffffffff0, 0xfffffffffffffffe, 0xffffffffffffffff, 14},
+		{0xffffffffffffffff, 0xfffffffffffffffe, 0xffffffffffffffff, 0},
+	}
+	for _, c := range cases {
+		first, last, distance, ok := list.NearestRun(c.v)
+		if !ok || first != c.first || last != c.last || distance != c.distance {
+			t.Errorf("NearestRun(%d) = [%d - %d], %d, %t, expected [%d - %d], %d", c.v, first, last, distance, ok, c.first, c.last, c.distance)
+		}
+	}
+
+	first, last, distance, ok := makeRange(intrv{5, 7}).NearestRun(100)
+	if !ok || first != 5 || last != 7 || distance != 93 {
+		t.Errorf("NearestRun(100) = [%d - %d], %d, %t", first, last, distance, ok)
+	}
+	if _, _, _, ok := (List{}).NearestRun(0); ok {
+		t.Error("NearestRun() of empty list ok")
+	}
+}
+
+func Test_ContainsMany(t *testing.T) {
+	list := makeRange(intrv{0, 2}, intrv{10, 10}, intrv{20, 29}, intrv{0xfffffffffffffffe, 0xffffffffffffffff})
+	probes := []uint64{0, 2, 3, 10, 10, 15, 20, 29, 30, 0xffffffffffffffff, 1, 11}
+	result := list.ContainsMany(probes)
+	for i, v := range probes {
+		if result[i] != list.Contains(v) {
+			t.Errorf("ContainsMany() of %d = %v", v, result[i])
+		}
+	}
+}
+
+func Test_ContainsCursor(t *testing.T) {
+	var progression []uint64
+	for v := uint64(5); v < 7005; v += 7 {
+		progression = append(progression, v)
+	}
+	lists := []List{
+		makeRange(intrv{0, 2}, intrv{10, 10}, intrv{20, 29}, intrv{0xfffffffffffffffe, 0xffffffffffffffff}),
+		Create(progression...),
+		FromRaw(3, 2, 0, 4, 3, 0, 3, 0, 2, 2, 2, 2, 2, 2),
+		// Pairs and runs ending at math.MaxUint64.
+		Create(math.MaxUint64),
+		Create(math.MaxUint64-1, math.MaxUint64),
+		Create(1<<62-1, 1<<63-1, 3<<62-1, math.MaxUint64),
+	}
+	for i, list := range lists {
+		c := list.ContainsCursor()
+		probes := []uint64{0, 0, 1, 2, 3, 5, 9, 10, 12, 13, 15, 20, 29, 33, 40, 1000, 5000, 7001, 7005, 7006, 1<<62 - 1, 1 << 62, 3<<62 - 1, 0xfffffffffffffffe, 0xffffffffffffffff, 4, 6, 12, 7000, 0xffffffffffffffff}
+		for _, v := range probes {
+			if got := c.Contains(v); got != list.Contains(v) {
+				t.Errorf("List %d: ContainsCursor.Contains(%d) = %v", i, v, got)
+			}
+		}
+	}
+}
+
+func Test_Rank(t *testing.T) {
+	list := makeRange(intrv{5, 9}, intrv{20, 20}, intrv{30, 39})
+	probes := []uint64{0, 5, 7, 9, 10, 19, 20, 21, 30, 35, 39, 40, 1000, 6, 31}
+	expected := []uint64{0, 0, 2, 4, 5, 5, 5, 6, 6, 11, 15, 16, 16, 1, 7}
+	result := list.RankMany(probes)
+	for i, v := range probes {
+		if result[i] != expected[i] {
+			t.Errorf("RankMany() of %d = %d, expected %d", v, result[i], expected[i])
+		}
+		if r := list.Rank(v); r != expected[i] {
+			t.Errorf("Rank(%d) = %d, expected %d", v, r, expected[i])
+		}
+	}
+}
+
+func Test_ValuesAt(t *testing.T) {
+	list := makeRange(intrv{5, 9}, intrv{20, 20}, intrv{30, 39})
+	values := list.Expand()
+
+	for _, c := range [][2]uint64{{0, 3}, {3, 5}, {5, 1}, {6, 100}, {15, 1}, {16, 1}, {100, 5}, {2, 0}} {
+		offset, limit := c[0], c[1]
+		expected := []uint64{}
+		for i := offset; i < uint64(len(values)) && i < offset+limit; i++ {
+			expected = append(expected, values[i])
+		}
+
+		result := list.ValuesAt(offset, limit)
+		if !equalUint64(result, expected) {
+			t.Errorf("ValuesAt(%d, %d) = %v, expected %v", offset, limit, result, expected)
+		}
+
+		slice := list.Slice(offset, limit)
+		if !equalUint64(slice.Expand(), expected) {
+			t.Errorf("Slice(%d, %d) = %v, expected %v", offset, limit, slice, expected)
+		}
+	}
+}
+
+func Test_Sample(t *testing.T) {
+	list := makeRange(intrv{5, 9}, intrv{20, 20}, intrv{1000, 1999}, intrv{1 << 40, 1<<40 + 1<<20})
+	r := rand.New(rand.NewSource(1))
+
+	for _, k := range []int{0, 1, 10, 500} {
+		sample := list.Sample(k, r)
+		if len(sample) != k {
+			t.Errorf("Sample(%d) returned %d values", k, len(sample))
+		}
+		for i, v := range sample {
+			if i > 0 && v <= sample[i-1] {
+				t.Errorf("Sample(%d) not strictly ascending at %d: %v", k, i, sample)
+				break
+			}
+			if !list.Contains(v) {
+				t.Errorf("Sample(%d) returned non-member %d", k, v)
+			}
+		}
+	}
+
+	small := makeRange(intrv{5, 9}, intrv{20, 20})
+	if sample := small.Sample(10, r); !equalUint64(sample, small.Expand()) {
+		t.Errorf("Sample of small list = %v, expected all members", sample)
+	}
+
+	// Each member of a small list should be drawn about equally often
+	counts := map[uint64]int{}
+	for i := 0; i < 6000; i++ {
+		for _, v := range small.Sample(2, r) {
+			counts[v]++
+		}
+	}
+	for _, v := range small.Expand() {
+		if counts[v] < 1700 || counts[v] > 2300 {
+			t.Errorf("Member %d sampled %d times, expected about 2000", v, counts[v])
+		}
+	}
+}
+
+func Test_Quantile(t *testing.T) {
+	list := makeRange(intrv{1, 4}, intrv{10, 10}, intrv{20, 24})
+	// Members: 1 2 3 4 10 20 21 22 23 24
+	cases := map[float64]uint64{
+		-1:   1,
+		0:    1,
+		0.05: 1,
+		0.1:  1,
+		0.11: 2,
+		0.5:  10,
+		0.51: 20,
+		0.9:  23,
+		1:    24,
+		2:    24,
+	}
+	for q, expected := range cases {
+		if result := list.Quantile(q); result != expected {
+			t.Errorf("Quantile(%v) = %d, expected %d", q, result, expected)
+		}
+	}
+
+	qs := []float64{1, 0.5, 0, 0.9}
+	result := list.Quantiles(qs...)
+	if !equalUint64(result, []uint64{24, 10, 1, 23}) {
+		t.Errorf("Quantiles(%v) = %v", qs, result)
+	}
+
+	expectUint64(t, List{}.Quantile(0.5), math.MaxUint64)
+}
+
+func Test_Bounds(t *testing.T) {
+	cases := []struct {
+		list        List
+		first, last uint64
+		ok          bool
+	}{
+		{List{}, 0, 0, false},
+		{Create(0), 0, 0, true},
+		{makeRange(intrv{5, 9}, intrv{20, 29}), 5, 29, true},
+		{makeRange(intrv{0xfffffffffffffff0, 0xffffffffffffffff}), 0xfffffffffffffff0, 0xffffffffffffffff, true},
+		{FromRaw(3, 0, 4, 2, 5, 0), 7, 8, true},
+		{UnionOf(&Progression{Base: 7, Stride: 10, N: 1000}), 7, 9997, true},
+	}
+	for _, c := range cases {
+		first, last, ok := c.list.Bounds()
+		if first != c.first || last != c.last || ok != c.ok {
+			t.Errorf("Bounds(%v) = %d, %d, %v, expected %d, %d, %v", c.list, first, last, ok, c.first, c.last, c.ok)
+		}
+		if first, ok := c.list.First(); first != c.first || ok != c.ok {
+			t.Errorf("First(%v) = %d, %v, expected %d, %v", c.list, first, ok, c.first, c.ok)
+		}
+		if last, ok := c.list.Last(); last != c.last || ok != c.ok {
+			t.Errorf("Last(%v) = %d, %v, expected %d, %v", c.list, last, ok, c.last, c.ok)
+		}
+	}
+}
+
+func Test_CountRunsAtLeast(t *testing.T) {
+	list := makeRange(intrv{0, 0}, intrv{2, 4}, intrv{10, 19}, intrv{30, 31}, intrv{40, 40})
+	for k, expected := range map[uint64]uint64{0: 5, 1: 5, 2: 3, 3: 2, 4: 1, 10: 1, 11: 0} {
+		if n := list.CountRunsAtLeast(k); n != expected {
+			t.Errorf("CountRunsAtLeast(%d) = %d, expected %d", k, n, expected)
+		}
+	}
+	// Runs split by zero skips are counted whole.
+	expectUint64(t, FromRaw(0, 2, 0, 2, 3, 1).CountRunsAtLeast(4), 1)
+	expectUint64(t, List{}.CountRunsAtLeast(1), 0)
+}
+
+func Test_LongestRunLargestGap(t *testing.T) {
+	cases := []struct {
+		list List
+		run  [3]uint64
+		gap  [3]uint64
+	}{
+		{List{}, [3]uint64{0, 0, 0}, [3]uint64{0, 0, 0}},
+		{Create(5), [3]uint64{5, 5, 1}, [3]uint64{0, 0, 0}},
+		{makeRange(intrv{0, 0}, intrv{2, 4}, intrv{10, 19}, intrv{30, 39}, intrv{100, 100}), [3]uint64{10, 19, 10}, [3]uint64{40, 99, 60}},
+		{makeRange(intrv{3, 5}, intrv{9, 11}, intrv{15, 17}), [3]uint64{3, 5, 3}, [3]uint64{6, 8, 3}},
+		{FromRaw(0, 2, 0, 2, 3, 1), [3]uint64{0, 3, 4}, [3]uint64{4, 6, 3}},
+		{makeRange(intrv{0, 0}, intrv{math.MaxUint64, math.MaxUint64}), [3]uint64{0, 0, 1}, [3]uint64{1, math.MaxUint64 - 1, math.MaxUint64 - 1}},
+	}
+	for _, c := range cases {
+		first, last, n := c.list.LongestRun()
+		if [3]uint64{first, last, n} != c.run {
+			t.Errorf("LongestRun(%v) = %d, %d, %d, expected %v", c.list, first, last, n, c.run)
+		}
+		first, last, n = c.list.LargestGap()
+		if [3]uint64{first, last, n} != c.gap {
+			t.Errorf("LargestGap(%v) = %d, %d, %d, expected %v", c.list, first, last, n, c.gap)
+		}
+	}
+}
+
+func Test_IsEmpty(t *testing.T) {
+	cases := map[string]struct {
+		list     List
+		expected bool
+	}{
+		"nil":        {nil, true},
+		"empty":      {List{}, true},
+		"zero takes": {FromRaw(3, 0, 4, 0), true},
+		"zero pair":  {FromRaw(0, 0), true},
+		"zero":       {Create(0), false},
+		"late":       {FromRaw(3, 0, 4, 0, 1, 1), false},
+	}
+	for name, c := range cases {
+		if result := c.list.IsEmpty(); result != c.expected {
+			t.Errorf("%s: IsEmpty(%v) = %v, expected %v", name, []byte(c.list), result, c.expected)
+		}
+	}
+
+	// 2^40 repeated zero take pairs are stepped over in one step.
+	var l List
+	e := l.Encode()
+	e.Add(1, 0)
+	e.addRepeats(1<<40 - 1)
+	if !l.IsEmpty() {
+		t.Errorf("IsEmpty(%v) = false, expected true", []byte(l))
+	}
+	e.Add(3, 1)
+	if first, ok := l.First(); !ok || first != 3+1<<40 {
+		t.Errorf("First(%v) = %d, %v, expected %d", []byte(l), first, ok, uint64(3+1<<40))
+	}
+}
+
+func Test_NumIntervals(t *testing.T) {
+	expectUint64(t, List{}.NumIntervals(), 0)
+	expectUint64(t, makeRange(intrv{0, 9}, intrv{20, 29}, intrv{31, 31}).NumIntervals(), 3)
+	expectUint64(t, FromRaw(0, 5, 0, 0, 2, 2).NumIntervals(), 2)
+}
+
+func Test_Coverage(t *testing.T) {
+	list := makeRange(intrv{0, 2}, intrv{8, 25}, intrv{31, 31}, intrv{39, 45})
+	cases := []struct {
+		width, lo, hi uint64
+		expected      []uint64
+	}{
+		{10, 0, 49, []uint64{5, 10, 6, 2, 6}},
+		{10, 0, 41, []uint64{5, 10, 6, 2, 2}},
+		{7, 5, 30, []uint64{4, 7, 7, 0}},
+		{100, 0, 49, []uint64{29}},
+		{1, 30, 33, []uint64{0, 1, 0, 0}},
+		{10, 46, 100, []uint64{0, 0, 0, 0, 0, 0}},
+	}
+	for _, c := range cases {
+		result := list.Coverage(c.width, c.lo, c.hi)
+		if !equalUint64(result, c.expected) {
+			t.Errorf("Coverage(%d, %d, %d) = %v, expected %v", c.width, c.lo, c.hi, result, c.expected)
+		}
+	}
+
+	// Against counting expanded members
+	r := rand.New(rand.NewSource(1))
+	var values []uint64
+	for v := uint64(r.Intn(5)); v < 5000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(7)))) {
+		values = append(values, v)
+	}
+	list = Create(values...)
+	for i := 0; i < 100; i++ {
+		width := 1 + uint64(r.Intn(200))
+		lo := uint64(r.Intn(5000))
+		hi := lo + uint64(r.Intn(1000))
+		expected := make([]uint64, (hi-lo)/width+1)
+		for _, v := range values {
+			if v >= lo && v <= hi {
+				expected[(v-lo)/width]++
+			}
+		}
+		if result := list.Coverage(width, lo, hi); !equalUint64(result, expected) {
+			t.Fatalf("Coverage(%d, %d, %d) = %v, expected %v", width, lo, hi, result, expected)
+		}
+	}
+
+	if bins := FromRaw(0, math.MaxUint64).Coverage(1<<62, 0, math.MaxUint64); !equalUint64(bins, []uint64{1 << 62, 1 << 62, 1 << 62, 1<<62 - 1}) {
+		t.Errorf("Coverage() of whole range = %v", bins)
+	}
+	if list.Coverage(0, 0, 10) != nil || list.Coverage(1, 10, 0) != nil {
+		t.Error("Coverage() of no bins not nil")
+	}
+}
+
+func Test_Downsample(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{20, 22}, intrv{30, 30}, intrv{40, 45})
+	cases := []struct {
+		n        uint64
+		expected []uint64
+	}{
+		{0, list.Expand()},
+		{1, list.Expand()},
+		{3, []uint64{0, 3, 6, 9, 22, 41, 44}},
+		{4, []uint64{0, 4, 8, 22, 42}},
+		{100, []uint64{0}},
+	}
+	for _, c := range cases {
+		result := list.Downsample(c.n)
+		if !equalUint64(result.Expand(), c.expected) {
+			t.Errorf("Downsample(%d) = %v, expected %v", c.n, result.Expand(), c.expected)
+		}
+	}
+
+	// Against the expanded sequence
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var values []uint64
+		for v := uint64(r.Intn(5)); v < 5000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(7)))) {
+			values = append(values, v)
+		}
+		n := 1 + uint64(r.Intn(20))
+		var expected []uint64
+		for k := uint64(0); k < uint64(len(values)); k += n {
+			expected = append(expected, values[k])
+		}
+		result := Create(values...).Downsample(n)
+		if !equalUint64(result.Expand(), expected) {
+			t.Fatalf("Downsample(%d) = %v, expected %v", n, result.Expand(), expected)
+		}
+		if string(result) != string(Create(expected...)) {
+			t.Fatalf("Downsample(%d) encoded as %x, expected %x", n, []byte(result), []byte(Create(expected...)))
+		}
+	}
+
+	// A huge interval is encoded as a repeat run, not value by value
+	huge := FromRaw(5, 1<<40).Downsample(3)
+	if huge.Len() != (1<<40+2)/3 || len(huge) > 16 {
+		t.Errorf("Downsample() of huge interval = %d members in %d bytes", huge.Len(), len(huge))
+	}
+	if last, _ := huge.Last(); last != 5+((1<<40-1)/3)*3 {
+		t.Errorf("Downsample() last = %d", last)
+	}
+}
+
+func Test_ThinByDistance(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{11, 12}, intrv{30, 30}, intrv{33, 45})
+	cases := []struct {
+		d        uint64
+		expected []uint64
+	}{
+		{0, list.Expand()},
+		{1, list.Expand()},
+		{3, []uint64{0, 3, 6, 9, 12, 30, 33, 36, 39, 42, 45}},
+		{4, []uint64{0, 4, 8, 12, 30, 34, 38, 42}},
+		{20, []uint64{0, 30}},
+		{math.MaxUint64, []uint64{0}},
+	}
+	for _, c := range cases {
+		result := list.ThinByDistance(c.d)
+		if !equalUint64(result.Expand(), c.expected) {
+			t.Errorf("ThinByDistance(%d) = %v, expected %v", c.d, result.Expand(), c.expected)
+		}
+	}
+
+	// Against greedy selection from the expanded sequence
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var values []uint64
+		for v := uint64(r.Intn(5)); v < 5000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(7)))) {
+			values = append(values, v)
+		}
+		d := 1 + uint64(r.Intn(30))
+		expected := []uint64{values[0]}
+		for _, v := range values[1:] {
+			if v >= expected[len(expected)-1]+d {
+				expected = append(expected, v)
+			}
+		}
+		result := Create(values...).ThinByDistance(d)
+		if !equalUint64(result.Expand(), expected) {
+			t.Fatalf("ThinByDistance(%d) = %v, expected %v", d, result.Expand(), expected)
+		}
+	}
+
+	// Near the top of the range
+	top := FromRaw(math.MaxUint64-10, 11).ThinByDistance(4)
+	if !equalUint64(top.Expand(), []uint64{math.MaxUint64 - 10, math.MaxUint64 - 6, math.MaxUint64 - 2}) {
+		t.Errorf("ThinByDistance() = %v", top.Expand())
+	}
+}
+
+func Test_FilterModulo(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{11, 12}, intrv{30, 30}, intrv{33, 45})
+	cases := []struct {
+		m, r     uint64
+		expected []uint64
+	}{
+		{1, 0, list.Expand()},
+		{2, 0, []uint64{0, 2, 4, 6, 8, 12, 30, 34, 36, 38, 40, 42, 44}},
+		{5, 3, []uint64{3, 8, 33, 38, 43}},
+		{5, 8, []uint64{3, 8, 33, 38, 43}},
+		{100, 30, []uint64{30}},
+		{0, 11, []uint64{11}},
+		{0, 10, nil},
+		{math.MaxUint64, 30, []uint64{30}},
+		{math.MaxUint64, 5, []uint64{5}},
+		{math.MaxUint64, 10, nil},
+	}
+	for _, c := range cases {
+		result := list.FilterModulo(c.m, c.r)
+		if !equalUint64(result.Expand(), c.expected) {
+			t.Errorf("FilterModulo(%d, %d) = %v, expected %v", c.m, c.r, result.Expand(), c.expected)
+		}
+	}
+
+	// Moduli near math.MaxUint64, over a list reaching it.
+	high := makeRange(intrv{3, 4}, intrv{math.MaxUint64 - 3, math.MaxUint64})
+	for _, c := range []struct {
+		m, r     uint64
+		expected []uint64
+	}{
+		{math.MaxUint64, 0, []uint64{math.MaxUint64}},
+		{math.MaxUint64, 4, []uint64{4}},
+		{math.MaxUint64, math.MaxUint64 - 2, []uint64{math.MaxUint64 - 2}},
+		{math.MaxUint64 - 1, 1, []uint64{math.MaxUint64}},
+		{math.MaxUint64 - 1, 3, []uint64{3}},
+	} {
+		if result := high.FilterModulo(c.m, c.r); !equalUint64(result.Expand(), c.expected) {
+			t.Errorf("FilterModulo(%d, %d) = %v, expected %v", c.m, c.r, result.Expand(), c.expected)
+		}
+	}
+
+	// The shares of m workers partition the list
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		var values []uint64
+		for v := uint64(r.Intn(5)); v < 5000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(7)))) {
+			values = append(values, v)
+		}
+		list := Create(values...)
+		m := 1 + uint64(r.Intn(12))
+		shares := make([]List, m)
+		for k := range shares {
+			shares[k] = list.FilterModulo(m, uint64(k))
+			for _, v := range shares[k].Expand() {
+				if v%m != uint64(k) {
+					t.Fatalf("FilterModulo(%d, %d) holds %d", m, k, v)
+				}
+			}
+		}
+		if !equalUint64(Union(shares...).Expand(), values) {
+			t.Fatalf("Union of FilterModulo(%d) shares != list", m)
+		}
+	}
+}
+
+func Test_PartitionByModulo(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		var values []uint64
+		for v := uint64(r.Intn(5)); v < 5000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(7)))) {
+			values = append(values, v)
+		}
+		list := Create(values...)
+		m := 1 + uint64(r.Intn(12))
+		parts := list.PartitionByModulo(m)
+		if uint64(len(parts)) != m {
+			t.Fatalf("PartitionByModulo(%d) returned %d lists", m, len(parts))
+		}
+		for k, part := range parts {
+			if expected := list.FilterModulo(m, uint64(k)); string(part) != string(expected) {
+				t.Fatalf("PartitionByModulo(%d)[%d] = %v, expected %v", m, k, part.Expand(), expected.Expand())
+			}
+		}
+	}
+
+	parts := FromRaw(0, 1<<40).PartitionByModulo(3)
+	for k, part := range parts {
+		if n := part.Len(); n != (1<<40-uint64(k)+2)/3 {
+			t.Errorf("PartitionByModulo(3)[%d].Len() = %d", k, n)
+		}
+	}
+	if (List{}).PartitionByModulo(0) != nil {
+		t.Error("PartitionByModulo(0) not nil")
+	}
+}
+
+func Test_SplitParts(t *testing.T) {
+	list := makeRange(intrv{0, 9}, intrv{20, 22}, intrv{30, 30}, intrv{40, 45})
+	parts := list.SplitParts(3)
+	expected := [][]uint64{
+		{0, 1, 2, 3, 4, 5, 6},
+		{7, 8, 9, 20, 21, 22, 30},
+		{40, 41, 42, 43, 44, 45},
+	}
+	for k := range expected {
+		if !equalUint64(parts[k].Expand(), expected[k]) {
+			t.Errorf("SplitParts(3)[%d] = %v, expected %v", k, parts[k].Expand(), expected[k])
+		}
+	}
+
+	// More parts than members
+	parts = makeRange(intrv{5, 6}).SplitParts(4)
+	if len(parts) != 4 || !equalUint64(parts[0].Expand(), []uint64{5}) || !equalUint64(parts[1].Expand(), []uint64{6}) ||
+		!parts[2].IsEmpty() || !parts[3].IsEmpty() {
+		t.Errorf("SplitParts(4) = %v", parts)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		var values []uint64
+		for v := uint64(r.Intn(5)); v < 5000; v += 1 + uint64(r.Intn(1<<uint(r.Intn(7)))) {
+			values = append(values, v)
+		}
+		list := Create(values...)
+		p := 1 + r.Intn(20)
+		parts := list.SplitParts(p)
+		var joined []uint64
+		for k, part := range parts {
+			n := part.Len()
+			if n != uint64(len(values)/p) && n != uint64(len(values)/p+1) {
+				t.Fatalf("SplitParts(%d)[%d].Len() = %d of %d", p, k, n, len(values))
+			}
+			joined = append(joined, part.Expand()...)
+		}
+		if !equalUint64(joined, values) {
+			t.Fatalf("SplitParts(%d) do not join to the list", p)
+		}
+	}
+
+	if (List{}).SplitParts(0) != nil {
+		t.Error("SplitParts(0) not nil")
+	}
+	for _, part := range (List{}).SplitParts(2) {
+		if !part.IsEmpty() {
+			t.Error("SplitParts() of empty list not empty")
+		}
+	}
+}
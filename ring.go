@@ -0,0 +1,115 @@
+package skiptake
+
+// Ring is a circular domain of the values [0, N), such as the positions of a
+// hash ring, in which a range of values may wrap around from N-1 to 0. An N of
+// zero is the whole range of uint64, which wraps at math.MaxUint64.
+//
+// The set operations of a Ring first reduce the members of their operands
+// modulo N, and return lists of values within [0, N). A wrapped range is held
+// as two runs, one starting at 0, and one ending at N-1. Eg, to find which of
+// the ownership ranges of a node are also claimed by another:
+//
+//		ring := skiptake.Ring{N: 1 << 32}
+//		a := ring.Range(0xf0000000, 0x0fffffff) // Wraps through zero
+//		b := ring.Range(0x08000000, 0x17ffffff)
+//		for _, r := range ring.Ranges(ring.Intersection(a, b)) {
+//			...
+//		}
+//
+type Ring struct {
+	N uint64
+}
+
+// max returns the greatest value of the ring.
+func (r Ring) max() uint64 {
+	return r.N - 1
+}
+
+// mod reduces v modulo N.
+func (r Ring) mod(v uint64) uint64 {
+	if r.N == 0 {
+		return v
+	}
+	return v % r.N
+}
+
+// Range returns a list of the values from start to end inclusive, reduced
+// modulo N. If start is greater than end, the range wraps around from N-1 to
+// 0.
+func (r Ring) Range(start, end uint64) List {
+	start, end = r.mod(start), r.mod(end)
+	b := Build(&List{})
+	if start <= end {
+		b.interval(start, end)
+	} else {
+		b.interval(0, end)
+		b.interval(start, r.max())
+	}
+	return b.Finish()
+}
+
+// Union returns the union of the passed lists, with their members reduced
+// modulo N.
+func (r Ring) Union(lists ...List) List {
+	return Union(r.foldAll(lists)...)
+}
+
+// Intersection returns the intersection of the passed lists, with their
+// members reduced modulo N.
+func (r Ring) Intersection(lists ...List) List {
+	return Intersection(r.foldAll(lists)...)
+}
+
+// Complement returns the values of the ring which are not members of l,
+// reduced modulo N.
+func (r Ring) Complement(l List) List {
+	return ComplementRange(r.fold(l), 0, r.max())
+}
+
+// Ranges returns the runs of consecutive members of l, reduced modulo N, as
+// inclusive [first, last] ranges in ascending order of last. A run through
+// N-1 and 0 is returned as one range with first greater than last. A list
+// holding every value of the ring is returned as the single range [0, N-1].
+func (r Ring) Ranges(l List) [][2]uint64 {
+	var ranges [][2]uint64
+	iter := r.fold(l).Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		ranges = append(ranges, [2]uint64{first, last})
+	}
+	if k := len(ranges) - 1; k > 0 && ranges[0][0] == 0 && ranges[k][1] == r.max() {
+		ranges[0][0] = ranges[k][0]
+		ranges = ranges[:k]
+	}
+	return ranges
+}
+
+// foldAll returns each of lists reduced modulo N.
+func (r Ring) foldAll(lists []List) []List {
+	folded := make([]List, len(lists))
+	for i, l := range lists {
+		folded[i] = r.fold(l)
+	}
+	return folded
+}
+
+// fold returns l with its members reduced modulo N. Returns l itself if all
+// its members are already within the ring.
+func (r Ring) fold(l List) List {
+	if _, last, ok := l.Bounds(); !ok || r.N == 0 || last < r.N {
+		return l
+	}
+	var pieces [][2]uint64
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if last-first >= r.max() {
+			return r.Range(0, r.max())
+		}
+		first, last = r.mod(first), r.mod(last)
+		if first <= last {
+			pieces = append(pieces, [2]uint64{first, last})
+		} else {
+			pieces = append(pieces, [2]uint64{0, last}, [2]uint64{first, r.max()})
+		}
+	}
+	return UnionIntervals(pieces)
+}
@@ -0,0 +1,78 @@
+package skiptake
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func equalRanges(a, b [][2]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_RingRange(t *testing.T) {
+	ring := Ring{N: 100}
+	cases := []struct {
+		start, end uint64
+		expected   List
+	}{
+		{10, 20, makeRange(intrv{10, 20})},
+		{90, 9, makeRange(intrv{0, 9}, intrv{90, 99})},
+		{0, 99, makeRange(intrv{0, 99})},
+		{99, 0, makeRange(intrv{0, 0}, intrv{99, 99})},
+		{150, 190, makeRange(intrv{50, 90})},
+		{150, 210, makeRange(intrv{0, 10}, intrv{50, 99})},
+	}
+	for _, c := range cases {
+		if got := ring.Range(c.start, c.end); !bytes.Equal(got, c.expected) {
+			t.Errorf("Range(%d, %d) = %v, expected %v", c.start, c.end, got.Expand(), c.expected.Expand())
+		}
+	}
+
+	full := Ring{}.Range(math.MaxUint64-1, 1)
+	if !bytes.Equal(full, makeRange(intrv{0, 1}, intrv{math.MaxUint64 - 1, math.MaxUint64})) {
+		t.Errorf("Range() of uint64 ring = %v", full)
+	}
+}
+
+func Test_RingSetOperations(t *testing.T) {
+	ring := Ring{N: 100}
+	a := ring.Range(80, 19)
+	b := ring.Range(10, 89)
+
+	if got := ring.Ranges(ring.Intersection(a, b)); !equalRanges(got, [][2]uint64{{10, 19}, {80, 89}}) {
+		t.Errorf("Intersection() = %v", got)
+	}
+	if got := ring.Ranges(ring.Union(ring.Range(90, 5), ring.Range(50, 60))); !equalRanges(got, [][2]uint64{{90, 5}, {50, 60}}) {
+		t.Errorf("Union() = %v", got)
+	}
+	if got := ring.Ranges(ring.Union(a, b)); !equalRanges(got, [][2]uint64{{0, 99}}) {
+		t.Errorf("Union() of whole ring = %v", got)
+	}
+	if got := ring.Ranges(ring.Complement(a)); !equalRanges(got, [][2]uint64{{20, 79}}) {
+		t.Errorf("Complement() = %v", got)
+	}
+	if got := ring.Ranges(ring.Complement(ring.Range(20, 79))); !equalRanges(got, [][2]uint64{{80, 19}}) {
+		t.Errorf("Complement() wrapping = %v", got)
+	}
+
+	// Members beyond the ring are reduced modulo N.
+	folded := makeRange(intrv{5, 5}, intrv{195, 210}, intrv{330, 340})
+	if got := ring.Ranges(ring.Union(folded)); !equalRanges(got, [][2]uint64{{95, 10}, {30, 40}}) {
+		t.Errorf("Union() of folded list = %v", got)
+	}
+	if got := ring.Ranges(ring.Complement(makeRange(intrv{1000, 1099}))); len(got) != 0 {
+		t.Errorf("Complement() of whole ring = %v", got)
+	}
+	if got := ring.Ranges(List{}); len(got) != 0 {
+		t.Errorf("Ranges() of empty list = %v", got)
+	}
+}
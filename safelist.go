@@ -0,0 +1,84 @@
+package skiptake
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SafeList is a List which is safe for concurrent use. Readers never block:
+// each read sees a complete version of the list, and changes swap in a new
+// version atomically. Changes are serialized with respect to each other.
+//
+// The zero value of SafeList holds an empty list.
+type SafeList struct {
+	mu sync.Mutex   // Held while changing the list
+	v  atomic.Value // Holds the current List
+}
+
+// NewSafeList returns a SafeList holding l. l must not be modified after.
+func NewSafeList(l List) *SafeList {
+	s := &SafeList{}
+	s.v.Store(l)
+	return s
+}
+
+// Load returns the current version of the list. The returned list must not be
+// modified, and is unaffected by later changes to the SafeList.
+func (s *SafeList) Load() List {
+	l, _ := s.v.Load().(List)
+	return l
+}
+
+// Len returns how many values are in the expanded sequence.
+func (s *SafeList) Len() uint64 {
+	return s.Load().Len()
+}
+
+// Iterate returns a new skiptake.Iterator for the current version of the list.
+func (s *SafeList) Iterate() Iterator {
+	return s.Load().Iterate()
+}
+
+// Expand expands the current version of the list. See List.Expand().
+func (s *SafeList) Expand() []uint64 {
+	return s.Load().Expand()
+}
+
+// String implements the fmt.Stringer interface.
+func (s *SafeList) String() string {
+	return s.Load().String()
+}
+
+// Replace atomically replaces the list with l, returning the previous list. l
+// must not be modified after.
+func (s *SafeList) Replace(l List) List {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.Load()
+	s.v.Store(l)
+	return old
+}
+
+// Update atomically replaces the list with the result of fn, which is passed
+// the current list. fn must return a new list rather than modify the one passed
+// to it. Other changes wait until fn returns, but reads do not.
+func (s *SafeList) Update(fn func(List) List) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v.Store(fn(s.Load()))
+}
+
+// UnionWith atomically replaces the list with its union with the passed lists.
+func (s *SafeList) UnionWith(lists ...List) {
+	s.Update(func(l List) List {
+		return Union(append([]List{l}, lists...)...)
+	})
+}
+
+// IntersectWith atomically replaces the list with its intersection with the
+// passed lists.
+func (s *SafeList) IntersectWith(lists ...List) {
+	s.Update(func(l List) List {
+		return Intersection(append([]List{l}, lists...)...)
+	})
+}
@@ -0,0 +1,40 @@
+package skiptake
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_SafeList(t *testing.T) {
+	var s SafeList
+	expectUint64(t, s.Len(), 0)
+
+	old := s.Replace(Create(1, 2, 3))
+	if len(old) != 0 {
+		t.Errorf("Replace() of zero value returned %v", old)
+	}
+
+	var wg sync.WaitGroup
+	for i := uint64(0); i < 50; i++ {
+		wg.Add(2)
+		go func(i uint64) {
+			defer wg.Done()
+			s.UnionWith(Create(100 + i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			// Every read sees a complete list
+			if err := s.Load().Validate(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	expectUint64(t, s.Len(), 53)
+
+	s.IntersectWith(makeRange(intrv{2, 120}))
+	expected := makeRange(intrv{2, 3}, intrv{100, 120})
+	if !Equal(s.Load(), expected) {
+		t.Errorf("%v != %v", s.Load(), expected)
+	}
+}
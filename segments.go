@@ -0,0 +1,154 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// SegmentInfo describes one List of a Segments container.
+type SegmentInfo struct {
+	First uint64 // The least value held by the segment
+	Last  uint64 // The greatest value held by the segment
+	Len   uint64 // How many values are held by the segment
+}
+
+// Segments chains multiple Lists, each holding a sub-range of a sequence too
+// large to hold in a single allocation, and presents them as one sequence.
+//
+// Segments hold absolute values, are in ascending order, and must not overlap.
+// They are loaded on demand by a callback, and only one segment is referenced
+// at a time while iterating.
+type Segments struct {
+	info []SegmentInfo
+	load func(i int) (List, error)
+}
+
+// NewSegments returns a Segments container for segments described by info.
+// The callback load is called to fetch the i'th segment when it is needed.
+func NewSegments(info []SegmentInfo, load func(i int) (List, error)) *Segments {
+	return &Segments{info: info, load: load}
+}
+
+// NumSegments returns the number of segments.
+func (s *Segments) NumSegments() int {
+	return len(s.info)
+}
+
+// Segment returns the description of the i'th segment.
+func (s *Segments) Segment(i int) SegmentInfo {
+	return s.info[i]
+}
+
+// Len returns how many values are in the whole expanded sequence. No segments
+// are loaded.
+func (s *Segments) Len() uint64 {
+	var ret uint64
+	for _, info := range s.info {
+		ret += info.Len
+	}
+	return ret
+}
+
+// Iterate returns a new iterator over the whole sequence.
+func (s *Segments) Iterate() *SegmentsIterator {
+	return &SegmentsIterator{s: s}
+}
+
+// SegmentsIterator iterates over the sequence held by a Segments container,
+// loading each segment as it is reached. Intervals which continue from one
+// segment into the next are joined, so SegmentsIterator can be used as an
+// input to set operations.
+//
+// If loading a segment fails, iteration stops as if at end-of-sequence, and
+// the error is returned by Err().
+type SegmentsIterator struct {
+	s      *Segments
+	i      int      // Index of the next segment to load
+	cur    Iterator // Iterator of the current segment
+	primed bool
+	first  uint64 // Next interval, read ahead to join intervals
+	last   uint64
+	take   uint64 // Remaining values in the interval being returned by Next()
+	n      uint64 // Next value to be returned by Next()
+	eos    bool
+	err    error
+}
+
+// NextInterval fetches the next interval range in the expanded sequence, as
+// Iterator.NextInterval(). Returns (math.MaxUint64, 0) in the case of end of
+// stream.
+func (t *SegmentsIterator) NextInterval() (first uint64, last uint64) {
+	t.take = 0
+	if !t.primed {
+		t.first, t.last = t.segmentInterval()
+		t.primed = true
+	}
+	first, last = t.first, t.last
+	if first > last {
+		t.eos = true
+		return math.MaxUint64, 0
+	}
+	for {
+		t.first, t.last = t.segmentInterval()
+		if t.first > t.last || last == math.MaxUint64 || t.first != last+1 {
+			break
+		}
+		last = t.last
+	}
+	return first, last
+}
+
+// Next returns the next value in the sequence. Returns math.MaxUint64 at
+// end-of-sequence, although this is a legitimate sequence value. Use EOS() to
+// differentiate in this case.
+func (t *SegmentsIterator) Next() uint64 {
+	if t.take == 0 {
+		first, last := t.NextInterval()
+		if first > last {
+			return math.MaxUint64
+		}
+		t.n = first
+		t.take = last - first + 1
+		if t.take == 0 {
+			// The interval is the entire range of uint64.
+			t.take = math.MaxUint64
+		}
+	}
+	t.take--
+	t.n++
+	return t.n - 1
+}
+
+// EOS returns true once a call to Next() or NextInterval() has reached the
+// end-of-sequence.
+func (t *SegmentsIterator) EOS() bool {
+	return t.eos
+}
+
+// Err returns the error, if any, that occurred loading a segment.
+func (t *SegmentsIterator) Err() error {
+	return t.err
+}
+
+// segmentInterval returns the next interval from the segments, loading the
+// next segment whenever the current one is exhausted.
+func (t *SegmentsIterator) segmentInterval() (first, last uint64) {
+	for t.err == nil {
+		if t.cur.Decoder != nil {
+			if first, last = t.cur.NextInterval(); first <= last {
+				return
+			}
+			t.cur = Iterator{}
+		}
+		if t.i >= len(t.s.info) {
+			break
+		}
+		l, err := t.s.load(t.i)
+		if err != nil {
+			t.err = err
+			break
+		}
+		t.i++
+		t.cur = l.Iterate()
+	}
+	return math.MaxUint64, 0
+}
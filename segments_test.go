@@ -0,0 +1,83 @@
+package skiptake
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Segments(t *testing.T) {
+	lists := []List{
+		makeRange(intrv{0, 4}, intrv{10, 19}),
+		makeRange(intrv{20, 24}, intrv{40, 40}),
+		List{},
+		makeRange(intrv{41, 50}, intrv{60, 61}),
+	}
+	info := make([]SegmentInfo, len(lists))
+	for i, l := range lists {
+		e := l.Expand()
+		if len(e) > 0 {
+			info[i] = SegmentInfo{First: e[0], Last: e[len(e)-1], Len: uint64(len(e))}
+		}
+	}
+	loads := 0
+	s := NewSegments(info, func(i int) (List, error) {
+		loads++
+		return lists[i], nil
+	})
+
+	expectUint64(t, s.Len(), Union(lists...).Len())
+	if loads != 0 {
+		t.Errorf("Len() loaded %d segments", loads)
+	}
+
+	// Intervals continuing into the next segment are joined.
+	expected := [][2]uint64{{0, 4}, {10, 24}, {40, 50}, {60, 61}}
+	iter := s.Iterate()
+	for _, e := range expected {
+		first, last := iter.NextInterval()
+		if first != e[0] || last != e[1] {
+			t.Errorf("[%d - %d] != %v", first, last, e)
+		}
+	}
+	if first, last := iter.NextInterval(); first <= last || !iter.EOS() {
+		t.Errorf("Expected end-of-sequence, got [%d - %d]", first, last)
+	}
+	if loads != len(lists) {
+		t.Errorf("Loaded %d segments, expected %d", loads, len(lists))
+	}
+
+	values := []uint64{}
+	iter = s.Iterate()
+	for n := iter.Next(); !iter.EOS(); n = iter.Next() {
+		values = append(values, n)
+	}
+	if expected := Union(lists...).Expand(); !equalUint64(values, expected) {
+		t.Errorf("%v != %v", values, expected)
+	}
+
+	other := Create(5, 6).Iterate()
+	union := UnionOf(s.Iterate(), &other)
+	if expected := Union(append(lists, Create(5, 6))...); !Equal(union, expected) {
+		t.Errorf("%v != %v", union, expected)
+	}
+}
+
+func Test_SegmentsError(t *testing.T) {
+	failure := errors.New("failure")
+	s := NewSegments(make([]SegmentInfo, 2), func(i int) (List, error) {
+		if i == 1 {
+			return nil, failure
+		}
+		return Create(1, 2), nil
+	})
+	iter := s.Iterate()
+	first, last := iter.NextInterval()
+	expectUint64(t, first, 1)
+	expectUint64(t, last, 2)
+	if first, last = iter.NextInterval(); first <= last {
+		t.Errorf("Expected end-of-sequence")
+	}
+	if iter.Err() != failure {
+		t.Errorf("Err() = %v", iter.Err())
+	}
+}
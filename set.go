@@ -0,0 +1,292 @@
+package skiptake
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultMaxPending is the number of pending changes a Set holds before
+// compacting, unless Set.MaxPending is set.
+const DefaultMaxPending = 4096
+
+// Set is a mutable set of values, supporting the addition and removal of
+// individual values without rebuilding a List for each change.
+//
+// A Set holds a base List, and small sorted buffers of pending additions and
+// removals. Queries answer against the combination of the three. When the
+// number of pending changes exceeds MaxPending, they are merged into a new
+// base List in a single pass.
+//
+// A Set is not safe for concurrent use.
+type Set struct {
+	// MaxPending is the number of pending changes held before compacting.
+	// Zero means DefaultMaxPending.
+	MaxPending int
+
+	base    List
+	adds    []uint64 // Sorted values, not members of base
+	removes []uint64 // Sorted values, members of base
+
+	// The storage of base is reused once replaced, if it was allocated by the
+	// set and never returned by List().
+	owned bool
+	spare List
+}
+
+// NewSet returns a Set holding the values of base. base must not be modified
+// after.
+func NewSet(base List) *Set {
+	return &Set{base: base}
+}
+
+// Add adds v to the set.
+func (s *Set) Add(v uint64) {
+	if i, ok := searchUint64(s.removes, v); ok {
+		s.removes = append(s.removes[:i], s.removes[i+1:]...)
+		return
+	}
+	if i, ok := searchUint64(s.adds, v); !ok && !s.base.Contains(v) {
+		s.adds = insertUint64(s.adds, i, v)
+		s.maybeCompact()
+	}
+}
+
+// Remove removes v from the set.
+func (s *Set) Remove(v uint64) {
+	if i, ok := searchUint64(s.adds, v); ok {
+		s.adds = append(s.adds[:i], s.adds[i+1:]...)
+		return
+	}
+	if i, ok := searchUint64(s.removes, v); !ok && s.base.Contains(v) {
+		s.removes = insertUint64(s.removes, i, v)
+		s.maybeCompact()
+	}
+}
+
+// Contains returns true if v is a member of the set.
+func (s *Set) Contains(v uint64) bool {
+	if _, ok := searchUint64(s.adds, v); ok {
+		return true
+	}
+	if _, ok := searchUint64(s.removes, v); ok {
+		return false
+	}
+	return s.base.Contains(v)
+}
+
+// Len returns how many values are in the set.
+func (s *Set) Len() uint64 {
+	return s.base.Len() + uint64(len(s.adds)) - uint64(len(s.removes))
+}
+
+// Pending returns the number of changes not yet compacted into the base list.
+func (s *Set) Pending() int {
+	return len(s.adds) + len(s.removes)
+}
+
+// Iterate returns the intervals of the set, including pending changes,
+// without compacting. The set must not be changed while iterating.
+func (s *Set) Iterate() Intervals {
+	bi := s.base.Iterate()
+	return newUnionIntervals(
+		newRemoveIntervals(&bi, &valueIntervals{values: s.removes}),
+		&valueIntervals{values: s.adds},
+	)
+}
+
+// List compacts any pending changes, and returns the set as a List. The
+// returned List must not be modified, and is unaffected by later changes to
+// the set.
+func (s *Set) List() List {
+	s.Compact()
+	s.owned = false
+	return s.base
+}
+
+// Compact merges pending changes into a new base list.
+func (s *Set) Compact() {
+	if s.Pending() == 0 {
+		return
+	}
+	b := Build(&s.spare)
+	iter := s.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		b.interval(first, last)
+	}
+	s.replace(b.Finish())
+}
+
+// UnionWith adds all members of the passed lists to the set.
+//
+// The result is built in a single pass, including any pending changes, into
+// storage left over from previous operations where possible. This makes Set
+// suitable as a long-lived accumulator of many lists.
+func (s *Set) UnionWith(lists ...List) {
+	b := Build(&s.spare)
+	union(&b, s.withAll(lists))
+	s.replace(b.Finish())
+}
+
+// IntersectWith removes all values from the set which are not members of all
+// of the passed lists. As UnionWith, the result is built in a single pass into
+// reused storage where possible.
+func (s *Set) IntersectWith(lists ...List) {
+	b := Build(&s.spare)
+	intersection(&b, s.withAll(lists), math.MaxUint64)
+	s.replace(b.Finish())
+}
+
+// withAll returns cursors over the set and each of the passed lists.
+func (s *Set) withAll(lists []List) []cursor {
+	return append(iterateAll(lists), cursor{src: s.Iterate()})
+}
+
+// replace replaces the base list, clearing pending changes. The storage of the
+// previous base list is kept for reuse if owned.
+func (s *Set) replace(base List) {
+	if s.owned {
+		s.spare = s.base[:0]
+	} else {
+		s.spare = nil
+	}
+	s.base = base
+	s.owned = true
+	s.adds = s.adds[:0]
+	s.removes = s.removes[:0]
+}
+
+func (s *Set) maybeCompact() {
+	max := s.MaxPending
+	if max == 0 {
+		max = DefaultMaxPending
+	}
+	if s.Pending() > max {
+		s.Compact()
+	}
+}
+
+// searchUint64 returns the index of v in the sorted slice a, and if it was
+// found, otherwise the index v would be inserted at.
+func searchUint64(a []uint64, v uint64) (int, bool) {
+	i := sort.Search(len(a), func(i int) bool { return a[i] >= v })
+	return i, i < len(a) && a[i] == v
+}
+
+func insertUint64(a []uint64, i int, v uint64) []uint64 {
+	a = append(a, 0)
+	copy(a[i+1:], a[i:])
+	a[i] = v
+	return a
+}
+
+// valueIntervals yields the intervals of a sorted slice of distinct values.
+type valueIntervals struct {
+	values []uint64
+}
+
+func (v *valueIntervals) NextInterval() (first, last uint64) {
+	if len(v.values) == 0 {
+		return math.MaxUint64, 0
+	}
+	first = v.values[0]
+	i := 1
+	for i < len(v.values) && v.values[i] == first+uint64(i) {
+		i++
+	}
+	v.values = v.values[i:]
+	return first, first + uint64(i) - 1
+}
+
+// unionIntervals lazily yields the union of two sources of intervals.
+type unionIntervals struct {
+	a cursor
+	b cursor
+}
+
+func newUnionIntervals(a, b Intervals) *unionIntervals {
+	u := &unionIntervals{a: cursor{src: a}, b: cursor{src: b}}
+	u.a.next()
+	u.b.next()
+	return u
+}
+
+// lowest returns the cursor with the lowest current interval, or nil if both
+// are at end-of-stream.
+func (u *unionIntervals) lowest() *cursor {
+	a, b := &u.a, &u.b
+	switch {
+	case a.first > a.last && b.first > b.last:
+		return nil
+	case a.first > a.last:
+		return b
+	case b.first > b.last:
+		return a
+	case b.first < a.first:
+		return b
+	}
+	return a
+}
+
+func (u *unionIntervals) NextInterval() (first, last uint64) {
+	c := u.lowest()
+	if c == nil {
+		return math.MaxUint64, 0
+	}
+	first, last = c.first, c.last
+	c.next()
+	// Join overlapping and abutting intervals.
+	for {
+		c = u.lowest()
+		if c == nil || (last != math.MaxUint64 && c.first > last+1) {
+			break
+		}
+		if c.last > last {
+			last = c.last
+		}
+		c.next()
+	}
+	return
+}
+
+// differenceIntervals lazily yields the intervals of a with the values of b
+// removed.
+type differenceIntervals struct {
+	a cursor
+	b cursor
+}
+
+func newDifferenceIntervals(a, b Intervals) *differenceIntervals {
+	d := &differenceIntervals{a: cursor{src: a}, b: cursor{src: b}}
+	d.a.next()
+	d.b.next()
+	return d
+}
+
+func (d *differenceIntervals) NextInterval() (first, last uint64) {
+	a, b := &d.a, &d.b
+	for a.first <= a.last {
+		// Skip intervals of b before the current interval of a.
+		for b.first <= b.last && b.last < a.first {
+			b.next()
+		}
+		if b.first > b.last || b.first > a.last {
+			// No overlap.
+			first, last = a.first, a.last
+			a.next()
+			return
+		}
+		first, last = a.first, b.first-1
+		before := b.first > a.first // Values of a remain before b
+		if b.last >= a.last {
+			a.next()
+		} else {
+			// Continue with the remainder of a after b.
+			a.first = b.last + 1
+			b.next()
+		}
+		if before {
+			return
+		}
+	}
+	return math.MaxUint64, 0
+}
@@ -3,51 +3,189 @@ package skiptake
 // Set algebra operations on skiptake.List instances
 
 import (
-	"container/heap"
+	"bytes"
 	"math"
 )
 
-// firstHeap implements the container/heap.Interface for a set of Iterators.
+// Intervals is the interface implemented by sources of a sequence of
+// intervals, such as *Iterator, so that they can be used as inputs to set
+// operations without first being built into a List.
 //
-// We choose to implement Less only on the interval first value, so it is as
-// fast as possible.
-type firstHeap []Iterator
-
-func (m firstHeap) Len() int      { return len(m) }
-func (m firstHeap) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
-func (m firstHeap) Less(i, j int) bool {
-	a, b := m[i].n, m[j].n
+// NextInterval returns the next interval of the sequence. The values returned
+// are inclusive, and intervals must be returned in ascending order without
+// overlapping. Returns (math.MaxUint64, 0) in the case of end of stream.
+type Intervals interface {
+	NextInterval() (first, last uint64)
+}
+
+// cursor holds the current interval of a source of intervals.
+type cursor struct {
+	src    Intervals
+	seeker IntervalSeeker // src, if it can seek
+	first  uint64
+	last   uint64
+}
+
+func (c *cursor) next() {
+	c.first, c.last = c.src.NextInterval()
+}
+
+// seek advances to the first interval whose last value is at least v, or to
+// end-of-sequence. The current interval must end before v.
+func (c *cursor) seek(v uint64) {
+	if c.seeker != nil {
+		c.first, c.last = c.seeker.SeekInterval(v)
+		return
+	}
+	for c.next(); c.first <= c.last && c.last < v; c.next() {
+	}
+}
+
+// iterateAll returns cursors over Iterators of each of the passed lists. The
+// Iterators and their Decoders are allocated together, rather than one by one.
+func iterateAll(lists []List) []cursor {
+	dec := make([]Decoder, len(lists))
+	iter := make([]Iterator, len(lists))
+	cur := make([]cursor, len(lists))
+	for i := range lists {
+		dec[i] = lists[i].Decode()
+		iter[i].Decoder = &dec[i]
+		cur[i].src, cur[i].seeker = &iter[i], &iter[i]
+	}
+	return cur
+}
+
+// sourceAll returns cursors over each of the passed sources.
+func sourceAll(sources []Intervals) []cursor {
+	cur := make([]cursor, len(sources))
+	for i := range sources {
+		cur[i].src = sources[i]
+		cur[i].seeker, _ = sources[i].(IntervalSeeker)
+	}
+	return cur
+}
+
+// firstHeap is a binary min-heap of cursors, ordered by their current interval.
+//
+// The heap is maintained directly over the slice of cursors, rather than with
+// container/heap, to avoid calling through heap.Interface for every interval
+// merged.
+//
+// We choose to order only on the interval first value, so it is as fast as
+// possible. Ties are broken by the longest interval, which also sorts cursors
+// at end-of-stream, with a last value of zero, after any interval starting at
+// math.MaxUint64.
+type firstHeap []cursor
+
+func (m firstHeap) less(i, j int) bool {
+	a, b := m[i].first, m[j].first
 	if a == b {
-		return m[i].take < m[j].take
+		return m[i].last > m[j].last
 	}
 	return a < b
 }
 
-func (m *firstHeap) Push(x interface{}) {
-	*m = append(*m, x.(Iterator))
+// init establishes the heap ordering.
+func (m firstHeap) init() {
+	for i := len(m)/2 - 1; i >= 0; i-- {
+		m.down(i)
+	}
+}
+
+// down moves the cursor at i down the heap until it is ordered.
+func (m firstHeap) down(i int) {
+	for {
+		j := 2*i + 1
+		if j >= len(m) {
+			return
+		}
+		if k := j + 1; k < len(m) && m.less(k, j) {
+			j = k
+		}
+		if !m.less(j, i) {
+			return
+		}
+		m[i], m[j] = m[j], m[i]
+		i = j
+	}
 }
 
-func (m *firstHeap) Pop() interface{} {
-	old := *m
-	n := len(old)
-	x := old[n-1]
-	*m = old[:n-1]
-	return x
+// advance moves the cursor at the top of the heap to its next interval, and
+// restores the heap ordering. Cursors reaching end-of-stream are removed, so
+// that the heap only holds cursors with intervals remaining.
+func (m *firstHeap) advance() {
+	h := *m
+	h[0].next()
+	if h[0].first > h[0].last {
+		h[0] = h[len(h)-1]
+		h = h[:len(h)-1]
+		*m = h
+	}
+	h.down(0)
 }
 
 // Union returns a new List that is the computed set algebra union of the passed
 // slice of lists.
+//
+// Byte-identical lists are merged only once. If every list is identical, a
+// copy of the list is returned without merging.
 func Union(lists ...List) List {
+	lists, same := distinct(lists)
+	if same {
+		return append(List{}, lists[0]...)
+	}
+	if len(lists) == 2 {
+		return Union2(lists[0], lists[1])
+	}
 	b := Build(&List{})
-	iter := make(firstHeap, len(lists))
-	if len(lists) > 0 {
-		for i := range lists {
-			iter[i] = lists[i].Iterate()
-			// Prime
-			iter[i].NextSkipTake()
+	scratch := acquireMerge(lists)
+	union(&b, scratch.cur)
+	scratch.release()
+	return b.Finish()
+}
+
+// Union2 returns a new List that is the computed set algebra union of the two
+// passed lists. This is the same as Union(a, b), without the overhead of
+// merging an arbitrary number of lists.
+func Union2(a, b List) List {
+	result := newSpanWriter(&List{})
+	union2(result, newSpan(a), newSpan(b))
+	return result.Finish()
+}
+
+func union2(result *spanWriter, a, b *span) {
+	a.next()
+	b.next()
+	var pf, pl uint64 // Pending interval, extended until a gap follows it
+	pending := false
+	for a.first <= a.last || b.first <= b.last {
+		c := a
+		if b.first <= b.last && (a.first > a.last || b.first < a.first) {
+			c = b
 		}
-		union(&b, iter)
+		switch {
+		case !pending:
+			pf, pl, pending = c.first, c.last, true
+		case pl == math.MaxUint64 || c.first <= pl+1:
+			if c.last > pl {
+				pl = c.last
+			}
+		default:
+			result.interval(pf, pl)
+			pf, pl = c.first, c.last
+		}
+		c.next()
+	}
+	if pending {
+		result.interval(pf, pl)
 	}
+}
+
+// UnionOf returns a new List that is the computed set algebra union of the
+// passed sources of intervals.
+func UnionOf(sources ...Intervals) List {
+	b := Build(&List{})
+	union(&b, sourceAll(sources))
 	return b.Finish()
 }
 
@@ -56,19 +194,25 @@ func union(result *Builder, iter firstHeap) {
 	var r uint64 // Current candidate intersection interval last value
 	var l uint64 // Proceededing non-intersection interval first value
 
-	heap.Init(&iter)
+	if len(iter) == 0 {
+		return
+	}
+	for i := range iter {
+		// Prime
+		iter[i].next()
+	}
+	iter.init()
 	// Starting is a special case because of zero skips.
-	n, r = iter[0].Interval()
+	n, r = iter[0].first, iter[0].last
 	if n > r { // EOS
 		return
 	}
-	iter[0].NextInterval()
-	heap.Fix(&iter, 0)
+	iter.advance()
 	result.Skip(n)
 	result.Take(r - n)
 
-	for {
-		first, last := iter[0].Interval()
+	for len(iter) > 0 {
+		first, last := iter[0].first, iter[0].last
 		if first > last { // EOS
 			return
 		}
@@ -84,51 +228,216 @@ func union(result *Builder, iter firstHeap) {
 			result.Take(last - r)
 			r = last
 		}
-		iter[0].NextInterval()
-		heap.Fix(&iter, 0)
+		iter.advance()
 	}
 }
 
 // Intersection returns a new List that is the computed set algebra intersection
 // of the passed slice of lists.
+//
+// Byte-identical lists are merged only once. If every list is identical, a
+// copy of the list is returned without merging. The bounds of the lists are
+// checked first, so the result is returned without merging when any list is
+// empty or the lists do not overlap. Merging stops as soon as any list is
+// exhausted, so intersecting with a short list is cheap however long the
+// others are. More than two lists are merged driven by the shortest, so
+// their order does not matter.
 func Intersection(lists ...List) List {
+	lists, same := distinct(lists)
+	if same {
+		return append(List{}, lists[0]...)
+	}
+	if len(lists) == 2 {
+		return Intersection2(lists[0], lists[1])
+	}
+	max, ok := intersectionBound(lists)
+	if !ok {
+		return List{}
+	}
 	b := Build(&List{})
-	iter := make([]Iterator, len(lists))
-	for i := range lists {
-		iter[i] = lists[i].Iterate()
+	scratch := acquireMerge(lists)
+	scratch.bySelectivity()
+	intersection(&b, scratch.cur, max)
+	scratch.release()
+	return b.Finish()
+}
+
+// Intersection2 returns a new List that is the computed set algebra
+// intersection of the two passed lists. This is the same as
+// Intersection(a, b), without the overhead of merging an arbitrary number of
+// lists. As for Intersection, the bounds of the lists are checked first.
+func Intersection2(a, b List) List {
+	max, ok := intersectionBound([]List{a, b})
+	if !ok {
+		return List{}
+	}
+	result := newSpanWriter(&List{})
+	intersection2(result, newSpan(a), newSpan(b), max)
+	return result.Finish()
+}
+
+// intersection2 merges two lists. Merging stops early once either list is
+// exhausted, or passes max.
+func intersection2(result *spanWriter, a, b *span, max uint64) {
+	a.next()
+	b.next()
+	for a.first <= a.last && b.first <= b.last {
+		switch {
+		case a.last < b.first:
+			a.next()
+		case b.last < a.first:
+			b.next()
+		default:
+			first, last := a.first, a.last
+			if b.first > first {
+				first = b.first
+			}
+			if first > max {
+				return
+			}
+			if b.last < last {
+				last = b.last
+			}
+			result.interval(first, last)
+			if a.last == last {
+				a.next()
+			}
+			if b.last == last {
+				b.next()
+			}
+		}
 	}
-	intersection(&b, iter)
+}
+
+// distinctMax is the most lists distinct() searches for duplicates, as each
+// list is compared with every earlier list.
+const distinctMax = 16
+
+// distinct returns the passed lists with any which are byte-identical to an
+// earlier list removed. same is true if there were multiple lists, all of
+// them identical. Beyond distinctMax lists, only whether they are all the same
+// is checked.
+func distinct(lists []List) ([]List, bool) {
+	if len(lists) > distinctMax {
+		for _, l := range lists[1:] {
+			if !bytes.Equal(l, lists[0]) {
+				return lists, false
+			}
+		}
+		return lists[:1], true
+	}
+	var result []List // Only allocated once a duplicate is found
+	for i, l := range lists {
+		dup := false
+		for _, prev := range lists[:i] {
+			if bytes.Equal(prev, l) {
+				dup = true
+				break
+			}
+		}
+		switch {
+		case dup && result == nil:
+			result = append([]List{}, lists[:i]...)
+		case !dup && result != nil:
+			result = append(result, l)
+		}
+	}
+	if result == nil {
+		result = lists
+	}
+	return result, len(lists) > 1 && len(result) == 1
+}
+
+// intersectionBoundRatio is how many times longer than the shortest list a
+// list may be for intersectionBound to find its last member.
+const intersectionBoundRatio = 16
+
+// intersectionBound returns the largest value the intersection of the passed
+// lists may contain. ok is false if the intersection must be empty, as a list
+// is empty or the bounds of the lists do not overlap.
+//
+// The bounds of each list are found once. The first member of a list is found
+// from its first pairs. Finding the last member costs a pass over the list, as
+// by Bounds(), so is only done for lists at most intersectionBoundRatio times
+// the length of the shortest, which a merge is likely to read in full anyway.
+// Intersecting a short list with a long one stays cheap.
+func intersectionBound(lists []List) (max uint64, ok bool) {
+	shortest := -1
+	for _, l := range lists {
+		if len(l) == 0 {
+			return 0, false
+		}
+		if shortest < 0 || len(l) < shortest {
+			shortest = len(l)
+		}
+	}
+	var min uint64
+	max = math.MaxUint64
+	for _, l := range lists {
+		var first, last uint64
+		if len(l) <= shortest*intersectionBoundRatio {
+			first, last, ok = l.Bounds()
+		} else {
+			iter := l.Iterate()
+			first, last = iter.NextInterval()
+			ok, last = first <= last, math.MaxUint64
+		}
+		if !ok {
+			return 0, false
+		}
+		if first > min {
+			min = first
+		}
+		if last < max {
+			max = last
+		}
+		if min > max {
+			return 0, false
+		}
+	}
+	return max, true
+}
+
+// IntersectionOf returns a new List that is the computed set algebra
+// intersection of the passed sources of intervals.
+func IntersectionOf(sources ...Intervals) List {
+	b := Build(&List{})
+	intersection(&b, sourceAll(sources), math.MaxUint64)
 	return b.Finish()
 }
 
-func intersection(result *Builder, iter []Iterator) {
+// intersection merges the passed cursors. Merging stops early once any cursor
+// is exhausted, or the candidate passes max.
+func intersection(result *Builder, iter []cursor, max uint64) {
 
 	// Handle a degenerate case out of hand
 	if len(iter) == 0 {
 		return
 	}
+	for i := range iter {
+		// Prime
+		iter[i].next()
+	}
 
 	var n uint64 // Current candidate intersection interval first value
 	var r uint64 // Current candidate intersection interval last value
 	var l uint64 // Proceededing non-intersection interval first value
 outer:
-	for r != math.MaxUint64 {
+	for r != math.MaxUint64 && n <= max {
 		for i := range iter {
-			// Scan intervals while they are before our candidate area.
+			// Seek past intervals before our candidate area.
 			it := &iter[i]
-			for first, last := it.Interval(); ; first, last = it.NextInterval() {
-				if first > last { // EOS
-					return
-				}
-				if last >= n {
-					if first > n {
-						// Increased the lower bound of the candidate interval.
-						n = first
-						// Rescan all sequences.
-						continue outer
-					}
-					break
-				}
+			if it.first <= it.last && it.last < n {
+				it.seek(n)
+			}
+			if it.first > it.last { // EOS
+				return
+			}
+			if it.first > n {
+				// Increased the lower bound of the candidate interval.
+				n = it.first
+				// Rescan all sequences.
+				continue outer
 			}
 		}
 
@@ -136,8 +445,8 @@ outer:
 		// Find the longest range of all intervals.
 		r = math.MaxUint64
 		for _, it := range iter {
-			if _, last := it.Interval(); last < r {
-				r = last
+			if it.last < r {
+				r = it.last
 			}
 		}
 		result.Skip(n - l)
@@ -147,6 +456,92 @@ outer:
 	}
 }
 
+// Difference returns a new List that is the computed set algebra difference
+// of the passed lists, that is the members of a which are not members of b.
+func Difference(a, b List) List {
+	result := newSpanWriter(&List{})
+	difference(result, newSpan(a), newSpan(b))
+	return result.Finish()
+}
+
+func difference(result *spanWriter, a, b *span) {
+	a.next()
+	b.next()
+	for a.first <= a.last {
+		// Skip intervals of b before the current interval of a.
+		for b.first <= b.last && b.last < a.first {
+			b.next()
+		}
+		if b.first > b.last || b.first > a.last {
+			// No overlap.
+			result.interval(a.first, a.last)
+			a.next()
+			continue
+		}
+		if b.first > a.first {
+			result.interval(a.first, b.first-1)
+		}
+		if b.last >= a.last {
+			a.next()
+			continue
+		}
+		// Continue with the remainder of a after b.
+		a.first = b.last + 1
+		b.next()
+	}
+}
+
+// Delta returns the members of b which are not members of a as added, and
+// the members of a which are not members of b as removed. This is equivalent
+// to Difference(b, a) and Difference(a, b), but computed in a single pass over
+// both lists.
+func Delta(a, b List) (added, removed List) {
+	ai, bi := a.Iterate(), b.Iterate()
+	ab, rb := Build(&List{}), Build(&List{})
+	delta(&ab, &rb, &cursor{src: &ai}, &cursor{src: &bi})
+	return ab.Finish(), rb.Finish()
+}
+
+func delta(added, removed *Builder, a, b *cursor) {
+	a.next()
+	b.next()
+	for a.first <= a.last || b.first <= b.last {
+		switch {
+		case b.first > b.last || (a.first <= a.last && a.first < b.first):
+			// The front of a precedes b.
+			if b.first > b.last || a.last < b.first {
+				removed.interval(a.first, a.last)
+				a.next()
+			} else {
+				removed.interval(a.first, b.first-1)
+				a.first = b.first
+			}
+		case a.first > a.last || b.first < a.first:
+			// The front of b precedes a.
+			if a.first > a.last || b.last < a.first {
+				added.interval(b.first, b.last)
+				b.next()
+			} else {
+				added.interval(b.first, a.first-1)
+				b.first = a.first
+			}
+		default:
+			// Both start together. Drop the values in common.
+			switch {
+			case a.last == b.last:
+				a.next()
+				b.next()
+			case a.last < b.last:
+				b.first = a.last + 1
+				a.next()
+			default:
+				a.first = b.last + 1
+				b.next()
+			}
+		}
+	}
+}
+
 // Complement returns a new List that is the set algebra complement of the
 // passed List set. The range of the returned list is [0, math.MaxUint64].
 func Complement(list List) List {
@@ -157,56 +552,34 @@ func Complement(list List) List {
 // passed List set, bounded to the range [0, max].
 func ComplementMax(list List, max uint64) List {
 	b := Build(&List{})
-	complement(&b, list.Iterate(), max)
+	iter := list.Iterate()
+	complement(&b, &iter, 0, max)
 	return b.Finish()
 }
 
-func complement(result *Builder, set Iterator, max uint64) {
-	var n uint64
-	for {
-		skip, take := set.NextSkipTake()
-		if skip > 0 {
-			if max-n <= skip {
-				// End boundary case. Next source skip starts outside our max
-				// boundary. Add a take for the rest of the output range and be
-				// done.
-				result.Take(max - n + 1)
-				n += skip
-				return
-			}
-			if n == 0 {
-				// Beginning boundary case. Need to add a zero-skip and take if
-				// the source starts with a skip.
-				result.Skip(0)
-			}
-			// Non-boundary case. Take the skip, minus the implied source take.
-			result.Take(skip - 1)
+// ComplementRange returns a new List that is the set algebra complement of the
+// passed List set, bounded to the range [min, max]. Values of the passed list
+// outside the range are ignored. Returns an empty list if min is greater than
+// max.
+func ComplementRange(list List, min, max uint64) List {
+	b := Build(&List{})
+	iter := list.Iterate()
+	complement(&b, &iter, min, max)
+	return b.Finish()
+}
 
+func complement(result *Builder, set Intervals, min, max uint64) {
+	gaps := complementIntervals{src: set}
+	for first, last := gaps.NextInterval(); first <= last && first <= max; first, last = gaps.NextInterval() {
+		if last < min {
+			continue
 		}
-		n += skip
-		if take == 0 {
-			// Input source is done.
-			break
-		}
-		if max-n <= take {
-			// End boundary case. Next source take finished outside our max
-			// boundary.
-			n += take
-			return
+		if first < min {
+			first = min
 		}
-		// Non-boundary case. Add source take as a skip.
-		result.Skip(take)
-
-		n += take
-	}
-
-	// End-boundary case. Source list finished before our max boundary. Add a
-	// take for the rest of the interval between the end of the source to the
-	// max boundary.
-	if n <= max {
-		if n == 0 {
-			result.Skip(0)
+		if last > max {
+			last = max
 		}
-		result.Take(max - n)
+		result.interval(first, last)
 	}
 }
@@ -1,6 +1,9 @@
 package skiptake
 
 import (
+	"bytes"
+	"context"
+	"sort"
 	"testing"
 )
 
@@ -239,3 +242,301 @@ func TestSetOperationsComplementMax(t *testing.T) {
 		)
 	})
 }
+
+func testDifference(t *testing.T, expected []uint64, a, b List) {
+	t.Logf("A: %v", a)
+	t.Logf("B: %v", b)
+	difference := Difference(a, b)
+	t.Logf("Difference: %v", difference)
+	result := difference.Expand()
+	if !equalUint64(expected, result) {
+		t.Errorf("%v != %v", result, expected)
+	}
+}
+
+func TestSetOperationsDifference(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		testDifference(t, []uint64{}, List{}, Create(1, 2))
+	})
+
+	t.Run("EmptySubtrahend", func(t *testing.T) {
+		testDifference(t, []uint64{1, 2}, Create(1, 2), List{})
+	})
+
+	t.Run("Disjoint", func(t *testing.T) {
+		testDifference(t, []uint64{1, 2, 10}, Create(1, 2, 10), Create(0, 3, 5, 11))
+	})
+
+	t.Run("Common", func(t *testing.T) {
+		testDifference(t,
+			[]uint64{0, 1, 5, 6, 9, 15, 16, 17, 20},
+			makeRange(intrv{0, 9}, intrv{12, 20}),
+			makeRange(intrv{2, 4}, intrv{7, 8}, intrv{10, 14}, intrv{18, 19}),
+		)
+	})
+
+	t.Run("Subset", func(t *testing.T) {
+		testDifference(t, []uint64{}, makeRange(intrv{5, 10}), makeRange(intrv{0, 20}))
+	})
+
+	t.Run("MaxRange", func(t *testing.T) {
+		testDifference(t,
+			[]uint64{0xfffffffffffffffd, 0xffffffffffffffff},
+			makeRange(intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+			makeRange(intrv{0, 0xfffffffffffffffc}, intrv{0xfffffffffffffffe, 0xfffffffffffffffe}),
+		)
+	})
+}
+
+func TestSetOperationsDelta(t *testing.T) {
+	lists := []List{
+		{},
+		Create(1, 2),
+		Create(1, 2, 10),
+		Create(0, 3, 5, 11),
+		makeRange(intrv{0, 9}, intrv{12, 20}),
+		makeRange(intrv{2, 4}, intrv{7, 8}, intrv{10, 14}, intrv{18, 19}),
+		makeRange(intrv{5, 30}, intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+		makeRange(intrv{0xfffffffffffffffe, 0xffffffffffffffff}),
+	}
+	for i, a := range lists {
+		for j, b := range lists {
+			added, removed := Delta(a, b)
+			if !Equal(added, Difference(b, a)) {
+				t.Errorf("Delta(lists[%d], lists[%d]) added %v, expected %v", i, j, added, Difference(b, a))
+			}
+			if !Equal(removed, Difference(a, b)) {
+				t.Errorf("Delta(lists[%d], lists[%d]) removed %v, expected %v", i, j, removed, Difference(a, b))
+			}
+		}
+	}
+}
+
+func TestSetOperationsComplementBoundary(t *testing.T) {
+	// Lists ending at math.MaxUint64
+	testComplement(t,
+		makeRange(intrv{1, 1}, intrv{0xffffffffffffffff, 0xffffffffffffffff}),
+		makeRange(intrv{0, 0}, intrv{2, 0xfffffffffffffffe}),
+		0xffffffffffffffff,
+	)
+	testComplement(t,
+		makeRange(intrv{0, 0}, intrv{0xffffffffffffffff, 0xffffffffffffffff}),
+		makeRange(intrv{1, 0xfffffffffffffffe}),
+		0xffffffffffffffff,
+	)
+}
+
+func TestSetOperationsComplementRange(t *testing.T) {
+	subject := makeRange(intrv{0, 3}, intrv{10, 19}, intrv{30, 30}, intrv{0xfffffffffffffff0, 0xffffffffffffffff})
+	cases := []struct {
+		min, max uint64
+		expected List
+	}{
+		{0, 40, makeRange(intrv{4, 9}, intrv{20, 29}, intrv{31, 40})},
+		{5, 25, makeRange(intrv{5, 9}, intrv{20, 25})},
+		{10, 19, List{}},
+		{12, 12, List{}},
+		{25, 25, Create(25)},
+		{20, 0xffffffffffffffff, makeRange(intrv{20, 29}, intrv{31, 0xffffffffffffffef})},
+		{40, 30, List{}},
+	}
+	for _, c := range cases {
+		result := ComplementRange(subject, c.min, c.max)
+		t.Logf("ComplementRange [%d, %d]: %v", c.min, c.max, result)
+		if !Equal(result, c.expected) {
+			t.Errorf("ComplementRange(%v, %d, %d) = %v, expected %v", subject, c.min, c.max, result, c.expected)
+		}
+		if c.min == 0 && !Equal(result, ComplementMax(subject, c.max)) {
+			t.Errorf("ComplementRange() differs from ComplementMax()")
+		}
+	}
+}
+
+func TestSetOperationsIntersectionBounds(t *testing.T) {
+	t.Run("Disjoint", func(t *testing.T) {
+		testIntersection(t, []uint64{}, makeRange(intrv{0, 9}, intrv{20, 29}), makeRange(intrv{30, 39}))
+	})
+
+	t.Run("Touching", func(t *testing.T) {
+		testIntersection(t, []uint64{29}, makeRange(intrv{0, 9}, intrv{20, 29}), makeRange(intrv{29, 39}))
+	})
+
+	t.Run("OneEmpty", func(t *testing.T) {
+		testIntersection(t, []uint64{}, Create(1, 2, 3), List{}, Create(2, 3))
+	})
+
+	t.Run("Max", func(t *testing.T) {
+		testIntersection(t, []uint64{7, 0xffffffffffffffff},
+			makeRange(intrv{5, 9}, intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+			Create(7, 0xffffffffffffffff),
+		)
+	})
+
+	t.Run("Tail", func(t *testing.T) {
+		// Merging stops at the end of the shortest list.
+		testIntersection(t, []uint64{5, 6, 7},
+			makeRange(intrv{0, 9}, intrv{20, 29}, intrv{40, 49}),
+			makeRange(intrv{5, 7}),
+		)
+	})
+
+	t.Run("EarlyExit", func(t *testing.T) {
+		// Disjoint lists of many intervals. A merge would read thousands of
+		// intervals, checking the context, which is done after its first
+		// check.
+		var low, high []uint64
+		for v := uint64(0); v < 100000; v++ {
+			if v%3 != 0 && v%7 != 0 {
+				low = append(low, v)
+				high = append(high, v+200000)
+			}
+		}
+		a, b := Create(low...), Create(high...)
+		ctx := &countdownContext{Context: context.Background(), n: 1}
+		if l, err := IntersectionContext(ctx, a, b); err != nil || !l.IsEmpty() {
+			t.Errorf("IntersectionContext() = %v, %v, expected an early exit", l, err)
+		}
+
+		// A list of 2^40 pairs, which a merge would step through.
+		var long List
+		p := Build(&long)
+		p.progression(10, 3, 1<<40)
+		long = p.Finish()
+		if l := Intersection(long, Create(1<<50)); !l.IsEmpty() {
+			t.Errorf("Intersection() = %v", l)
+		}
+		if l := Intersection2(Create(1<<50), long); !l.IsEmpty() {
+			t.Errorf("Intersection2() = %v", l)
+		}
+		var out bytes.Buffer
+		if n, err := IntersectionTo(&out, Create(1<<50), long, Create(1<<50, 1<<51)); n != 0 || err != nil {
+			t.Errorf("IntersectionTo() = %d, %v", n, err)
+		}
+	})
+}
+
+func TestSetOperationsIdentical(t *testing.T) {
+	a := makeRange(intrv{0, 9}, intrv{20, 29})
+	b := makeRange(intrv{5, 24})
+	same := append(List{}, a...)
+
+	union := Union(a, same, a)
+	if !Equal(union, a) {
+		t.Errorf("Union of identical lists = %v, expected %v", union, a)
+	}
+	intersection := Intersection(a, same)
+	if !Equal(intersection, a) {
+		t.Errorf("Intersection of identical lists = %v, expected %v", intersection, a)
+	}
+	// The result must be a copy
+	intersection[0]++
+	if !Equal(same, a) || Equal(intersection, a) {
+		t.Errorf("Intersection of identical lists shares memory with its input")
+	}
+
+	// Duplicates among other lists
+	lists := []List{a, b, same, b}
+	testUnion(t, Union(a, b).Expand(), lists...)
+	testIntersection(t, Intersection(a, b).Expand(), lists...)
+	if !Equal(lists[2], a) || !Equal(lists[3], b) {
+		t.Errorf("Input lists were modified: %v", lists)
+	}
+
+	if !Equal(List{}, nil) {
+		t.Errorf("Empty lists not equal")
+	}
+}
+
+func TestSetOperationsTwoLists(t *testing.T) {
+	lists := []List{
+		{},
+		Create(1, 2),
+		Create(1, 2, 10),
+		Create(0, 3, 5, 11),
+		makeRange(intrv{0, 9}, intrv{12, 20}),
+		makeRange(intrv{2, 4}, intrv{7, 8}, intrv{10, 14}, intrv{18, 19}),
+		makeRange(intrv{5, 30}, intrv{0xfffffffffffffff0, 0xffffffffffffffff}),
+		makeRange(intrv{0xfffffffffffffffe, 0xffffffffffffffff}),
+	}
+	for i, a := range lists {
+		for j, b := range lists {
+			// The heap-based merges of UnionOf and IntersectionOf are the
+			// reference.
+			ai, bi := a.Iterate(), b.Iterate()
+			if result, expected := Union2(a, b), UnionOf(&ai, &bi); !Equal(result, expected) {
+				t.Errorf("Union2(lists[%d], lists[%d]) = %v, expected %v", i, j, result, expected)
+			}
+			ai, bi = a.Iterate(), b.Iterate()
+			if result, expected := Intersection2(a, b), IntersectionOf(&ai, &bi); !Equal(result, expected) {
+				t.Errorf("Intersection2(lists[%d], lists[%d]) = %v, expected %v", i, j, result, expected)
+			}
+		}
+	}
+}
+
+func TestSetOperationsUnionMany(t *testing.T) {
+	lists := make([]List, 1000)
+	members := map[uint64]bool{}
+	for i := range lists {
+		v := uint64(i) * 3
+		lists[i] = Create(v, v+1, 5000+v)
+		members[v], members[v+1], members[5000+v] = true, true, true
+	}
+	expected := make([]uint64, 0, len(members))
+	for v := range members {
+		expected = append(expected, v)
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+	if result := Union(lists...).Expand(); !equalUint64(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+
+	// Merging allocates only for the cursors and the result, not per list or
+	// interval.
+	allocs := testing.AllocsPerRun(10, func() { Union(lists...) })
+	if allocs > 50 {
+		t.Errorf("Union of %d lists made %v allocations", len(lists), allocs)
+	}
+}
+
+func TestSetOperationsIntersectionOrder(t *testing.T) {
+	var dense, sparse []uint64
+	for v := uint64(0); v < 20000; v++ {
+		if v%3 != 0 && v%7 != 0 {
+			dense = append(dense, v)
+		}
+		if v%1000 == 1 {
+			sparse = append(sparse, v)
+		}
+	}
+	a, b := Create(dense...), makeRange(intrv{0, 10000}, intrv{10002, 20000})
+	c := Create(sparse...)
+	expected := Intersection(c, a, b)
+	lists := []List{a, b, c}
+	if result := Intersection(lists...); !Equal(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+	if !Equal(lists[2], c) {
+		t.Errorf("Intersection() reordered the passed lists")
+	}
+	// c is packed in 5 bytes, b in 7 and a in over 10000.
+	scratch := acquireMerge(lists)
+	scratch.bySelectivity()
+	for i, expected := range []List{c, b, a} {
+		if it := scratch.cur[i].src.(*Iterator); !Equal(it.Decoder.Elements, expected) {
+			t.Errorf("bySelectivity() placed %v at %d", it.Decoder.Elements, i)
+		}
+	}
+	scratch.release()
+
+	// A long run of repeated pairs is sought past, not stepped through, when
+	// a short list drives the merge.
+	var long List
+	p := Build(&long)
+	p.progression(10, 3, 1<<40)
+	long = p.Finish()
+	if result := Intersection(long, makeRange(intrv{0, 1 << 42}), Create(1<<41, 1<<41+2)); !Equal(result, Create(1<<41+2)) {
+		t.Errorf("Intersection() = %v", result.Expand())
+	}
+}
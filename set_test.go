@@ -0,0 +1,141 @@
+package skiptake
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func Test_Set(t *testing.T) {
+	s := NewSet(makeRange(intrv{10, 19}, intrv{30, 39}))
+	s.MaxPending = 1000
+
+	s.Add(5)
+	s.Add(20)
+	s.Add(15) // Already a member
+	s.Remove(12)
+	s.Remove(39)
+	s.Remove(50) // Not a member
+	s.Add(12)    // Undo a removal
+	s.Add(21)
+	s.Remove(21) // Undo an addition
+
+	expected := []uint64{5, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 30, 31, 32, 33, 34, 35, 36, 37, 38}
+	for v := uint64(0); v < 60; v++ {
+		_, member := searchUint64(expected, v)
+		if s.Contains(v) != member {
+			t.Errorf("Contains(%d) = %v", v, !member)
+		}
+	}
+	expectUint64(t, s.Len(), uint64(len(expected)))
+	if s.Pending() != 3 {
+		t.Errorf("Pending() = %d, expected 3", s.Pending())
+	}
+
+	result := UnionOf(s.Iterate())
+	if !equalUint64(result.Expand(), expected) {
+		t.Errorf("Iterate() %v != %v", result, Create(expected...))
+	}
+
+	l := s.List()
+	if s.Pending() != 0 {
+		t.Errorf("Pending() = %d after compaction", s.Pending())
+	}
+	if !equalUint64(l.Expand(), expected) {
+		t.Errorf("List() %v != %v", l, Create(expected...))
+	}
+}
+
+func Test_SetCompact(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := NewSet(nil)
+	s.MaxPending = 16
+	model := map[uint64]bool{}
+	for i := 0; i < 2000; i++ {
+		v := uint64(r.Intn(500))
+		if r.Intn(3) == 0 {
+			s.Remove(v)
+			delete(model, v)
+		} else {
+			s.Add(v)
+			model[v] = true
+		}
+		if s.Pending() > s.MaxPending {
+			t.Fatalf("Pending() = %d", s.Pending())
+		}
+	}
+	expected := []uint64{}
+	for v := range model {
+		expected = append(expected, v)
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+	if result := s.List().Expand(); !equalUint64(result, expected) {
+		t.Errorf("%v != %v", Create(result...), Create(expected...))
+	}
+}
+
+func Test_UnionIntervals(t *testing.T) {
+	a := makeRange(intrv{3, 0xffffffffffffffff})
+	b := makeRange(intrv{0, 1}, intrv{5, 6}, intrv{100, 200})
+	ai, bi := a.Iterate(), b.Iterate()
+	u := newUnionIntervals(&ai, &bi)
+	first, last := u.NextInterval()
+	expectUint64(t, first, 0)
+	expectUint64(t, last, 1)
+	first, last = u.NextInterval()
+	expectUint64(t, first, 3)
+	expectUint64(t, last, 0xffffffffffffffff)
+	if first, last = u.NextInterval(); first <= last {
+		t.Errorf("Unexpected interval [%d - %d]", first, last)
+	}
+}
+
+func Test_DifferenceIntervals(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		lists := [2]List{}
+		for k := range lists {
+			var values []uint64
+			for v := uint64(r.Intn(5)); v < 300; v += 1 + uint64(r.Intn(1<<uint(r.Intn(5)))) {
+				values = append(values, v)
+			}
+			lists[k] = Create(values...)
+		}
+		ai, bi := lists[0].Iterate(), lists[1].Iterate()
+		result := UnionOf(newDifferenceIntervals(&ai, &bi))
+		if expected := Difference(lists[0], lists[1]); string(result) != string(expected) {
+			t.Fatalf("differenceIntervals = %v, expected %v", result, expected)
+		}
+	}
+}
+
+func Test_SetUnionIntersectWith(t *testing.T) {
+	base := makeRange(intrv{10, 19})
+	s := NewSet(base)
+	s.Add(30)
+	s.UnionWith(Create(1, 2), makeRange(intrv{18, 25}))
+	expected := makeRange(intrv{1, 2}, intrv{10, 25}, intrv{30, 30})
+	if !equalUint64(base.Expand(), makeRange(intrv{10, 19}).Expand()) {
+		t.Errorf("Base list was modified: %v", base)
+	}
+	if result := UnionOf(s.Iterate()); !Equal(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+
+	s.Remove(15)
+	s.IntersectWith(makeRange(intrv{2, 20}))
+	expected = makeRange(intrv{2, 2}, intrv{10, 14}, intrv{16, 20})
+	if result := UnionOf(s.Iterate()); !Equal(result, expected) {
+		t.Errorf("%v != %v", result, expected)
+	}
+
+	// A list returned by List() is unaffected by later operations.
+	l := s.List()
+	s.UnionWith(Create(100))
+	s.UnionWith(Create(200))
+	s.UnionWith(Create(300))
+	if !Equal(l, expected) {
+		t.Errorf("Returned list was modified: %v", l)
+	}
+	expectUint64(t, s.Len(), expected.Len()+3)
+}
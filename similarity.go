@@ -0,0 +1,98 @@
+package skiptake
+
+// Measures of the similarity between two lists, computed without building the
+// result of any set operation.
+
+// HammingDistance returns the number of members in exactly one of the passed
+// lists, that is the length of their symmetric difference. Both lists are
+// walked once, and no list is built.
+func HammingDistance(a, b List) uint64 {
+	na, nb, common := cardinalities(a, b)
+	return na + nb - 2*common
+}
+
+// Jaccard returns the Jaccard index of the passed lists, |A∩B| / |A∪B|. Two
+// empty lists are considered identical, with an index of 1.
+func Jaccard(a, b List) float64 {
+	return Similarity(a, b).Jaccard
+}
+
+// OverlapCoefficient returns the overlap coefficient of the passed lists,
+// |A∩B| / min(|A|, |B|). This is 1 whenever one list is a subset of the other,
+// including when either is empty.
+func OverlapCoefficient(a, b List) float64 {
+	return Similarity(a, b).Overlap
+}
+
+// Dice returns the Sørensen–Dice coefficient of the passed lists,
+// 2|A∩B| / (|A|+|B|). Two empty lists are considered identical, with a
+// coefficient of 1.
+func Dice(a, b List) float64 {
+	return Similarity(a, b).Dice
+}
+
+// Similarities holds several similarity measures of a pair of lists, as
+// returned by Similarity().
+type Similarities struct {
+	Jaccard float64
+	Overlap float64
+	Dice    float64
+}
+
+// Similarity returns the Jaccard index, overlap coefficient and Dice
+// coefficient of the passed lists, computed together from a single walk of
+// both lists. Use this rather than the individual functions when more than
+// one measure is needed.
+func Similarity(a, b List) Similarities {
+	na, nb, common := cardinalities(a, b)
+	if na == 0 && nb == 0 {
+		return Similarities{1, 1, 1}
+	}
+	c := float64(common)
+	s := Similarities{
+		Jaccard: c / (float64(na) + float64(nb) - c),
+		Overlap: 1,
+		Dice:    2 * c / (float64(na) + float64(nb)),
+	}
+	if smaller := min64(na, nb); smaller > 0 {
+		s.Overlap = c / float64(smaller)
+	}
+	return s
+}
+
+// cardinalities walks the intervals of a and b together, returning the number
+// of members of each, and the number of members common to both.
+func cardinalities(a, b List) (na, nb, common uint64) {
+	ai, bi := a.Iterate(), b.Iterate()
+	ac, bc := cursor{src: &ai}, cursor{src: &bi}
+	ac.next()
+	bc.next()
+	for ac.first <= ac.last && bc.first <= bc.last {
+		first, last := ac.first, ac.last
+		if bc.first > first {
+			first = bc.first
+		}
+		if bc.last < last {
+			last = bc.last
+		}
+		if first <= last {
+			common += last - first + 1
+		}
+		// Advance whichever interval ends first. The other may still overlap
+		// the next interval of its counterpart.
+		if ac.last <= bc.last {
+			na += ac.last - ac.first + 1
+			ac.next()
+		} else {
+			nb += bc.last - bc.first + 1
+			bc.next()
+		}
+	}
+	for ; ac.first <= ac.last; ac.next() {
+		na += ac.last - ac.first + 1
+	}
+	for ; bc.first <= bc.last; bc.next() {
+		nb += bc.last - bc.first + 1
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_HammingDistance(t *testing.T) {
+	a := makeRange(intrv{0, 9}, intrv{20, 29}, intrv{100, 100})
+	b := makeRange(intrv{5, 24}, intrv{28, 40}, intrv{0xfffffffffffffff0, 0xffffffffffffffff})
+
+	lists := []List{{}, a, b, makeRange(intrv{100, 100}), makeRange(intrv{0xffffffffffffffff, 0xffffffffffffffff})}
+	for i, x := range lists {
+		for j, y := range lists {
+			expected := Union(Difference(x, y), Difference(y, x)).Len()
+			if result := HammingDistance(x, y); result != expected {
+				t.Errorf("HammingDistance(lists[%d], lists[%d]) = %d, expected %d", i, j, result, expected)
+			}
+		}
+	}
+
+	t.Logf("Hamming distance: %d", HammingDistance(a, b))
+	expectUint64(t, HammingDistance(a, a), 0)
+}
+
+func Test_Similarity(t *testing.T) {
+	a := makeRange(intrv{0, 9}, intrv{20, 29})  // 20 members
+	b := makeRange(intrv{5, 14}, intrv{25, 34}) // 20 members, 10 common
+	c := makeRange(intrv{0, 4})                 // Subset of a
+	d := makeRange(intrv{1000, 1009})           // Disjoint from all
+
+	cases := []struct {
+		name                   string
+		x, y                   List
+		jaccard, overlap, dice float64
+	}{
+		{"a,b", a, b, 10.0 / 30, 10.0 / 20, 20.0 / 40},
+		{"a,a", a, a, 1, 1, 1},
+		{"a,c", a, c, 5.0 / 20, 1, 10.0 / 25},
+		{"a,d", a, d, 0, 0, 0},
+		{"a,empty", a, List{}, 0, 1, 0},
+		{"empty,empty", List{}, List{}, 1, 1, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Similarity(tc.x, tc.y)
+			if s != (Similarities{tc.jaccard, tc.overlap, tc.dice}) {
+				t.Errorf("Similarity = %+v, expected {%v %v %v}", s, tc.jaccard, tc.overlap, tc.dice)
+			}
+			if Jaccard(tc.x, tc.y) != tc.jaccard || Jaccard(tc.y, tc.x) != tc.jaccard {
+				t.Errorf("Jaccard = %v, expected %v", Jaccard(tc.x, tc.y), tc.jaccard)
+			}
+			if OverlapCoefficient(tc.x, tc.y) != tc.overlap {
+				t.Errorf("OverlapCoefficient = %v, expected %v", OverlapCoefficient(tc.x, tc.y), tc.overlap)
+			}
+			if Dice(tc.x, tc.y) != tc.dice {
+				t.Errorf("Dice = %v, expected %v", Dice(tc.x, tc.y), tc.dice)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package skiptake
+
+import (
+	"encoding/binary"
+)
+
+// This file implements an alternative byte packing of (skip, take) pairs,
+// based on the Simple-8b word packing scheme of Anh and Moffat.
+//
+// Skip and take values are flattened into a single stream, stored as skip-1
+// and take-1 for the same reasons as in the varint packing. The stream is then
+// packed into 64-bit words. The top 4 bits of each word are a selector, which
+// chooses how many values of which equal bit width are packed into the
+// remaining 60 bits:
+//
+//		selector:  1   2   3   4   5   6   7   8   9  10  11  12  13  14  15
+//		values:  120  60  30  20  15  12  10   8   7   6   5   4   3   2   1
+//		bits:      0   1   2   3   4   5   6   7   8  10  12  15  20  30  60
+//
+// Selector 1 encodes a run of 120 zero values, which is 60 pairs of (skip 1,
+// take 1), in a single word. Selector 0 is an escape for values which do not
+// fit in 60 bits: the following word holds a single raw 64-bit value.
+//
+// Words are stored little-endian. Dense lists of small skips and takes pack far
+// tighter than with varints, and a whole word of values is unpacked with one
+// load and a fixed sequence of shifts.
+
+type simple8bSelector struct {
+	n    int
+	bits uint
+}
+
+var simple8bSelectors = [16]simple8bSelector{
+	{1, 64}, {120, 0}, {60, 1}, {30, 2}, {20, 3}, {15, 4}, {12, 5}, {10, 6},
+	{8, 7}, {7, 8}, {6, 10}, {5, 12}, {4, 15}, {3, 20}, {2, 30}, {1, 60},
+}
+
+// Simple8bEncoder packs (skip, take) pairs into Elements using Simple-8b words.
+//
+// Values are buffered until enough are present to choose the best packing for
+// a word, so Flush must be called after the final pair has been added.
+type Simple8bEncoder struct {
+	Elements *[]byte
+	pending  []uint64
+}
+
+// Add adds a new skip-take pair to the sequence.
+func (e *Simple8bEncoder) Add(skip, take uint64) {
+	e.pending = append(e.pending, skip-1, take-1)
+	if len(e.pending) >= 2*simple8bSelectors[1].n {
+		e.pack(false)
+	}
+}
+
+// Flush packs all buffered values.
+func (e *Simple8bEncoder) Flush() {
+	e.pack(true)
+	e.pending = e.pending[:0]
+}
+
+// pack writes words while there are enough buffered values to fill any
+// selector, or until empty if final is set.
+func (e *Simple8bEncoder) pack(final bool) {
+	v := e.pending
+	for len(v) >= simple8bSelectors[1].n || (final && len(v) > 0) {
+		n := e.packWord(v)
+		v = v[n:]
+	}
+	e.pending = append(e.pending[:0], v...)
+}
+
+// packWord writes a single word, choosing the selector which packs the most
+// values from the front of v. Returns how many values were packed.
+func (e *Simple8bEncoder) packWord(v []uint64) int {
+	var ar [8]byte
+	for s := 1; s < len(simple8bSelectors); s++ {
+		sel := simple8bSelectors[s]
+		if sel.n > len(v) || !simple8bFits(v[:sel.n], sel.bits) {
+			continue
+		}
+		w := uint64(s) << 60
+		for j, x := range v[:sel.n] {
+			w |= x << (uint(j) * sel.bits)
+		}
+		binary.LittleEndian.PutUint64(ar[:], w)
+		*e.Elements = append(*e.Elements, ar[:]...)
+		return sel.n
+	}
+
+	// Escape
+	binary.LittleEndian.PutUint64(ar[:], 0)
+	*e.Elements = append(*e.Elements, ar[:]...)
+	binary.LittleEndian.PutUint64(ar[:], v[0])
+	*e.Elements = append(*e.Elements, ar[:]...)
+	return 1
+}
+
+func simple8bFits(v []uint64, bits uint) bool {
+	for _, x := range v {
+		if x>>bits != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Simple8bDecoder unpacks (skip, take) pairs packed by a Simple8bEncoder. A
+// whole word of values is unpacked at once, and handed out by subsequent calls
+// to Next().
+type Simple8bDecoder struct {
+	Elements []byte
+	i        int
+	values   [120]uint64
+	n        int // Number of values unpacked into values
+	k        int // Number of values returned
+}
+
+// Next returns the next pair of skip, take values. Returns (0,0) in the case of
+// end-of-sequence.
+func (d *Simple8bDecoder) Next() (skip, take uint64) {
+	if d.EOS() {
+		return 0, 0
+	}
+	skip = d.next() + 1
+	take = d.next() + 1
+	return
+}
+
+// EOS returns if the decoder is at the end of the sequence.
+func (d *Simple8bDecoder) EOS() bool {
+	return d.k == d.n && d.i+8 > len(d.Elements)
+}
+
+// Reset resets the location of the decoder to the beginning of the sequence.
+func (d *Simple8bDecoder) Reset() {
+	d.i, d.n, d.k = 0, 0, 0
+}
+
+func (d *Simple8bDecoder) next() uint64 {
+	if d.k == d.n {
+		if d.i+8 > len(d.Elements) {
+			return 0
+		}
+		d.unpack()
+	}
+	v := d.values[d.k]
+	d.k++
+	return v
+}
+
+// unpack unpacks the word at the current location.
+func (d *Simple8bDecoder) unpack() {
+	w := binary.LittleEndian.Uint64(d.Elements[d.i:])
+	d.i += 8
+	d.k = 0
+	s := w >> 60
+	if s == 0 {
+		d.n = 1
+		if d.i+8 <= len(d.Elements) {
+			d.values[0] = binary.LittleEndian.Uint64(d.Elements[d.i:])
+		}
+		d.i += 8
+		return
+	}
+	sel := simple8bSelectors[s]
+	d.n = sel.n
+	if sel.bits == 0 {
+		for j := 0; j < sel.n; j++ {
+			d.values[j] = 0
+		}
+		return
+	}
+	mask := uint64(1)<<sel.bits - 1
+	for j := 0; j < sel.n; j++ {
+		d.values[j] = w & mask
+		w >>= sel.bits
+	}
+}
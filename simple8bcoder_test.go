@@ -0,0 +1,43 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Simple8bEncodeDecode(t *testing.T) {
+	for name, values := range codecTestCases {
+		t.Run(name, func(t *testing.T) {
+			var b []byte
+			testPairCodec(t, values, &Simple8bEncoder{Elements: &b}, func() PairDecoder {
+				t.Logf("Encoded as %d bytes: %v", len(b), b)
+				return &Simple8bDecoder{Elements: b}
+			})
+		})
+	}
+}
+
+func Test_Simple8bDense(t *testing.T) {
+	// Every other integer, then runs of small mixed sizes.
+	values := [][2]uint64{}
+	for i := 0; i < 1000; i++ {
+		values = append(values, [2]uint64{1, 1})
+	}
+	for i := 0; i < 1000; i++ {
+		values = append(values, [2]uint64{uint64(i%7) + 1, uint64(i%3) + 1})
+	}
+
+	var b []byte
+	testPairCodec(t, values, &Simple8bEncoder{Elements: &b}, func() PairDecoder {
+		return &Simple8bDecoder{Elements: b}
+	})
+
+	var l List
+	e := l.Encode()
+	for _, p := range values {
+		e.Add(p[0], p[1])
+	}
+	t.Logf("Simple8b: %d bytes, varint: %d bytes", len(b), len(l))
+	if len(b) >= len(l) {
+		t.Errorf("Simple8b packing (%d bytes) not smaller than varint (%d bytes)", len(b), len(l))
+	}
+}
@@ -36,7 +36,14 @@ it.
 package skiptake
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strconv"
 	"strings"
 )
 
@@ -61,6 +68,9 @@ func Create(values ...uint64) List {
 // Equal returns true if two lists are the same. That is, they contain the same
 // subsequence.
 func Equal(a, b List) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
 	ai := a.Iterate()
 	bi := b.Iterate()
 	for !ai.EOS() {
@@ -76,6 +86,38 @@ func Equal(a, b List) bool {
 	return true
 }
 
+// Fingerprint returns a 64-bit hash of the set of values in the list. Lists
+// holding the same values have the same fingerprint, even if their encodings
+// differ.
+func Fingerprint(l List) uint64 {
+	iter := l.Iterate()
+	f := fingerprinter{src: &iter, h: newFingerprintHash()}
+	for first, last := f.NextInterval(); first <= last; first, last = f.NextInterval() {
+	}
+	return f.h.Sum64()
+}
+
+func newFingerprintHash() hash.Hash64 {
+	return fnv.New64a()
+}
+
+// fingerprinter passes through intervals from src, hashing them as it goes.
+type fingerprinter struct {
+	src Intervals
+	h   hash.Hash64
+	buf [16]byte
+}
+
+func (f *fingerprinter) NextInterval() (first, last uint64) {
+	first, last = f.src.NextInterval()
+	if first <= last {
+		binary.LittleEndian.PutUint64(f.buf[:8], first)
+		binary.LittleEndian.PutUint64(f.buf[8:], last)
+		f.h.Write(f.buf[:])
+	}
+	return
+}
+
 // FromRaw creates a skip-take list from a slice of []uint64 values
 // representing a sequence of alternating skip and take values.
 func FromRaw(v ...uint64) List {
@@ -103,12 +145,33 @@ func (l List) GetRaw() []uint64 {
 	return result
 }
 
+// Clone returns a copy of the list, which shares no memory with l.
+func (l List) Clone() List {
+	c := make(List, len(l))
+	copy(c, l)
+	return c
+}
+
+// Compact returns the list in an allocation of exactly its size. Lists built
+// by a Builder usually have spare capacity, which is wasted while the list is
+// kept. If l has no spare capacity, it is returned as-is, otherwise a copy is
+// returned. Eg:
+//
+//		kept = append(kept, b.Finish().Compact())
+//
+func (l List) Compact() List {
+	if cap(l) == len(l) {
+		return l
+	}
+	return l.Clone()
+}
+
 // Len returns how many values are in the expanded sequence.
 func (l List) Len() uint64 {
 	var ret uint64
 	for d := l.Decode(); !d.EOS(); {
-		_, t := d.Next()
-		ret += t
+		_, t, c := d.NextRun()
+		ret += t * c
 	}
 	return ret
 }
@@ -119,6 +182,32 @@ func (l List) Iterate() Iterator {
 	return Iterator{Decoder: &d}
 }
 
+// IterateInto reinitializes the passed Iterator to iterate the list from its
+// beginning. The Iterator's Decoder is reused if it has one, so tight loops
+// over many lists can run without allocating. Eg:
+//
+//		var iter skiptake.Iterator
+//		for _, l := range lists {
+//			l.IterateInto(&iter)
+//			...
+//		}
+//
+func (l List) IterateInto(t *Iterator) {
+	if t.Decoder == nil {
+		t.Decoder = &Decoder{}
+	}
+	l.DecodeInto(t.Decoder)
+	t.skipSum, t.take, t.n = 0, 0, 0
+	t.fill = nil
+}
+
+// IterateWith returns a new skiptake.Iterator for a list encoded with the
+// passed options.
+func (l List) IterateWith(opts Options) Iterator {
+	d := l.DecodeWith(opts)
+	return Iterator{Decoder: &d}
+}
+
 // Expand expands the sequence as a slice of uint64 values of length Len().
 //
 // Note: Caution is to be exercised. A naive use of Expand() on a result of
@@ -148,41 +237,174 @@ func (l List) String() string {
 // return. List which exceed this limit will be truncated with `...`. Otherwise
 // all ranges will be included.
 func (l List) Format(maxLen int) string {
+	switch {
+	case maxLen == 0:
+		return ""
+	case maxLen < 0:
+		maxLen = 0
+	}
+	return l.FormatWith(FormatOptions{MaxLen: maxLen})
+}
+
+// FormatOptions configures the output of List.FormatWith(). The zero value of
+// FormatOptions formats as List.Format(-1).
+type FormatOptions struct {
+	// MaxLen is the maximum length of the string to return, with zero meaning
+	// no limit. Lists which exceed this are truncated with Ellipsis.
+	MaxLen int
+
+	// MaxRanges is the maximum number of ranges to include, with zero meaning
+	// no limit. Lists with more ranges are truncated with Ellipsis.
+	MaxRanges int
+
+	// Separator is written between ranges. Defaults to ", ".
+	Separator string
+
+	// RangeFormat is a fmt format string with two %s verbs, for the first and
+	// last values of ranges longer than 1, Eg "%s-%s". Defaults to
+	// "[%s - %s]".
+	RangeFormat string
+
+	// Ellipsis is written in place of ranges that are omitted. Defaults to
+	// "...".
+	Ellipsis string
+
+	// Hex formats values in hexadecimal, with a 0x prefix.
+	Hex bool
+
+	// Summarize follows the Ellipsis of a truncated list with how many values
+	// and ranges were omitted, Eg "... +1,234 more in 56 ranges". The summary
+	// is not counted towards MaxLen.
+	Summarize bool
+}
+
+func (o FormatOptions) separator() string {
+	if o.Separator == "" {
+		return ", "
+	}
+	return o.Separator
+}
+
+func (o FormatOptions) rangeFormat() string {
+	if o.RangeFormat == "" {
+		return "[%s - %s]"
+	}
+	return o.RangeFormat
+}
+
+func (o FormatOptions) ellipsis() string {
+	if o.Ellipsis == "" {
+		return "..."
+	}
+	return o.Ellipsis
+}
+
+func (o FormatOptions) value(v uint64) string {
+	if o.Hex {
+		return "0x" + strconv.FormatUint(v, 16)
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// FormatWith returns a human-friendly representation of the list of values as
+// a sequence of ranges, as configured by opts. Eg:
+//
+//		l.FormatWith(skiptake.FormatOptions{RangeFormat: "%s-%s", Separator: ","})
+//
+// returns
+//
+//		2-5,7,9-12
+//
+func (l List) FormatWith(opts FormatOptions) string {
 	b := strings.Builder{}
+	sep, ellipsis := opts.separator(), opts.ellipsis()
+	maxLen := opts.MaxLen
+	if maxLen <= 0 {
+		maxLen = -1
+	}
 
-	first := true
+	ranges := 0
 	iter := l.Iterate()
 	for iter.NextSkipTake(); !iter.EOS(); iter.NextSkipTake() {
 		var s string
 		begin, end := iter.Interval()
 		if end <= begin {
-			s = fmt.Sprintf("%d", begin)
+			s = opts.value(begin)
 		} else {
-			s = fmt.Sprintf("[%d - %d]", begin, end)
+			s = fmt.Sprintf(opts.rangeFormat(), opts.value(begin), opts.value(end))
 		}
 		neededCap := len(s)
-		if !first {
-			neededCap += 2
+		if ranges > 0 {
+			neededCap += len(sep)
 		}
 		if !iter.EOS() {
-			neededCap += 3
+			neededCap += len(ellipsis)
 		}
 
-		if maxLen < 0 || maxLen >= neededCap {
-			if !first {
-				b.WriteString(", ")
+		if (maxLen < 0 || maxLen >= neededCap) && (opts.MaxRanges <= 0 || ranges < opts.MaxRanges) {
+			if ranges > 0 {
+				b.WriteString(sep)
 			}
 			b.WriteString(s)
 			if maxLen >= 0 {
-				maxLen -= len(s) + 2
+				maxLen -= len(s) + len(sep)
 			}
 		} else {
-			if maxLen >= 3 {
-				b.WriteString("...")
+			if maxLen < 0 || maxLen >= len(ellipsis) {
+				b.WriteString(ellipsis)
+			}
+			if opts.Summarize {
+				n, ranges := remaining(&iter)
+				fmt.Fprintf(&b, " +%s more in %s range", groupDigits(n), groupDigits(ranges))
+				if ranges != 1 {
+					b.WriteByte('s')
+				}
 			}
 			break
 		}
-		first = false
+		ranges++
 	}
 	return b.String()
 }
+
+// remaining returns how many values and intervals remain in iter, including
+// the current interval. The intervals are counted from the runs of the
+// decoder, without iterating over repeated pairs.
+func remaining(iter *Iterator) (n, intervals uint64) {
+	n, intervals = iter.take, 1
+	open := true // Whether a following zero skip extends the last interval
+	for d := iter.Decoder; !d.EOS(); {
+		skip, take, count := d.NextRun()
+		switch {
+		case take == 0:
+			open = open && skip == 0
+			continue
+		case skip > 0:
+			intervals += count
+		case !open:
+			intervals++
+		}
+		open = true
+		hi, lo := bits.Mul64(take, count)
+		if sum, carry := bits.Add64(n, lo, 0); hi == 0 && carry == 0 {
+			n = sum
+		} else {
+			n = math.MaxUint64
+		}
+	}
+	return
+}
+
+// groupDigits formats n in decimal, with commas separating groups of three
+// digits.
+func groupDigits(n uint64) string {
+	s := strconv.FormatUint(n, 10)
+	b := make([]byte, 0, len(s)+len(s)/3)
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b = append(b, ',')
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
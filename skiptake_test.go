@@ -146,3 +146,79 @@ func Test_SkipTake_InclusiveMaxValue(t *testing.T) {
 		t.Errorf("%#v != %#v", result, subject)
 	}
 }
+
+func Test_SkipTake_CloneCompact(t *testing.T) {
+	b := Build(&List{})
+	for v := uint64(0); v < 1000; v += 3 + v%5 {
+		b.Next(v)
+	}
+	l := b.Finish()
+	t.Logf("Built list of %d bytes, capacity %d", len(l), cap(l))
+
+	c := l.Clone()
+	if !Equal(c, l) || len(c) != len(l) {
+		t.Errorf("Clone() = %v, expected %v", c, l)
+	}
+	c[0]++
+	if Equal(c, l) {
+		t.Errorf("Clone() shares memory with the list")
+	}
+
+	compact := l.Compact()
+	if !Equal(compact, l) || cap(compact) != len(l) {
+		t.Errorf("Compact() = %v with capacity %d, expected %v with capacity %d", compact, cap(compact), l, len(l))
+	}
+	if again := compact.Compact(); &again[0] != &compact[0] {
+		t.Errorf("Compact() of a compact list made a copy")
+	}
+	expectUint64(t, uint64(len(List{}.Clone())), 0)
+}
+
+func Test_Builder_AppendList(t *testing.T) {
+	var progression List
+	b := Build(&progression)
+	b.progression(3, 7, 1000)
+	progression = b.Finish()
+
+	chunks := []List{
+		Create(0, 1, 2, 5, 9),
+		progression,
+		makeRange(intrv{0, 99}, intrv{200, 299}),
+		List{},
+		FromRaw(4, 0, 2, 3), // A leading skip with a take of zero
+		Create(0),
+	}
+	const chunkSize = 10000
+
+	var appended, expected List
+	b = Build(&appended)
+	e := Build(&expected)
+	for i, chunk := range chunks {
+		base := uint64(i) * chunkSize
+		if !b.AppendList(chunk, base) {
+			t.Fatalf("chunk %d refused", i)
+		}
+		for _, v := range chunk.Expand() {
+			e.Next(base + v)
+		}
+	}
+	appended, expected = b.Finish(), e.Finish()
+	if !Equal(appended, expected) {
+		t.Fatalf("AppendList() = %v, expected %v", appended.Expand(), expected.Expand())
+	}
+	if string(appended) != string(expected) {
+		t.Errorf("AppendList() encoded as %v, expected %v", []byte(appended), []byte(expected))
+	}
+
+	// Chunks which abut are coalesced, and overlapping ones refused.
+	var l List
+	b = Build(&l)
+	b.AppendList(makeRange(intrv{0, 9}), 0)
+	b.AppendList(makeRange(intrv{0, 9}), 10)
+	if b.AppendList(Create(0, 30), 15) {
+		t.Error("expected overlapping list to be refused")
+	}
+	if l = b.Finish(); string(l) != string(makeRange(intrv{0, 19})) {
+		t.Errorf("AppendList() = %v, expected [0, 19]", l.Expand())
+	}
+}
@@ -0,0 +1,98 @@
+package skiptaketest
+
+import (
+	"testing"
+
+	"github.com/arthurt/skiptake"
+)
+
+// maxExpand is the largest list, in values, which the round trip checks
+// expand.
+const maxExpand = 1 << 20
+
+// EqualUint64 returns whether a and b hold the same values in the same order.
+func EqualUint64(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertEquivalent checks that l is valid and holds exactly the values of
+// expected, which must be strictly increasing. Differences are reported with
+// t.Errorf. Returns whether l is equivalent.
+func AssertEquivalent(t testing.TB, l skiptake.List, expected []uint64) bool {
+	t.Helper()
+	if err := l.Validate(); err != nil {
+		t.Errorf("list %v is invalid: %v", []byte(l), err)
+		return false
+	}
+	if n := l.Len(); n != uint64(len(expected)) {
+		t.Errorf("list %v has %d values, expected %d", l, n, len(expected))
+		return false
+	}
+	iter := l.Iterate()
+	for i, v := range expected {
+		if n := iter.Next(); n != v {
+			t.Errorf("list %v value %d is %d, expected %d", l, i, n, v)
+			return false
+		}
+	}
+	return true
+}
+
+// CheckRoundTrip checks the invariants that the package promises for l: that
+// it is valid, and is unchanged by conversion to and from its raw pairs, its
+// values, a copy, and frames of every registered codec. Failures are reported
+// with t.Errorf. Returns whether all checks passed.
+//
+// Lists of more than 2^20 values are not converted to and from their values.
+func CheckRoundTrip(t testing.TB, l skiptake.List) bool {
+	t.Helper()
+	if err := l.Validate(); err != nil {
+		t.Errorf("list %v is invalid: %v", []byte(l), err)
+		return false
+	}
+	ok := true
+	check := func(what string, r skiptake.List) {
+		t.Helper()
+		if !skiptake.Equal(l, r) {
+			t.Errorf("list %v differs after %s: %v", l, what, r)
+			ok = false
+		} else if skiptake.Fingerprint(l) != skiptake.Fingerprint(r) {
+			t.Errorf("list %v fingerprint differs after %s", l, what)
+			ok = false
+		}
+	}
+
+	check("FromRaw(GetRaw())", skiptake.FromRaw(l.GetRaw()...))
+	check("Clone()", l.Clone())
+	if l.Len() <= maxExpand {
+		check("Create(Expand())", skiptake.Create(l.Expand()...))
+	}
+	for id := 0; id < 256; id++ {
+		codec, registered := skiptake.LookupCodec(skiptake.CodecID(id))
+		if !registered {
+			continue
+		}
+		b, err := skiptake.Marshal(l, skiptake.CodecID(id))
+		if err != nil {
+			t.Errorf("Marshal(%v, %d) error: %v", l, id, err)
+			ok = false
+			continue
+		}
+		r, err := skiptake.Unmarshal(b)
+		if err != nil {
+			t.Errorf("Unmarshal of %v with codec %d error: %v", l, id, err)
+			ok = false
+			continue
+		}
+		check("a frame of codec "+codec.Name, r)
+	}
+	return ok
+}
@@ -0,0 +1,69 @@
+package skiptaketest
+
+import (
+	"testing"
+
+	"github.com/arthurt/skiptake"
+)
+
+// recorder is a testing.TB which records failures rather than failing.
+type recorder struct {
+	testing.TB
+	errors int
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.TB.Logf("recorded: "+format, args...)
+	r.errors++
+}
+
+func Test_AssertEquivalent(t *testing.T) {
+	l := FromIntervals([][2]uint64{{0, 2}, {4, 5}})
+	testCases := []struct {
+		list     skiptake.List
+		values   []uint64
+		expected bool
+	}{
+		{l, []uint64{0, 1, 2, 4, 5}, true},
+		{skiptake.List{}, []uint64{}, true},
+		{l, []uint64{0, 1, 2, 4}, false},
+		{l, []uint64{0, 1, 2, 4, 6}, false},
+		{skiptake.List{0xff}, nil, false},
+	}
+	for _, c := range testCases {
+		r := &recorder{TB: t}
+		if result := AssertEquivalent(r, c.list, c.values); result != c.expected || (r.errors == 0) != c.expected {
+			t.Errorf("AssertEquivalent(%v, %v) = %v with %d errors", c.list, c.values, result, r.errors)
+		}
+	}
+}
+
+func Test_CheckRoundTrip(t *testing.T) {
+	lists := []skiptake.List{
+		{},
+		skiptake.Create(0),
+		skiptake.Create(1, 2, 3, 10, 1<<40),
+		FromIntervals([][2]uint64{{1, 1 << 63}}),
+	}
+	for _, l := range lists {
+		if !CheckRoundTrip(t, l) {
+			t.Errorf("CheckRoundTrip(%v) failed", l)
+		}
+	}
+
+	r := &recorder{TB: t}
+	if CheckRoundTrip(r, skiptake.List{0xff}) || r.errors == 0 {
+		t.Errorf("CheckRoundTrip of an invalid list passed")
+	}
+}
+
+func Test_EqualUint64(t *testing.T) {
+	if !EqualUint64([]uint64{1, 2}, []uint64{1, 2}) || !EqualUint64(nil, []uint64{}) {
+		t.Errorf("EqualUint64 of equal slices is false")
+	}
+	if EqualUint64([]uint64{1, 2}, []uint64{1, 3}) || EqualUint64([]uint64{1}, []uint64{1, 2}) {
+		t.Errorf("EqualUint64 of differing slices is true")
+	}
+}
@@ -0,0 +1,264 @@
+package skiptaketest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+
+	"github.com/arthurt/skiptake"
+)
+
+// The Fuzz functions check the invariants the skiptake package promises for
+// arbitrary input, for use as fuzzing entry points. They follow the go-fuzz
+// convention: they panic if an invariant is broken, and otherwise return 1 if
+// the input was well formed, and 0 if not. Eg, with native Go fuzzing:
+//
+//		func FuzzDecode(f *testing.F) {
+//			for _, seed := range skiptaketest.Seeds() {
+//				f.Add(seed)
+//			}
+//			f.Fuzz(func(t *testing.T, data []byte) {
+//				skiptaketest.FuzzDecode(data)
+//			})
+//		}
+//
+
+// maxFuzzPairs is the most skip-take pairs a list may hold for the Fuzz
+// functions to check it. Repeat counts allow a short list to hold far more
+// pairs than can be checked quickly.
+const maxFuzzPairs = 1 << 16
+
+// FuzzDecode treats data as a packed list. Invalid lists must decode without
+// panicking. Valid lists are checked for consistency between their encoding,
+// intervals and values, and must survive re-encoding, set operations with
+// themselves, and frames of every registered codec.
+func FuzzDecode(data []byte) int {
+	l := skiptake.List(data)
+	if err := l.Validate(); err != nil {
+		for d := l.Decode(); !d.EOS(); {
+			d.NextRun()
+		}
+		return 0
+	}
+	if !checkList(l) {
+		return 0
+	}
+	return 1
+}
+
+// FuzzFrame treats data as a frame, as written by skiptake.AppendFrame. Any
+// frame must be read without panicking. Frames holding a valid list must
+// survive being written and read again with the same codec.
+func FuzzFrame(data []byte) int {
+	id, err := skiptake.FrameCodec(data)
+	if err != nil {
+		return 0
+	}
+	if !framePairsWithin(data, maxFuzzPairs) {
+		return 0
+	}
+	l, err := skiptake.Unmarshal(data)
+	if err != nil || l.Validate() != nil {
+		return 0
+	}
+	b, err := skiptake.Marshal(l, id)
+	if err != nil {
+		panic(fmt.Sprintf("list %v read from frame %v cannot be written: %v", l, data, err))
+	}
+	r, err := skiptake.Unmarshal(b)
+	if err != nil || !skiptake.Equal(l, r) {
+		panic(fmt.Sprintf("list %v read from frame %v differs after writing with codec %d: %v, %v", l, data, id, r, err))
+	}
+	return 1
+}
+
+// framePairsWithin returns whether the frame data decodes to at most max
+// pairs. Reading a frame decodes it pair by pair, so a short frame holding a
+// long run of repeats can take far longer than a fuzzing input should.
+func framePairsWithin(data []byte, max int) bool {
+	c, ok := skiptake.LookupCodec(skiptake.CodecID(data[0]))
+	size, k := binary.Uvarint(data[1:])
+	if !ok || k <= 0 || uint64(len(data)-1-k) < size {
+		return true
+	}
+	d := c.NewDecoder(data[1+k : 1+k+int(size)])
+	for n := 0; !d.EOS(); n++ {
+		if n == max {
+			return false
+		}
+		d.Next()
+	}
+	return true
+}
+
+// FuzzValues treats data as a sequence of uvarint differences between
+// increasing values. The list created from the values must hold exactly those
+// values, and is then checked as FuzzDecode().
+func FuzzValues(data []byte) int {
+	values := []uint64{}
+	var v uint64
+	for i := 0; i < len(data); {
+		d, n := binary.Uvarint(data[i:])
+		if n <= 0 || len(values) > maxFuzzPairs {
+			return 0
+		}
+		i += n
+		if len(values) > 0 {
+			if d == 0 || v+d < v {
+				return 0
+			}
+			d += v
+		}
+		v = d
+		values = append(values, v)
+	}
+
+	l := skiptake.Create(values...)
+	if err := l.Validate(); err != nil {
+		panic(fmt.Sprintf("Create(%v) = %v is invalid: %v", values, []byte(l), err))
+	}
+	iter := l.Iterate()
+	for _, v := range values {
+		if n := iter.Next(); n != v || iter.EOS() {
+			panic(fmt.Sprintf("Create(%v) = %v holds %d, expected %d", values, l, n, v))
+		}
+	}
+	if iter.Next(); !iter.EOS() {
+		panic(fmt.Sprintf("Create(%v) = %v holds extra values", values, l))
+	}
+	checkList(l)
+	return 1
+}
+
+// checkList checks the invariants of the valid list l. Returns false without
+// checking if l holds too many pairs.
+func checkList(l skiptake.List) bool {
+	if pairs(l) > maxFuzzPairs {
+		return false
+	}
+
+	// Intervals are increasing and separated, and agree with Len().
+	var n uint64
+	var intervals [][2]uint64
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if k := len(intervals); k > 0 && first <= intervals[k-1][1]+1 {
+			panic(fmt.Sprintf("list %v interval [%d, %d] follows [%d, %d]", []byte(l), first, last, intervals[k-1][0], intervals[k-1][1]))
+		}
+		intervals = append(intervals, [2]uint64{first, last})
+		n += last - first + 1
+	}
+	if len(intervals) == 1 && intervals[0] == [2]uint64{0, math.MaxUint64} {
+		// The full range holds 2^64 values, and cannot be rebuilt.
+		return true
+	}
+	if l.Len() != n {
+		panic(fmt.Sprintf("list %v Len() = %d, intervals hold %d", []byte(l), l.Len(), n))
+	}
+
+	// Rebuilding the list, from its pairs or its intervals, holds the same
+	// values.
+	fp := skiptake.Fingerprint(l)
+	same := func(what string, r skiptake.List) {
+		if skiptake.Fingerprint(r) != fp {
+			panic(fmt.Sprintf("list %v differs after %s: %v", []byte(l), what, []byte(r)))
+		}
+	}
+	same("FromRaw(GetRaw())", skiptake.FromRaw(l.GetRaw()...))
+	rebuilt := FromIntervals(intervals)
+	if skiptake.Fingerprint(rebuilt) != fp {
+		panic(fmt.Sprintf("list %v fingerprint differs from its intervals %v", []byte(l), intervals))
+	}
+	same("Union with itself", skiptake.Union(l, rebuilt))
+	same("Intersection with itself", skiptake.Intersection(l, rebuilt))
+	if d := skiptake.Difference(l, rebuilt); d.Len() != 0 {
+		panic(fmt.Sprintf("list %v less itself is %v", []byte(l), d))
+	}
+
+	for id := 0; id < 256; id++ {
+		if _, ok := skiptake.LookupCodec(skiptake.CodecID(id)); !ok {
+			continue
+		}
+		b, err := skiptake.Marshal(l, skiptake.CodecID(id))
+		if err != nil {
+			panic(fmt.Sprintf("Marshal(%v, %d): %v", []byte(l), id, err))
+		}
+		r, err := skiptake.Unmarshal(b)
+		if err != nil {
+			panic(fmt.Sprintf("Unmarshal(Marshal(%v, %d)): %v", []byte(l), id, err))
+		}
+		same(fmt.Sprintf("a frame of codec %d", id), r)
+	}
+	return true
+}
+
+// pairs returns how many skip-take pairs l holds.
+func pairs(l skiptake.List) uint64 {
+	var n uint64
+	for d := l.Decode(); !d.EOS(); {
+		_, _, count := d.NextRun()
+		n += count
+		if n > maxFuzzPairs || count == 0 {
+			// A count of zero is a run of 2^64 pairs.
+			return maxFuzzPairs + 1
+		}
+	}
+	return n
+}
+
+// Seeds returns packed lists exercising each feature of the encoding, as a
+// starting corpus for FuzzDecode.
+func Seeds() [][]byte {
+	lists := []skiptake.List{
+		{},
+		skiptake.Create(0),
+		skiptake.Create(5),
+		skiptake.Create(1, 2, 3, 4, 7, 8, 9),
+		skiptake.Create(0, 1<<32, 1<<63, math.MaxUint64),
+		skiptake.FromRaw(0, 5000000000),
+		skiptake.FromRaw(9, 1, 0, 1, 1, 1),
+		skiptake.FromRaw(9, 1, 3, 0, 1, 1),
+		skiptake.FromRaw(1, math.MaxUint64),
+	}
+	progression := skiptake.Build(&skiptake.List{})
+	for v := uint64(100); v < 10000; v += 7 {
+		progression.Next(v)
+	}
+	lists = append(lists, progression.Finish())
+	lists = append(lists, skiptake.Complement(lists[3]))
+
+	seeds := make([][]byte, len(lists))
+	for i, l := range lists {
+		seeds[i] = l
+	}
+	return seeds
+}
+
+// FrameSeeds returns the lists of Seeds() written as frames with every
+// registered codec, as a starting corpus for FuzzFrame.
+func FrameSeeds() [][]byte {
+	var seeds [][]byte
+	for id := 0; id < 256; id++ {
+		if _, ok := skiptake.LookupCodec(skiptake.CodecID(id)); !ok {
+			continue
+		}
+		for _, l := range Seeds() {
+			if b, err := skiptake.Marshal(l, skiptake.CodecID(id)); err == nil {
+				seeds = append(seeds, b)
+			}
+		}
+	}
+	return seeds
+}
+
+// WriteCorpus writes each seed to its own file in dir, as a go-fuzz corpus.
+func WriteCorpus(dir string, seeds [][]byte) error {
+	for i, seed := range seeds {
+		if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("seed-%03d", i)), seed, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
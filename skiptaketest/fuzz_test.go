@@ -0,0 +1,80 @@
+package skiptaketest
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func Test_FuzzSeeds(t *testing.T) {
+	for _, seed := range Seeds() {
+		if FuzzDecode(seed) != 1 {
+			t.Errorf("FuzzDecode(%v) rejected a seed", seed)
+		}
+	}
+	frames := FrameSeeds()
+	if len(frames) < len(Seeds()) {
+		t.Errorf("FrameSeeds() returned %d frames", len(frames))
+	}
+	for _, seed := range frames {
+		if FuzzFrame(seed) != 1 {
+			t.Errorf("FuzzFrame(%v) rejected a seed", seed)
+		}
+	}
+}
+
+func Test_FuzzRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seeds := append(Seeds(), FrameSeeds()...)
+	for i := 0; i < 2000; i++ {
+		// Mutate a seed, or generate random bytes.
+		var data []byte
+		if i%2 == 0 {
+			data = append([]byte{}, seeds[r.Intn(len(seeds))]...)
+			for k := r.Intn(3); k >= 0 && len(data) > 0; k-- {
+				data[r.Intn(len(data))] ^= byte(1 << uint(r.Intn(8)))
+			}
+		} else {
+			data = make([]byte, r.Intn(24))
+			r.Read(data)
+		}
+		FuzzDecode(data)
+		FuzzFrame(data)
+		FuzzValues(data)
+	}
+}
+
+func Test_FuzzValues(t *testing.T) {
+	var data []byte
+	var buf [binary.MaxVarintLen64]byte
+	for _, d := range []uint64{3, 1, 1, 1, 100, 1 << 40, 2} {
+		data = append(data, buf[:binary.PutUvarint(buf[:], d)]...)
+	}
+	if FuzzValues(data) != 1 {
+		t.Errorf("FuzzValues(%v) rejected valid values", data)
+	}
+	if FuzzValues([]byte{5, 0}) != 0 {
+		t.Errorf("FuzzValues() accepted a repeated value")
+	}
+	if FuzzValues([]byte{0x80}) != 0 {
+		t.Errorf("FuzzValues() accepted a truncated uvarint")
+	}
+}
+
+func Test_WriteCorpus(t *testing.T) {
+	dir := t.TempDir()
+	seeds := Seeds()
+	if err := WriteCorpus(dir, seeds); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != len(seeds) {
+		t.Fatalf("WriteCorpus wrote %d files, %v", len(files), err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, files[3].Name()))
+	if err != nil || string(b) != string(seeds[3]) {
+		t.Errorf("WriteCorpus file %s = %v, expected %v", files[3].Name(), b, seeds[3])
+	}
+}
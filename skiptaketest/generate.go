@@ -0,0 +1,146 @@
+// Package skiptaketest provides utilities for testing code which uses
+// skip-take lists: generators of random lists, and assertions on their
+// contents and invariants.
+package skiptaketest
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/arthurt/skiptake"
+)
+
+// Distribution draws a random length, such as of a run of members or of a gap
+// between runs.
+type Distribution func(r *rand.Rand) uint64
+
+// Constant returns a Distribution which always draws n.
+func Constant(n uint64) Distribution {
+	return func(*rand.Rand) uint64 { return n }
+}
+
+// Uniform returns a Distribution which draws uniformly from [min, max].
+func Uniform(min, max uint64) Distribution {
+	return func(r *rand.Rand) uint64 {
+		span := max - min + 1
+		if span == 0 {
+			return r.Uint64()
+		}
+		return min + r.Uint64()%span
+	}
+}
+
+// Geometric returns a Distribution of lengths of at least 1 with the passed
+// mean, as of the runs of a random bitmap.
+func Geometric(mean float64) Distribution {
+	if mean <= 1 {
+		return Constant(1)
+	}
+	p := 1 / mean
+	return func(r *rand.Rand) uint64 {
+		n := math.Ceil(math.Log(1-r.Float64()) / math.Log(1-p))
+		if n < 1 {
+			return 1
+		}
+		if n >= math.MaxUint64 {
+			return math.MaxUint64
+		}
+		return uint64(n)
+	}
+}
+
+// Generator generates random lists, as alternating gaps and runs of members
+// drawn from its distributions.
+type Generator struct {
+	Rand  *rand.Rand
+	Start uint64       // The lowest possible member
+	Runs  Distribution // Lengths of runs of members. Zero lengths are drawn again.
+	Gaps  Distribution // Lengths of gaps before each run. Zero is allowed.
+}
+
+// NewGenerator returns a Generator of lists where a fraction density of values
+// are members, in runs with the passed mean length. As runs are separated by
+// at least one non-member, the density reached is at most meanRun / (meanRun +
+// 1). Eg:
+//
+//		g := skiptaketest.NewGenerator(rand.New(rand.NewSource(1)), 0.1, 4)
+//		l, values := g.Generate(1000)
+//
+func NewGenerator(r *rand.Rand, density, meanRun float64) *Generator {
+	return &Generator{
+		Rand: r,
+		Runs: Geometric(meanRun),
+		Gaps: Geometric(meanRun * (1 - density) / density),
+	}
+}
+
+// Intervals generates up to n inclusive intervals of members, in increasing
+// order. Fewer are returned if the intervals reach math.MaxUint64.
+func (g *Generator) Intervals(n int) [][2]uint64 {
+	intervals := make([][2]uint64, 0, n)
+	next := g.Start
+	for len(intervals) < n {
+		gap := g.Gaps(g.Rand)
+		if len(intervals) > 0 && gap == 0 {
+			// Abutting runs make one interval.
+			gap = 1
+		}
+		run := g.Runs(g.Rand)
+		for run == 0 {
+			run = g.Runs(g.Rand)
+		}
+		first := next + gap
+		if first < next || first+(run-1) < first {
+			break
+		}
+		last := first + (run - 1)
+		intervals = append(intervals, [2]uint64{first, last})
+		if last == math.MaxUint64 {
+			break
+		}
+		next = last + 1
+	}
+	return intervals
+}
+
+// List generates a list of up to n intervals. See Intervals().
+func (g *Generator) List(n int) skiptake.List {
+	return FromIntervals(g.Intervals(n))
+}
+
+// Generate generates a list of up to n intervals, along with the values it
+// holds. See Intervals(). The lengths drawn from the Runs distribution should
+// be kept small enough for the values to fit in memory.
+func (g *Generator) Generate(n int) (skiptake.List, []uint64) {
+	intervals := g.Intervals(n)
+	return FromIntervals(intervals), Values(intervals)
+}
+
+// FromIntervals returns the list holding the passed inclusive intervals, which
+// must be increasing and not overlap. Abutting intervals are joined. Eg:
+//
+//		l := skiptaketest.FromIntervals([][2]uint64{{0, 2}, {4, 5}})
+//
+// The interval [0, math.MaxUint64] cannot be held by a list.
+func FromIntervals(intervals [][2]uint64) skiptake.List {
+	b := skiptake.Build(&skiptake.List{})
+	for _, iv := range intervals {
+		b.Next(iv[0])
+		b.Take(iv[1] - iv[0])
+	}
+	return b.Finish()
+}
+
+// Values returns the values of the passed inclusive intervals.
+func Values(intervals [][2]uint64) []uint64 {
+	values := []uint64{}
+	for _, iv := range intervals {
+		for v := iv[0]; ; v++ {
+			values = append(values, v)
+			if v == iv[1] {
+				break
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,77 @@
+package skiptaketest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func Test_Generator(t *testing.T) {
+	for _, density := range []float64{0.01, 0.1, 0.5, 0.9} {
+		for _, meanRun := range []float64{1, 4, 50} {
+			g := NewGenerator(rand.New(rand.NewSource(1)), density, meanRun)
+			l, values := g.Generate(2000)
+			AssertEquivalent(t, l, values)
+
+			intervals := l.NumIntervals()
+			if intervals != 2000 {
+				t.Errorf("density %g, mean run %g: %d intervals, expected 2000", density, meanRun, intervals)
+			}
+			_, last, _ := l.Bounds()
+			actual := float64(len(values)) / float64(last+1)
+			t.Logf("density %g, mean run %g: %d values, density %.3f", density, meanRun, len(values), actual)
+			expected := density
+			if reachable := meanRun / (meanRun + 1); expected > reachable {
+				expected = reachable
+			}
+			if math.Abs(actual-expected) > 0.1*expected+0.01 {
+				t.Errorf("density %g, mean run %g: actual density %.3f", density, meanRun, actual)
+			}
+		}
+	}
+}
+
+func Test_GeneratorLimits(t *testing.T) {
+	g := &Generator{
+		Rand:  rand.New(rand.NewSource(1)),
+		Start: math.MaxUint64 - 100,
+		Runs:  Uniform(0, 3),
+		Gaps:  Uniform(0, 3),
+	}
+	intervals := g.Intervals(1000)
+	if len(intervals) == 0 || len(intervals) >= 1000 {
+		t.Fatalf("Intervals() from near math.MaxUint64 returned %d intervals", len(intervals))
+	}
+	l := FromIntervals(intervals)
+	CheckRoundTrip(t, l)
+	AssertEquivalent(t, l, Values(intervals))
+
+	for i := 1; i < len(intervals); i++ {
+		if intervals[i][0] <= intervals[i-1][1]+1 {
+			t.Errorf("Intervals %v and %v abut or overlap", intervals[i-1], intervals[i])
+		}
+	}
+}
+
+func Test_Distributions(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	if n := Constant(7)(r); n != 7 {
+		t.Errorf("Constant(7) drew %d", n)
+	}
+	for i := 0; i < 1000; i++ {
+		if n := Uniform(3, 5)(r); n < 3 || n > 5 {
+			t.Fatalf("Uniform(3, 5) drew %d", n)
+		}
+	}
+	var sum uint64
+	for i := 0; i < 10000; i++ {
+		n := Geometric(20)(r)
+		if n < 1 {
+			t.Fatalf("Geometric(20) drew %d", n)
+		}
+		sum += n
+	}
+	if mean := float64(sum) / 10000; math.Abs(mean-20) > 1 {
+		t.Errorf("Geometric(20) mean is %g", mean)
+	}
+}
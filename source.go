@@ -0,0 +1,119 @@
+package skiptake
+
+import (
+	"math"
+)
+
+// Adapters for third-party bitmap libraries, so that their sets can be fed to
+// set operations and Builders without first being expanded. A library need
+// only implement one of the tiny interfaces below, typically by wrapping its
+// own iterator. Eg:
+//
+//		iter := list.Iterate()
+//		u := skiptake.UnionOf(skiptake.NewRunIntervals(runs), &iter)
+//
+
+// RunSource is implemented by sources of runs of consecutive values, such as
+// the run containers of a compressed bitmap. NextRun returns the first value
+// and length of the next run, with ok false at the end. Runs must be in
+// ascending order and must not overlap, but may abut or be empty.
+type RunSource interface {
+	NextRun() (start, length uint64, ok bool)
+}
+
+// BitSource is implemented by sources of single values, such as the iterator
+// of a bitmap. NextBit returns the next value, with ok false at the end.
+// Values must be strictly increasing.
+type BitSource interface {
+	NextBit() (v uint64, ok bool)
+}
+
+// pendingInterval accumulates abutting intervals into one.
+type pendingInterval struct {
+	first, last uint64
+	ok          bool
+}
+
+// add adds the interval [first, last] following the pending one. If it does
+// not abut the pending interval, the pending interval is returned and
+// replaced.
+func (p *pendingInterval) add(first, last uint64) (f, l uint64, flushed bool) {
+	if p.ok && first == p.last+1 {
+		p.last = last
+		return 0, 0, false
+	}
+	f, l, flushed = p.first, p.last, p.ok
+	p.first, p.last, p.ok = first, last, true
+	return
+}
+
+// flush returns the pending interval, or (math.MaxUint64, 0) if there is none.
+func (p *pendingInterval) flush() (first, last uint64) {
+	if !p.ok {
+		return math.MaxUint64, 0
+	}
+	p.ok = false
+	return p.first, p.last
+}
+
+// RunIntervals yields the runs of a RunSource as Intervals, coalescing runs
+// which abut.
+type RunIntervals struct {
+	src     RunSource
+	pending pendingInterval
+}
+
+// NewRunIntervals returns a RunIntervals over src.
+func NewRunIntervals(src RunSource) *RunIntervals {
+	return &RunIntervals{src: src}
+}
+
+// NextInterval returns the next interval of the source. Returns
+// (math.MaxUint64, 0) at end of stream.
+func (r *RunIntervals) NextInterval() (first, last uint64) {
+	for start, length, ok := r.src.NextRun(); ok; start, length, ok = r.src.NextRun() {
+		if length == 0 {
+			continue
+		}
+		if first, last, ok := r.pending.add(start, start+length-1); ok {
+			return first, last
+		}
+	}
+	return r.pending.flush()
+}
+
+// BitIntervals yields the values of a BitSource as Intervals of consecutive
+// values.
+type BitIntervals struct {
+	src     BitSource
+	pending pendingInterval
+}
+
+// NewBitIntervals returns a BitIntervals over src.
+func NewBitIntervals(src BitSource) *BitIntervals {
+	return &BitIntervals{src: src}
+}
+
+// NextInterval returns the next interval of consecutive values of the source.
+// Returns (math.MaxUint64, 0) at end of stream.
+func (b *BitIntervals) NextInterval() (first, last uint64) {
+	for v, ok := b.src.NextBit(); ok; v, ok = b.src.NextBit() {
+		if first, last, ok := b.pending.add(v, v); ok {
+			return first, last
+		}
+	}
+	return b.pending.flush()
+}
+
+// AddIntervals adds the intervals of src to the list being built. Returns
+// false if an interval is not greater than all previous values, in which case
+// it and the rest of src are ignored.
+func (b *Builder) AddIntervals(src Intervals) bool {
+	for first, last := src.NextInterval(); first <= last; first, last = src.NextInterval() {
+		if first < b.n {
+			return false
+		}
+		b.interval(first, last)
+	}
+	return true
+}
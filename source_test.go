@@ -0,0 +1,95 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+// runSlice is a RunSource over pairs of start and length, like the run
+// containers of a bitmap library.
+type runSlice [][2]uint64
+
+func (r *runSlice) NextRun() (start, length uint64, ok bool) {
+	if len(*r) == 0 {
+		return 0, 0, false
+	}
+	run := (*r)[0]
+	*r = (*r)[1:]
+	return run[0], run[1], true
+}
+
+// bitSlice is a BitSource over a slice of values.
+type bitSlice []uint64
+
+func (b *bitSlice) NextBit() (v uint64, ok bool) {
+	if len(*b) == 0 {
+		return 0, false
+	}
+	v = (*b)[0]
+	*b = (*b)[1:]
+	return v, true
+}
+
+func Test_RunIntervals(t *testing.T) {
+	// Abutting runs, such as those split at container boundaries, are
+	// coalesced, and empty runs are dropped.
+	runs := runSlice{{1, 2}, {3, 5}, {10, 0}, {12, 1}, {65530, 6}, {65536, 4}}
+	l := UnionOf(NewRunIntervals(&runs))
+	expectValues(t, l.Expand(), makeRange(intrv{1, 7}, intrv{12, 12}, intrv{65530, 65539}).Expand())
+	if n := l.NumIntervals(); n != 3 {
+		t.Errorf("NumIntervals() = %d, expected 3", n)
+	}
+
+	runs = runSlice{}
+	if l := UnionOf(NewRunIntervals(&runs)); !l.IsEmpty() {
+		t.Errorf("UnionOf(no runs) = %v", l.Expand())
+	}
+
+	// Set operations with lists
+	runs = runSlice{{0, 10}, {20, 10}}
+	iter := makeRange(intrv{5, 24}).Iterate()
+	expectValues(t, IntersectionOf(NewRunIntervals(&runs), &iter).Expand(), makeRange(intrv{5, 9}, intrv{20, 24}).Expand())
+}
+
+func Test_BitIntervals(t *testing.T) {
+	bits := bitSlice{0, 1, 2, 5, 7, 8, 100}
+	iter := NewBitIntervals(&bits)
+	expected := []intrv{{0, 2}, {5, 5}, {7, 8}, {100, 100}}
+	for _, e := range expected {
+		if first, last := iter.NextInterval(); first != uint64(e[0]) || last != uint64(e[1]) {
+			t.Errorf("NextInterval() = [%d, %d], expected %v", first, last, e)
+		}
+	}
+	if first, last := iter.NextInterval(); first <= last {
+		t.Errorf("NextInterval() = [%d, %d], expected EOS", first, last)
+	}
+
+	bits = bitSlice{3, 4, 10}
+	li := makeRange(intrv{0, 3}, intrv{9, 9}).Iterate()
+	expectValues(t, UnionOf(NewBitIntervals(&bits), &li).Expand(), []uint64{0, 1, 2, 3, 4, 9, 10})
+}
+
+func Test_Builder_AddIntervals(t *testing.T) {
+	l := List{}
+	b := Build(&l)
+	b.Next(0)
+	runs := runSlice{{2, 3}, {5, 1}, {9, 2}}
+	if !b.AddIntervals(NewRunIntervals(&runs)) {
+		t.Fatal("AddIntervals() = false")
+	}
+	bits := bitSlice{11, 12, 20}
+	if !b.AddIntervals(NewBitIntervals(&bits)) {
+		t.Fatal("AddIntervals() = false")
+	}
+	expectValues(t, b.Finish().Expand(), []uint64{0, 2, 3, 4, 5, 9, 10, 11, 12, 20})
+	if l.NumIntervals() != 4 {
+		t.Errorf("NumIntervals() = %d, expected 4", l.NumIntervals())
+	}
+
+	b = Build(&l)
+	b.Next(10)
+	bits = bitSlice{10, 11}
+	if b.AddIntervals(NewBitIntervals(&bits)) {
+		t.Error("AddIntervals() of out of order values = true")
+	}
+	expectValues(t, b.Finish().Expand(), []uint64{10})
+}
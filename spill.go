@@ -0,0 +1,244 @@
+package skiptake
+
+import (
+	"io"
+	"os"
+)
+
+// Set algebra over more lists than fit in memory at once, spilling partial
+// results to temporary files.
+
+// defaultSpillBudget is the memory budget used when SpillOptions.Budget is not
+// set.
+const defaultSpillBudget = 64 << 20
+
+// spillFanIn is how many spill files of the same generation are merged
+// together into one, which bounds how many files are open at once.
+const spillFanIn = 64
+
+// SpillOptions configures a SpillUnion.
+type SpillOptions struct {
+	// Budget is how many bytes of added lists are held before they are merged
+	// and spilled to a temporary file. Defaults to 64MiB.
+	Budget int
+
+	// Dir is the directory temporary files are created in. Defaults to
+	// os.TempDir().
+	Dir string
+}
+
+// SpillUnion computes the union of lists added one at a time, while holding
+// at most a budget of them in memory. Once the added lists exceed the budget,
+// they are merged and written to a temporary file with a StreamEncoder, and
+// released. The files are merged at the end, a window at a time. Eg:
+//
+//		u := skiptake.NewSpillUnion(skiptake.SpillOptions{Budget: 256 << 20})
+//		defer u.Close()
+//		for _, name := range names {
+//			if err := u.Add(load(name)); err != nil {
+//				...
+//			}
+//		}
+//		_, err := u.WriteTo(w)
+//
+// Close must be called to remove the temporary files.
+type SpillUnion struct {
+	opts    SpillOptions
+	pending []List
+	size    int // Bytes in pending
+	files   []spillFile
+}
+
+// spillFile is a temporary file holding the union of some of the added lists.
+// Files of a later generation are merged from spillFanIn of an earlier one.
+type spillFile struct {
+	name string
+	gen  int
+}
+
+// NewSpillUnion returns an empty SpillUnion.
+func NewSpillUnion(opts SpillOptions) *SpillUnion {
+	if opts.Budget <= 0 {
+		opts.Budget = defaultSpillBudget
+	}
+	return &SpillUnion{opts: opts}
+}
+
+// Add adds the list l to the union. The list is held, and so must not be
+// modified, until Add spills it, which happens once the lists held exceed the
+// budget. Returns an error if spilling fails.
+func (u *SpillUnion) Add(l List) error {
+	u.pending = append(u.pending, l)
+	if u.size += len(l); u.size < u.opts.Budget {
+		return nil
+	}
+	return u.spill()
+}
+
+// WriteTo writes the union of the added lists to w, as the packed bytes of a
+// List. Returns the number of bytes written.
+func (u *SpillUnion) WriteTo(w io.Writer) (int64, error) {
+	s := NewStreamEncoder(w)
+	err := u.merge(func(sources []Intervals) {
+		union(&s.b, sourceAll(sources))
+	})
+	s.Flush()
+	if err == nil {
+		err = s.Err()
+	}
+	return s.n, err
+}
+
+// List returns the union of the added lists in memory.
+func (u *SpillUnion) List() (List, error) {
+	b := Build(&List{})
+	err := u.merge(func(sources []Intervals) {
+		union(&b, sourceAll(sources))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.Finish(), nil
+}
+
+// WriteDifference writes the difference of a and the union of the added
+// lists to w, as the packed bytes of a List. Returns the number of bytes
+// written.
+func (u *SpillUnion) WriteDifference(w io.Writer, a List) (int64, error) {
+	s := NewStreamEncoder(w)
+	err := u.merge(func(sources []Intervals) {
+		iter := a.Iterate()
+		s.b.AddIntervals(newDifferenceIntervals(&iter, unionTree(sources)))
+	})
+	s.Flush()
+	if err == nil {
+		err = s.Err()
+	}
+	return s.n, err
+}
+
+// Close removes the temporary files, and releases the lists held.
+func (u *SpillUnion) Close() error {
+	var err error
+	for _, f := range u.files {
+		if e := os.Remove(f.name); e != nil && err == nil {
+			err = e
+		}
+	}
+	u.files = nil
+	u.pending = nil
+	u.size = 0
+	return err
+}
+
+// spill merges the held lists to a new file, then merges the latest files
+// while spillFanIn of them share a generation.
+func (u *SpillUnion) spill() error {
+	name, err := u.create(func(w io.Writer) error {
+		_, err := UnionTo(w, u.pending...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for i := range u.pending {
+		u.pending[i] = nil
+	}
+	u.pending = u.pending[:0]
+	u.size = 0
+	u.files = append(u.files, spillFile{name: name})
+
+	for len(u.files) >= spillFanIn {
+		last := u.files[len(u.files)-spillFanIn:]
+		if last[0].gen != last[len(last)-1].gen {
+			break
+		}
+		name, err := u.create(func(w io.Writer) error {
+			return mergeFiles(last, func(sources []Intervals) error {
+				s := NewStreamEncoder(w)
+				union(&s.b, sourceAll(sources))
+				s.Flush()
+				return s.Err()
+			})
+		})
+		if err != nil {
+			return err
+		}
+		for _, f := range last {
+			os.Remove(f.name)
+		}
+		u.files = append(u.files[:len(u.files)-spillFanIn], spillFile{name: name, gen: last[0].gen + 1})
+	}
+	return nil
+}
+
+// create writes a new temporary file with fn, returning its name. The file is
+// removed if fn fails.
+func (u *SpillUnion) create(fn func(w io.Writer) error) (string, error) {
+	f, err := os.CreateTemp(u.opts.Dir, "skiptake-spill-*")
+	if err != nil {
+		return "", err
+	}
+	err = fn(f)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// merge calls fn with sources of the intervals of every file and held list.
+func (u *SpillUnion) merge(fn func(sources []Intervals)) error {
+	return mergeFiles(u.files, func(sources []Intervals) error {
+		for _, c := range iterateAll(u.pending) {
+			sources = append(sources, c.src)
+		}
+		fn(sources)
+		return nil
+	})
+}
+
+// mergeFiles opens files, and calls fn with sources of their intervals.
+// Returns the first error from opening or reading them, or from fn.
+func mergeFiles(files []spillFile, fn func(sources []Intervals) error) error {
+	readers := make([]*readerIntervals, 0, len(files))
+	sources := make([]Intervals, 0, len(files))
+	defer func() {
+		for _, r := range readers {
+			r.r.(*os.File).Close()
+		}
+	}()
+	for _, f := range files {
+		file, err := os.Open(f.name)
+		if err != nil {
+			return err
+		}
+		r := newReaderIntervals(file)
+		readers = append(readers, r)
+		sources = append(sources, r)
+	}
+	err := fn(sources)
+	for _, r := range readers {
+		if err == nil {
+			err = r.err
+		}
+	}
+	return err
+}
+
+// unionTree returns a source of the union of sources, as a balanced tree of
+// unionIntervals.
+func unionTree(sources []Intervals) Intervals {
+	switch len(sources) {
+	case 0:
+		iter := List{}.Iterate()
+		return &iter
+	case 1:
+		return sources[0]
+	}
+	mid := len(sources) / 2
+	return newUnionIntervals(unionTree(sources[:mid]), unionTree(sources[mid:]))
+}
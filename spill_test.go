@@ -0,0 +1,91 @@
+package skiptake
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// spillTestLists returns n random lists of a few hundred values each.
+func spillTestLists(n int) []List {
+	r := rand.New(rand.NewSource(1))
+	lists := make([]List, n)
+	for i := range lists {
+		b := Build(&List{})
+		v := uint64(r.Intn(1000))
+		for k := 0; k < 200; k++ {
+			b.Next(v)
+			b.Take(uint64(r.Intn(3)))
+			v += uint64(r.Intn(5000)) + 5
+		}
+		lists[i] = b.Finish()
+	}
+	return lists
+}
+
+func Test_SpillUnion(t *testing.T) {
+	lists := spillTestLists(300)
+	expected := Union(lists...)
+	minuend := makeRange(intrv{100, 200000}, intrv{400000, 900000})
+
+	// A budget of a few lists, so that spill files are merged across more
+	// than one generation.
+	dir := t.TempDir()
+	u := NewSpillUnion(SpillOptions{Budget: 3 * len(lists[0]), Dir: dir})
+	for _, l := range lists {
+		if err := u.Add(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(u.files) == 0 || u.files[0].gen == 0 {
+		t.Errorf("Expected merged spill files, have %v", u.files)
+	}
+
+	var out bytes.Buffer
+	if n, err := u.WriteTo(&out); err != nil || n != int64(out.Len()) {
+		t.Errorf("WriteTo() = %d, %v", n, err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Errorf("WriteTo() differs from Union()")
+	}
+	if l, err := u.List(); err != nil || !bytes.Equal(l, expected) {
+		t.Errorf("List() differs from Union(), %v", err)
+	}
+	out.Reset()
+	if _, err := u.WriteDifference(&out, minuend); err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(out.Bytes(), Difference(minuend, expected)) {
+		t.Errorf("WriteDifference() differs from Difference()")
+	}
+
+	if err := u.Close(); err != nil {
+		t.Error(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d temporary files left after Close()", len(entries))
+	}
+}
+
+func Test_SpillUnion_Empty(t *testing.T) {
+	u := NewSpillUnion(SpillOptions{Dir: t.TempDir()})
+	defer u.Close()
+	if l, err := u.List(); err != nil || len(l) != 0 {
+		t.Errorf("List() = %v, %v", l, err)
+	}
+	a := Create(1, 2, 3, 10)
+	u.Add(Create(2, 3))
+	var out bytes.Buffer
+	if _, err := u.WriteDifference(&out, a); err != nil || !bytes.Equal(out.Bytes(), Create(1, 10)) {
+		t.Errorf("WriteDifference() = %v, %v", out.Bytes(), err)
+	}
+}
+
+func Test_SpillUnion_BadDir(t *testing.T) {
+	u := NewSpillUnion(SpillOptions{Budget: 1, Dir: "/nonexistent/skiptake"})
+	defer u.Close()
+	if err := u.Add(Create(1, 2)); err == nil {
+		t.Errorf("Expected an error spilling to a missing directory")
+	}
+}
@@ -0,0 +1,55 @@
+package skiptake
+
+import (
+	"fmt"
+	"math"
+)
+
+// SizeStats reports the size of a list, along with the estimated size of the
+// same set of values held in other common representations. All sizes are in
+// bytes. Sizes which would exceed math.MaxUint64 are reported as
+// math.MaxUint64.
+type SizeStats struct {
+	Bytes          uint64  // Size of the packed list
+	Len            uint64  // Number of members
+	Intervals      uint64  // Number of intervals of consecutive members
+	BytesPerMember float64 // Bytes / Len, or zero for an empty list
+	BitmapBytes    uint64  // Size as a dense bitmap, from zero to the largest member
+	RawBytes       uint64  // Size as a []uint64 of members
+	IntervalBytes  uint64  // Size as a slice of (first, last) uint64 pairs
+}
+
+// SizeStats returns a report of the size of the list, compared with other
+// representations.
+func (l List) SizeStats() SizeStats {
+	s := SizeStats{
+		Bytes:     uint64(len(l)),
+		Len:       l.Len(),
+		Intervals: l.NumIntervals(),
+	}
+	if s.Len > 0 {
+		s.BytesPerMember = float64(s.Bytes) / float64(s.Len)
+	}
+	if _, last, ok := l.Bounds(); ok {
+		s.BitmapBytes = last/8 + 1
+	}
+	s.RawBytes = saturatingMul(s.Len, 8)
+	s.IntervalBytes = saturatingMul(s.Intervals, 16)
+	return s
+}
+
+// String implements the fmt.Stringer interface, as a one line report. Eg:
+//
+//		1234 bytes, 100000 members in 52 intervals (0.0123 bytes/member); bitmap 12500 bytes, raw 800000 bytes, intervals 832 bytes
+//
+func (s SizeStats) String() string {
+	return fmt.Sprintf("%d bytes, %d members in %d intervals (%.4g bytes/member); bitmap %d bytes, raw %d bytes, intervals %d bytes",
+		s.Bytes, s.Len, s.Intervals, s.BytesPerMember, s.BitmapBytes, s.RawBytes, s.IntervalBytes)
+}
+
+func saturatingMul(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
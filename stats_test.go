@@ -0,0 +1,31 @@
+package skiptake
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_SizeStats(t *testing.T) {
+	l := makeRange(intrv{0, 99}, intrv{1000, 1099}, intrv{8000, 8000})
+	s := l.SizeStats()
+	t.Logf("%v", s)
+
+	expectUint64(t, s.Bytes, uint64(len(l)))
+	expectUint64(t, s.Len, 201)
+	expectUint64(t, s.Intervals, 3)
+	expectUint64(t, s.BitmapBytes, 1001)
+	expectUint64(t, s.RawBytes, 201*8)
+	expectUint64(t, s.IntervalBytes, 3*16)
+	if s.BytesPerMember != float64(len(l))/201 {
+		t.Errorf("BytesPerMember = %v", s.BytesPerMember)
+	}
+
+	empty := List{}.SizeStats()
+	if empty != (SizeStats{}) {
+		t.Errorf("SizeStats() of empty list = %+v", empty)
+	}
+
+	huge := makeRange(intrv{1, math.MaxUint64 - 1}).SizeStats()
+	expectUint64(t, huge.RawBytes, math.MaxUint64)
+	expectUint64(t, huge.BitmapBytes, math.MaxUint64/8+1)
+}
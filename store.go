@@ -0,0 +1,273 @@
+package skiptake
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// Store holds a collection of named lists, and supports cheap point-in-time
+// snapshots of the collection.
+//
+// Lists held by a Store are treated as immutable, and are shared, not copied,
+// between the Store and its snapshots. A snapshot costs nothing to take, and
+// the first change to the Store following a snapshot copies only the mapping
+// of names to lists. Lists that are unchanged between versions share the same
+// memory. Lists passed to, or returned from, a Store must not be modified.
+//
+// Lists longer than storeSegmentSize bytes are held as segments, each holding
+// a range of the values, as for Segments. A list replaced by Put or Update
+// keeps the segments of the list it replaces whose values are unchanged, so an
+// edit to part of a long list, such as adding a few values, costs only the
+// segments it touches, and versions share the rest. Get joins the segments of
+// such a list into a new List. Segments() returns them without joining.
+//
+// The zero Store is empty, and ready to use. A Store is safe for concurrent
+// use.
+type Store struct {
+	mu     sync.RWMutex
+	lists  map[string]storedList
+	shared bool // If lists is referenced by a snapshot
+}
+
+// Snapshot is an immutable point-in-time copy of the lists in a Store.
+type Snapshot struct {
+	lists map[string]storedList
+}
+
+// NewStore returns a new empty Store.
+func NewStore() *Store {
+	return &Store{lists: map[string]storedList{}}
+}
+
+// Get returns the named list.
+func (s *Store) Get(name string) (List, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, ok := s.lists[name]
+	return l.list(), ok
+}
+
+// Segments returns the named list as a Segments container over the segments
+// it is held as, which can be iterated without joining them.
+func (s *Store) Segments(name string) (*Segments, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, ok := s.lists[name]
+	return l.segments(), ok
+}
+
+// Names returns the names of all lists in the store, in sorted order.
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedNames(s.lists)
+}
+
+// Put stores the list l under the passed name, replacing any existing list.
+func (s *Store) Put(name string, l List) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := storeList(l, s.lists[name])
+	s.unshare()
+	s.lists[name] = stored
+}
+
+// Delete removes the named list.
+func (s *Store) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lists[name]; ok {
+		s.unshare()
+		delete(s.lists, name)
+	}
+}
+
+// Update replaces the named list with the result of fn, which is passed the
+// current list, or nil if there is none. fn must return a new list rather than
+// modify the one passed to it, Eg:
+//
+//		s.Update("selection", func(l List) List {
+//			return Union(l, added)
+//		})
+//
+// Other changes to the store wait until fn returns.
+func (s *Store) Update(name string, fn func(List) List) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.lists[name]
+	stored := storeList(fn(old.list()), old)
+	s.unshare()
+	s.lists[name] = stored
+}
+
+// Snapshot returns a point-in-time copy of the store.
+func (s *Store) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shared = true
+	return &Snapshot{lists: s.lists}
+}
+
+// unshare copies the mapping of names to lists if it is referenced by a
+// snapshot, or creates it if there is none.
+func (s *Store) unshare() {
+	if s.lists == nil {
+		s.lists = map[string]storedList{}
+	}
+	if !s.shared {
+		return
+	}
+	lists := make(map[string]storedList, len(s.lists))
+	for name, l := range s.lists {
+		lists[name] = l
+	}
+	s.lists = lists
+	s.shared = false
+}
+
+// Get returns the named list as it was at the time of the snapshot.
+func (s *Snapshot) Get(name string) (List, bool) {
+	l, ok := s.lists[name]
+	return l.list(), ok
+}
+
+// Segments returns the named list as it was at the time of the snapshot, as
+// Store.Segments().
+func (s *Snapshot) Segments(name string) (*Segments, bool) {
+	l, ok := s.lists[name]
+	return l.segments(), ok
+}
+
+// Names returns the names of all lists in the snapshot, in sorted order.
+func (s *Snapshot) Names() []string {
+	return sortedNames(s.lists)
+}
+
+func sortedNames(lists map[string]storedList) []string {
+	names := make([]string, 0, len(lists))
+	for name := range lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// storeSegmentSize is the length in bytes beyond which a list held by a Store
+// is split into segments.
+const storeSegmentSize = 4096
+
+// storedList is a list held by a Store, as segments holding absolute values in
+// ascending order. Segments are immutable, and shared between versions.
+type storedList struct {
+	segs []List
+	info []SegmentInfo
+}
+
+// storeList returns l as a storedList, reusing the segments of old, the list
+// it replaces, which hold the same values. A list of up to storeSegmentSize
+// bytes is held whole, without copying it.
+//
+// A longer list is split at the first values of the segments of old, so that
+// each part can be compared with the segment of old holding the same range of
+// values. A part which is unchanged, such as the prefix and suffix of a list
+// left alone by an edit, keeps its segment. Parts which differ are split into
+// new segments of about storeSegmentSize bytes.
+func storeList(l List, old storedList) storedList {
+	var s storedList
+	if len(l) <= storeSegmentSize {
+		s.add(l)
+		return s
+	}
+	if len(old.segs) < 2 {
+		s.split(l)
+		return s
+	}
+	var part List
+	b := Build(&part)
+	k := 0 // The segment of old whose range of values is being built
+	iter := l.Iterate()
+	first, last := iter.NextInterval()
+	for first <= last {
+		if k+1 < len(old.segs) && first >= old.info[k+1].First {
+			s.addPart(b.Finish(), old, k)
+			part, k = nil, k+1
+			b = Build(&part)
+			continue
+		}
+		end := last
+		if k+1 < len(old.segs) && last >= old.info[k+1].First {
+			end = old.info[k+1].First - 1
+		}
+		b.interval(first, end)
+		if end < last {
+			first = end + 1
+		} else {
+			first, last = iter.NextInterval()
+		}
+	}
+	s.addPart(b.Finish(), old, k)
+	return s
+}
+
+// addPart adds the part of a list in the range of values of the k'th segment
+// of old, reusing the segment if it holds the same values.
+func (s *storedList) addPart(part List, old storedList, k int) {
+	if bytes.Equal(part, old.segs[k]) {
+		s.segs = append(s.segs, old.segs[k])
+		s.info = append(s.info, old.info[k])
+		return
+	}
+	s.split(part)
+}
+
+// split adds the list l as segments of about storeSegmentSize bytes, split
+// between intervals.
+func (s *storedList) split(l List) {
+	var seg List
+	b := Build(&seg)
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		if b.Size() >= storeSegmentSize {
+			s.add(b.Finish())
+			seg = nil
+			b = Build(&seg)
+		}
+		b.interval(first, last)
+	}
+	if seg = b.Finish(); len(seg) > 0 {
+		s.add(seg)
+	}
+}
+
+// add adds seg as the next segment.
+func (s *storedList) add(seg List) {
+	first, last, _ := seg.Bounds()
+	s.segs = append(s.segs, seg)
+	s.info = append(s.info, SegmentInfo{First: first, Last: last, Len: seg.Len()})
+}
+
+// list returns the whole list. A list held as one segment is returned as is,
+// and otherwise the segments are joined into a new List. Returns nil for the
+// zero storedList.
+func (s storedList) list() List {
+	switch len(s.segs) {
+	case 0:
+		return nil
+	case 1:
+		return s.segs[0]
+	}
+	var l List
+	b := Build(&l)
+	for _, seg := range s.segs {
+		b.AppendList(seg, 0)
+	}
+	return b.Finish()
+}
+
+// segments returns a Segments container over the segments of the list.
+func (s storedList) segments() *Segments {
+	return NewSegments(s.info, func(i int) (List, error) {
+		return s.segs[i], nil
+	})
+}
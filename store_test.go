@@ -0,0 +1,127 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Store(t *testing.T) {
+	s := NewStore()
+	s.Put("a", Create(1, 2, 3))
+	s.Put("b", Create(10))
+
+	snap := s.Snapshot()
+
+	s.Update("a", func(l List) List {
+		return Union(l, Create(4))
+	})
+	s.Delete("b")
+	s.Put("c", Create(20))
+
+	if l, _ := s.Get("a"); !Equal(l, Create(1, 2, 3, 4)) {
+		t.Errorf("Store a = %v", l)
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Errorf("Store b not deleted")
+	}
+	if names := s.Names(); len(names) != 2 || names[0] != "a" || names[1] != "c" {
+		t.Errorf("Store names %v", names)
+	}
+
+	// The snapshot is unchanged.
+	if l, _ := snap.Get("a"); !Equal(l, Create(1, 2, 3)) {
+		t.Errorf("Snapshot a = %v", l)
+	}
+	if l, ok := snap.Get("b"); !ok || !Equal(l, Create(10)) {
+		t.Errorf("Snapshot b = %v", l)
+	}
+	if _, ok := snap.Get("c"); ok {
+		t.Errorf("Snapshot has c")
+	}
+
+	// Unchanged lists share memory between versions.
+	s.Put("d", Create(5, 6, 7))
+	snap2 := s.Snapshot()
+	s.Put("a", Create(0))
+	l1, _ := s.Get("d")
+	l2, _ := snap2.Get("d")
+	if &l1[0] != &l2[0] {
+		t.Errorf("Unchanged list was copied")
+	}
+}
+
+func Test_StoreZero(t *testing.T) {
+	var s Store
+	if _, ok := s.Get("a"); ok {
+		t.Error("Get() from zero Store found a list")
+	}
+	snap := s.Snapshot()
+	s.Put("a", Create(1))
+	s.Update("b", func(l List) List { return Create(2) })
+	if l, ok := s.Get("a"); !ok || !Equal(l, Create(1)) {
+		t.Errorf("Get() = %v, %v", l, ok)
+	}
+	if names := snap.Names(); len(names) != 0 {
+		t.Errorf("Snapshot of zero Store holds %v", names)
+	}
+}
+
+func Test_StoreSegments(t *testing.T) {
+	// A list long enough to be held as many segments.
+	var values []uint64
+	for v := uint64(0); v < 200000; v += 2 + v%7 {
+		values = append(values, v)
+	}
+	list := Create(values...)
+	s := NewStore()
+	s.Put("a", list)
+	snap := s.Snapshot()
+
+	// Adding a value in the middle of the list keeps the segments before and
+	// after it.
+	s.Update("a", func(l List) List {
+		return Union(l, Create(100001))
+	})
+	expected := Union(list, Create(100001))
+	if l, _ := s.Get("a"); !Equal(l, expected) {
+		t.Errorf("Store a differs after Update()")
+	}
+	if l, _ := snap.Get("a"); !Equal(l, list) {
+		t.Errorf("Snapshot a differs after Update()")
+	}
+
+	before, _ := snap.Segments("a")
+	after, _ := s.Segments("a")
+	if before.NumSegments() < 4 {
+		t.Fatalf("List held as %d segments", before.NumSegments())
+	}
+	shared := map[*byte]bool{}
+	for i := 0; i < before.NumSegments(); i++ {
+		seg, _ := before.load(i)
+		shared[&seg[0]] = true
+	}
+	copied := 0
+	for i := 0; i < after.NumSegments(); i++ {
+		if seg, _ := after.load(i); !shared[&seg[0]] {
+			copied++
+		}
+	}
+	if copied == 0 || copied > 2 {
+		t.Errorf("Update() copied %d of %d segments", copied, after.NumSegments())
+	}
+
+	// The segments iterate as the list.
+	if l := UnionOf(after.Iterate()); !Equal(l, expected) {
+		t.Errorf("Segments() differ from the list")
+	}
+
+	// Replacing the list with itself copies nothing.
+	s.Put("a", expected)
+	again, _ := s.Segments("a")
+	for i := 0; i < again.NumSegments(); i++ {
+		a, _ := again.load(i)
+		b, _ := after.load(i)
+		if &a[0] != &b[0] {
+			t.Errorf("Put() of an unchanged list copied segment %d", i)
+		}
+	}
+}
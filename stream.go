@@ -0,0 +1,315 @@
+package skiptake
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// streamBufferSize is how many bytes a StreamEncoder holds before writing them
+// out.
+const streamBufferSize = 4096
+
+// StreamEncoder packs a list directly to an io.Writer, holding only a small
+// buffer, so lists larger than memory can be written. The bytes written are
+// exactly those of the List that a Builder or Encoder would produce.
+//
+// A StreamEncoder is fed either values, with Next, Skip and Take as for a
+// Builder, or raw skip-take pairs with Add, but not both. Flush must be called
+// after the last value or pair to write out the remainder of the list. Eg:
+//
+//		s := skiptake.NewStreamEncoder(w)
+//		for _, v := range values {
+//			s.Next(v)
+//		}
+//		s.Flush()
+//		if err := s.Err(); err != nil {
+//			...
+//		}
+//
+// Once a write fails, nothing more is written, and the error is returned by
+// Err.
+type StreamEncoder struct {
+	w   io.Writer
+	buf List
+	b   Builder
+	n   int64
+	err error
+}
+
+// NewStreamEncoder returns a StreamEncoder writing to w.
+func NewStreamEncoder(w io.Writer) *StreamEncoder {
+	s := &StreamEncoder{w: w}
+	s.b = Build(&s.buf)
+	s.b.stream = s
+	return s
+}
+
+// Next feeds the next value of a strictly increasing sequence. See
+// Builder.Next().
+func (s *StreamEncoder) Next(n uint64) bool {
+	return s.b.Next(n)
+}
+
+// Skip adds a skip value, which implies a take of one. See Builder.Skip().
+func (s *StreamEncoder) Skip(skip uint64) {
+	s.b.Skip(skip)
+}
+
+// Take increases the current take count. See Builder.Take().
+func (s *StreamEncoder) Take(take uint64) {
+	s.b.Take(take)
+}
+
+// Add adds a raw skip-take pair, as Encoder.Add().
+func (s *StreamEncoder) Add(skip, take uint64) {
+	s.b.Encoder.Add(skip, take)
+	s.drain(false)
+}
+
+// Flush writes out the remainder of the list. Nothing may be added after
+// Flush.
+func (s *StreamEncoder) Flush() {
+	s.b.flush()
+	s.b.take = 0
+	s.drain(true)
+}
+
+// Err returns the first error from writing, if any.
+func (s *StreamEncoder) Err() error {
+	return s.err
+}
+
+// Written returns how many bytes have been written.
+func (s *StreamEncoder) Written() int64 {
+	return s.n
+}
+
+// drain writes out the buffered bytes which are final, once enough have
+// collected, or all of them if all is set. The Encoder rewrites the bytes
+// following the first pair of a run as the run grows, so only bytes before
+// the end of that pair are final.
+func (s *StreamEncoder) drain(all bool) {
+	end := s.b.Encoder.runEnd
+	if all {
+		end = len(s.buf)
+	} else if end < streamBufferSize {
+		return
+	}
+	if s.err == nil {
+		var n int
+		n, s.err = s.w.Write(s.buf[:end])
+		s.n += int64(n)
+	}
+	s.buf = s.buf[:copy(s.buf, s.buf[end:])]
+	if s.b.Encoder.runEnd -= end; s.b.Encoder.runEnd < 0 {
+		s.b.Encoder.runEnd = 0
+	}
+}
+
+// UnionTo writes the union of the passed lists to w, as the packed bytes of a
+// List, without holding the result in memory. Returns the number of bytes
+// written. The bytes written are the same as those of Union(lists...).
+func UnionTo(w io.Writer, lists ...List) (int64, error) {
+	s := NewStreamEncoder(w)
+	lists, same := distinct(lists)
+	if same {
+		s.write(lists[0])
+		return s.n, s.err
+	}
+	scratch := acquireMerge(lists)
+	union(&s.b, scratch.cur)
+	scratch.release()
+	s.Flush()
+	return s.n, s.err
+}
+
+// IntersectionTo writes the intersection of the passed lists to w, as the
+// packed bytes of a List, without holding the result in memory. Returns the
+// number of bytes written. The bytes written are the same as those of
+// Intersection(lists...).
+func IntersectionTo(w io.Writer, lists ...List) (int64, error) {
+	s := NewStreamEncoder(w)
+	lists, same := distinct(lists)
+	if same {
+		s.write(lists[0])
+		return s.n, s.err
+	}
+	max, ok := intersectionBound(lists)
+	if !ok {
+		return 0, nil
+	}
+	scratch := acquireMerge(lists)
+	scratch.bySelectivity()
+	intersection(&s.b, scratch.cur, max)
+	scratch.release()
+	s.Flush()
+	return s.n, s.err
+}
+
+// write writes the whole list l.
+func (s *StreamEncoder) write(l List) {
+	var n int
+	n, s.err = s.w.Write(l)
+	s.n += int64(n)
+}
+
+// streamRecordMax is the most bytes a single pair can be packed into: a skip, a
+// take and a repeat count.
+const streamRecordMax = 3 * binary.MaxVarintLen64
+
+// readerIntervals yields the intervals of the packed bytes of a List read from
+// an io.Reader, holding only a small window of them. The intervals are read by
+// an Iterator, which refills the window before each pair. A read error ends
+// the intervals early, and is kept in err.
+type readerIntervals struct {
+	r    io.Reader
+	buf  List
+	d    Decoder
+	iter Iterator
+	eof  bool
+	err  error
+}
+
+func newReaderIntervals(r io.Reader) *readerIntervals {
+	s := &readerIntervals{r: r, buf: make(List, streamBufferSize)}
+	s.iter = Iterator{Decoder: &s.d, fill: s.fill}
+	return s
+}
+
+// fill reads more of the list once fewer bytes than a whole pair remain
+// decoded, so the Decoder never reads a pair cut short by the window.
+func (s *readerIntervals) fill() {
+	k := len(s.d.Elements) - s.d.i
+	if s.eof || k >= streamRecordMax {
+		return
+	}
+	k = copy(s.buf, s.d.Elements[s.d.i:])
+	for k < streamRecordMax && !s.eof {
+		n, err := s.r.Read(s.buf[k:])
+		k += n
+		if err == io.EOF {
+			s.eof = true
+		} else if err != nil {
+			s.eof, s.err = true, err
+			k = 0
+		}
+	}
+	s.d.Elements, s.d.i = s.buf[:k], 0
+}
+
+// NextInterval returns the next interval, as Iterator.NextInterval().
+func (s *readerIntervals) NextInterval() (first, last uint64) {
+	return s.iter.NextInterval()
+}
+
+// UnionReaders writes the union of the lists read from readers to w, as the
+// packed bytes of a List. Each reader holds a single frame packed with
+// CodecVarint, as written by List.AppendTo(). Only a small window of each
+// list is held in memory, so lists far larger than memory can be merged. Eg:
+//
+//		var readers []io.Reader
+//		for _, f := range files {
+//			readers = append(readers, bufio.NewReader(f))
+//		}
+//		n, err := skiptake.UnionReaders(out, readers...)
+//
+// Exactly one frame is read from each reader, to its end, so readers may be
+// positioned within a stream of concatenated frames. Returns the number of
+// bytes written.
+func UnionReaders(w io.Writer, readers ...io.Reader) (int64, error) {
+	return mergeReaders(w, readers, func(b *Builder, cur []cursor) {
+		union(b, cur)
+	})
+}
+
+// IntersectionReaders writes the intersection of the lists read from readers
+// to w, as the packed bytes of a List. See UnionReaders().
+func IntersectionReaders(w io.Writer, readers ...io.Reader) (int64, error) {
+	return mergeReaders(w, readers, func(b *Builder, cur []cursor) {
+		intersection(b, cur, math.MaxUint64)
+	})
+}
+
+// mergeReaders calls merge with cursors over the frames read from readers,
+// building into a StreamEncoder writing to w.
+func mergeReaders(w io.Writer, readers []io.Reader, merge func(b *Builder, cur []cursor)) (int64, error) {
+	src := make([]*readerIntervals, len(readers))
+	payloads := make([]io.Reader, len(readers))
+	cur := make([]cursor, len(readers))
+	for i, r := range readers {
+		payload, err := streamFrame(r)
+		if err != nil {
+			return 0, err
+		}
+		src[i], payloads[i] = newReaderIntervals(payload), payload
+		cur[i].src = src[i]
+	}
+	s := NewStreamEncoder(w)
+	merge(&s.b, cur)
+	s.Flush()
+	for i, r := range src {
+		if r.err != nil {
+			return s.n, r.err
+		}
+		// A merge which stops early, such as an intersection, leaves the
+		// rest of the frame unread.
+		if _, err := io.Copy(io.Discard, payloads[i]); err != nil {
+			return s.n, err
+		}
+	}
+	return s.n, s.err
+}
+
+// streamFrame reads the header of the frame at the start of r. Returns a
+// reader of its payload.
+func streamFrame(r io.Reader) (io.Reader, error) {
+	br := &byteReader{r: r}
+	id, err := br.ReadByte()
+	if err != nil {
+		return nil, ErrShortFrame
+	}
+	if CodecID(id) != CodecVarint {
+		return nil, ErrStreamCodec
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, ErrShortFrame
+	}
+	return &payloadReader{r: r, n: size}, nil
+}
+
+// byteReader reads single bytes from an io.Reader without buffering ahead.
+type byteReader struct {
+	r io.Reader
+	b [1]byte
+}
+
+func (br *byteReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(br.r, br.b[:])
+	return br.b[0], err
+}
+
+// payloadReader reads the n bytes of a frame payload, returning ErrShortFrame
+// if the underlying reader ends first.
+type payloadReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (p *payloadReader) Read(b []byte) (int, error) {
+	if p.n == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(b)) > p.n {
+		b = b[:p.n]
+	}
+	k, err := p.r.Read(b)
+	p.n -= uint64(k)
+	if err == io.EOF && p.n > 0 {
+		err = ErrShortFrame
+	} else if err == io.EOF {
+		err = nil
+	}
+	return k, err
+}
@@ -0,0 +1,223 @@
+package skiptake
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+// streamTestLists returns lists large enough to be written out in several
+// parts, mixing irregular intervals with long arithmetic progressions.
+func streamTestLists() []List {
+	r := rand.New(rand.NewSource(1))
+	var lists []List
+	for i := 0; i < 3; i++ {
+		b := Build(&List{})
+		v := uint64(i)
+		for k := 0; k < 20000; k++ {
+			if k%5000 < 2000 {
+				v += 3 // Progression, encoded as a run
+				b.Next(v)
+			} else {
+				v += uint64(r.Intn(300)) + 2
+				b.Next(v)
+				b.Take(uint64(r.Intn(2)))
+			}
+			v++
+		}
+		lists = append(lists, b.Finish())
+	}
+	return lists
+}
+
+func Test_StreamEncoder(t *testing.T) {
+	for i, l := range streamTestLists() {
+		var values, pairs bytes.Buffer
+		s := NewStreamEncoder(&values)
+		iter := l.Iterate()
+		for n := iter.Next(); !iter.EOS(); n = iter.Next() {
+			s.Next(n)
+		}
+		s.Flush()
+		s.Flush()
+
+		p := NewStreamEncoder(&pairs)
+		d := l.Decode()
+		Transcode(p, &d)
+
+		for name, out := range map[string]*bytes.Buffer{"values": &values, "pairs": &pairs} {
+			if !bytes.Equal(out.Bytes(), l) {
+				t.Errorf("List %d streamed from %s differs: %d bytes, expected %d", i, name, out.Len(), len(l))
+			}
+		}
+		if s.Written() != int64(len(l)) || s.Err() != nil {
+			t.Errorf("List %d: Written() = %d, Err() = %v", i, s.Written(), s.Err())
+		}
+	}
+}
+
+func Test_UnionIntersectionTo(t *testing.T) {
+	lists := streamTestLists()
+	cases := [][]List{
+		lists,
+		lists[:2],
+		{lists[0], lists[0]},
+		{lists[0], {}},
+		{lists[1], makeRange(intrv{1 << 40, 1 << 41})},
+		{},
+	}
+	for i, c := range cases {
+		var u, n bytes.Buffer
+		un, err := UnionTo(&u, c...)
+		if err != nil || un != int64(u.Len()) || !bytes.Equal(u.Bytes(), Union(c...)) {
+			t.Errorf("Case %d: UnionTo() wrote %d bytes, %v, differing from Union()", i, un, err)
+		}
+		nn, err := IntersectionTo(&n, c...)
+		if err != nil || nn != int64(n.Len()) || !bytes.Equal(n.Bytes(), Intersection(c...)) {
+			t.Errorf("Case %d: IntersectionTo() wrote %d bytes, %v, differing from Intersection()", i, nn, err)
+		}
+	}
+}
+
+type failingWriter struct {
+	n int
+}
+
+var errTestWrite = errors.New("test write failure")
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	if w.n < len(b) {
+		n := w.n
+		w.n = 0
+		return n, errTestWrite
+	}
+	w.n -= len(b)
+	return len(b), nil
+}
+
+func Test_StreamEncoderError(t *testing.T) {
+	lists := streamTestLists()
+	w := &failingWriter{n: 5000}
+	n, err := UnionTo(w, lists...)
+	if err != errTestWrite || n != 5000 {
+		t.Errorf("UnionTo() = %d, %v, expected 5000, %v", n, err, errTestWrite)
+	}
+}
+
+func Test_ReaderIntervals(t *testing.T) {
+	lists := append(streamTestLists(), FromRaw(0, 3, 0, 2, 4, 0, 1, 1, 1, 1), List{})
+	for i, l := range lists {
+		// One byte reads shift the window for every pair.
+		src := newReaderIntervals(iotest.OneByteReader(bytes.NewReader(l)))
+		b := Build(&List{})
+		b.AddIntervals(src)
+		if got := b.Finish(); !equalUint64(got.Expand(), l.Expand()) || src.err != nil {
+			t.Errorf("List %d read back differs: %v, %v", i, got, src.err)
+		}
+	}
+
+	src := newReaderIntervals(iotest.TimeoutReader(bytes.NewReader(lists[0])))
+	b := Build(&List{})
+	b.AddIntervals(src)
+	if src.err != iotest.ErrTimeout {
+		t.Errorf("Read error = %v, expected %v", src.err, iotest.ErrTimeout)
+	}
+}
+
+func Test_UnionIntersectionReaders(t *testing.T) {
+	lists := streamTestLists()
+	cases := [][]List{
+		lists,
+		lists[:2],
+		{lists[0], {}},
+		{lists[1], makeRange(intrv{1 << 40, 1 << 41})},
+		{},
+	}
+	readers := func(c []List) []io.Reader {
+		var r []io.Reader
+		for _, l := range c {
+			r = append(r, iotest.HalfReader(bytes.NewReader(l.AppendTo(nil))))
+		}
+		return r
+	}
+	for i, c := range cases {
+		var u, n bytes.Buffer
+		un, err := UnionReaders(&u, readers(c)...)
+		if err != nil || un != int64(u.Len()) || !bytes.Equal(u.Bytes(), Union(c...)) {
+			t.Errorf("Case %d: UnionReaders() wrote %d bytes, %v, differing from Union()", i, un, err)
+		}
+		nn, err := IntersectionReaders(&n, readers(c)...)
+		if err != nil || nn != int64(n.Len()) || !bytes.Equal(n.Bytes(), Intersection(c...)) {
+			t.Errorf("Case %d: IntersectionReaders() wrote %d bytes, %v, differing from Intersection()", i, nn, err)
+		}
+	}
+
+	// Only the first frame is read.
+	frames := bytes.NewReader(lists[1].AppendTo(lists[0].AppendTo(nil)))
+	var out bytes.Buffer
+	if _, err := UnionReaders(&out, frames); err != nil || !bytes.Equal(out.Bytes(), lists[0]) {
+		t.Errorf("UnionReaders() of first frame differs, %v", err)
+	}
+	out.Reset()
+	if _, err := UnionReaders(&out, frames); err != nil || !bytes.Equal(out.Bytes(), lists[1]) {
+		t.Errorf("UnionReaders() of second frame differs, %v", err)
+	}
+
+	// An intersection ending early still reads each frame to its end.
+	var sparse []uint64
+	for v := uint64(0); v < 1<<20; v += 1 + v%7 {
+		sparse = append(sparse, v)
+	}
+	a := bytes.NewReader(lists[1].AppendTo(Create(sparse...).AppendTo(nil)))
+	b := bytes.NewReader(Create(0, 1).AppendTo(nil))
+	out.Reset()
+	if _, err := IntersectionReaders(&out, a, b); err != nil || !bytes.Equal(out.Bytes(), Create(0, 1)) {
+		t.Errorf("IntersectionReaders() of first frame differs, %v", err)
+	}
+	out.Reset()
+	if _, err := UnionReaders(&out, a); err != nil || !bytes.Equal(out.Bytes(), lists[1]) {
+		t.Errorf("UnionReaders() following IntersectionReaders() differs, %v", err)
+	}
+}
+
+func Test_UnionReadersError(t *testing.T) {
+	frame := streamTestLists()[0].AppendTo(nil)
+	short := bytes.NewReader(frame[:len(frame)-10])
+	if _, err := UnionReaders(io.Discard, short); err != ErrShortFrame {
+		t.Errorf("Truncated frame error = %v, expected %v", err, ErrShortFrame)
+	}
+	if _, err := UnionReaders(io.Discard, bytes.NewReader(nil)); err != ErrShortFrame {
+		t.Errorf("Empty reader error = %v, expected %v", err, ErrShortFrame)
+	}
+	packed := append([]byte{byte(CodecGroup)}, frame[1:]...)
+	if _, err := UnionReaders(io.Discard, bytes.NewReader(packed)); err != ErrStreamCodec {
+		t.Errorf("Packed frame error = %v, expected %v", err, ErrStreamCodec)
+	}
+}
+
+func Test_UnionIntersectionReadersRepeats(t *testing.T) {
+	// Frames holding 2^40 repeats of a zero take pair and of a zero skip pair,
+	// which must be coalesced without reading each repeat.
+	var skips List
+	e := skips.Encode()
+	e.Add(1, 0)
+	e.addRepeats(1<<40 - 1)
+	e.Add(1, 1)
+	var takes List
+	e = takes.Encode()
+	e.Add(5, 1)
+	e.Add(0, 1)
+	e.addRepeats(1<<40 - 1)
+
+	var out bytes.Buffer
+	if _, err := UnionReaders(&out, bytes.NewReader(skips.AppendTo(nil)), bytes.NewReader(Create(5).AppendTo(nil))); err != nil || !Equal(out.Bytes(), Create(5, 1<<40+1)) {
+		t.Errorf("UnionReaders() = %v, %v", List(out.Bytes()).Expand(), err)
+	}
+	out.Reset()
+	if _, err := IntersectionReaders(&out, bytes.NewReader(takes.AppendTo(nil)), bytes.NewReader(Create(1, 6, 1<<41).AppendTo(nil))); err != nil || !Equal(out.Bytes(), Create(6)) {
+		t.Errorf("IntersectionReaders() = %v, %v", List(out.Bytes()).Expand(), err)
+	}
+}
@@ -0,0 +1,204 @@
+// Package timeline maps times onto skip-take lists, so that schedules such as
+// availability can be held as lists and combined with set algebra. A Scale
+// divides time into buckets of a fixed resolution counted from an epoch, and
+// bucket n is position n of a list. Eg, to find the minutes at which everyone
+// is free:
+//
+//		s := timeline.Scale{Epoch: monday, Resolution: time.Minute}
+//		free := s.Common(aliceFree, bobFree, carolFree)
+//
+// Positions are computed from whole seconds and nanoseconds, not by
+// subtracting times, so are exact for any resolution across the full range of
+// time.Time, rather than only the 292 years of a time.Duration.
+package timeline
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/arthurt/skiptake"
+)
+
+// Range is the half-open range of time [Start, End).
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Scale maps times to list positions. Position n is the bucket of duration
+// Resolution starting at Epoch + n*Resolution. Times before Epoch have no
+// position. Resolution must be positive.
+type Scale struct {
+	Epoch      time.Time
+	Resolution time.Duration
+}
+
+var (
+	// UnixSeconds is a Scale of one position per second since the Unix epoch.
+	UnixSeconds = Scale{Epoch: time.Unix(0, 0).UTC(), Resolution: time.Second}
+
+	// UnixMinutes is a Scale of one position per minute since the Unix epoch.
+	UnixMinutes = Scale{Epoch: time.Unix(0, 0).UTC(), Resolution: time.Minute}
+)
+
+// maxUnix is the latest Unix time, in seconds, which time.Time can hold with
+// a second to spare. time.Time counts seconds from the year 1, not from 1970.
+const maxUnix = math.MaxInt64 - 62135596800 - 1
+
+// locate returns the bucket holding t, and whether t lies part way into it.
+// before is true if t is before the epoch. Buckets past math.MaxUint64 are
+// clamped to it.
+func (s Scale) locate(t time.Time) (n uint64, partial, before bool) {
+	if t.Before(s.Epoch) {
+		return 0, false, true
+	}
+	secs := t.Unix() - s.Epoch.Unix()
+	nanos := int64(t.Nanosecond() - s.Epoch.Nanosecond())
+	if nanos < 0 {
+		secs--
+		nanos += 1e9
+	}
+	// Nanoseconds since the epoch, as 128 bits.
+	hi, lo := bits.Mul64(uint64(secs), 1e9)
+	var carry uint64
+	lo, carry = bits.Add64(lo, uint64(nanos), 0)
+	hi += carry
+	res := uint64(s.Resolution)
+	if hi >= res {
+		return math.MaxUint64, false, false
+	}
+	n, rem := bits.Div64(hi, lo, res)
+	return n, rem != 0, false
+}
+
+// Pos returns the position of the bucket holding t. ok is false if t is
+// before the epoch.
+func (s Scale) Pos(t time.Time) (n uint64, ok bool) {
+	n, _, before := s.locate(t)
+	return n, !before
+}
+
+// Time returns the start of the bucket at position n, in the location of the
+// epoch. Positions beyond the range of time.Time return the latest time.
+func (s Scale) Time(n uint64) time.Time {
+	hi, lo := bits.Mul64(n, uint64(s.Resolution))
+	secs, nanos := uint64(math.MaxInt64), uint64(0)
+	if hi < 1e9 {
+		secs, nanos = bits.Div64(hi, lo, 1e9)
+	}
+	epoch := s.Epoch.Unix()
+	limit := uint64(maxUnix)
+	if epoch > 0 {
+		limit -= uint64(epoch)
+	}
+	if secs > limit {
+		secs, nanos = limit, 0
+	}
+	return time.Unix(epoch+int64(secs), int64(s.Epoch.Nanosecond())+int64(nanos)).In(s.Epoch.Location())
+}
+
+// Range returns the range of time spanned by positions first to last,
+// inclusive.
+func (s Scale) Range(first, last uint64) Range {
+	return Range{Start: s.Time(first), End: s.Time(last).Add(s.Resolution)}
+}
+
+// Within returns the positions of the buckets lying wholly within r. ok is
+// false if there are none.
+func (s Scale) Within(r Range) (first, last uint64, ok bool) {
+	first, partial, before := s.locate(r.Start)
+	if before {
+		first = 0
+	} else if partial {
+		first++
+	}
+	end, _, before := s.locate(r.End)
+	if before || end == 0 || end <= first {
+		return 0, 0, false
+	}
+	return first, end - 1, true
+}
+
+// Covering returns the positions of the buckets which r overlaps. ok is false
+// if there are none.
+func (s Scale) Covering(r Range) (first, last uint64, ok bool) {
+	first, _, before := s.locate(r.Start)
+	if before {
+		first = 0
+	}
+	end, partial, before := s.locate(r.End)
+	if partial {
+		end++
+	}
+	if before || end == 0 || end <= first {
+		return 0, 0, false
+	}
+	return first, end - 1, true
+}
+
+// Mask returns a list of the positions of the buckets lying wholly within any
+// of the ranges, such as the times at which a resource is available. The
+// ranges may be in any order, and may overlap.
+func (s Scale) Mask(ranges ...Range) skiptake.List {
+	return s.mask(s.Within, ranges)
+}
+
+// MaskCovering returns a list of the positions of the buckets which any of the
+// ranges overlap, such as the times at which a resource is busy. The ranges
+// may be in any order, and may overlap.
+func (s Scale) MaskCovering(ranges ...Range) skiptake.List {
+	return s.mask(s.Covering, ranges)
+}
+
+func (s Scale) mask(span func(Range) (first, last uint64, ok bool), ranges []Range) skiptake.List {
+	intervals := make([][2]uint64, 0, len(ranges))
+	for _, r := range ranges {
+		if first, last, ok := span(r); ok {
+			intervals = append(intervals, [2]uint64{first, last})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i][0] < intervals[j][0] })
+
+	l := skiptake.List{}
+	b := skiptake.Build(&l)
+	var next uint64 // Lowest position not yet added
+	for _, iv := range intervals {
+		if iv[1] < next {
+			continue
+		}
+		if iv[0] < next {
+			iv[0] = next
+		}
+		b.Next(iv[0])
+		b.Take(iv[1] - iv[0])
+		if iv[1] == math.MaxUint64 {
+			break
+		}
+		next = iv[1] + 1
+	}
+	return b.Finish()
+}
+
+// Ranges returns the ranges of time spanned by the intervals of the list, in
+// order.
+func (s Scale) Ranges(l skiptake.List) []Range {
+	ranges := []Range{}
+	iter := l.Iterate()
+	for first, last := iter.NextInterval(); first <= last; first, last = iter.NextInterval() {
+		ranges = append(ranges, s.Range(first, last))
+	}
+	return ranges
+}
+
+// Common returns the ranges of time lying within some range of every one of
+// the schedules, at the resolution of the scale. Each schedule is masked with
+// Mask(), so buckets only partly within a schedule are excluded.
+func (s Scale) Common(schedules ...[]Range) []Range {
+	masks := make([]skiptake.List, len(schedules))
+	for i, ranges := range schedules {
+		masks[i] = s.Mask(ranges...)
+	}
+	return s.Ranges(skiptake.Intersection(masks...))
+}
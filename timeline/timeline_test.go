@@ -0,0 +1,160 @@
+package timeline
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/arthurt/skiptake"
+)
+
+var epoch = time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+func at(hour, min, sec int) time.Time {
+	return time.Date(2024, 3, 4, hour, min, sec, 0, time.UTC)
+}
+
+func Test_Pos(t *testing.T) {
+	s := Scale{Epoch: epoch, Resolution: time.Minute}
+	cases := []struct {
+		t        time.Time
+		expected uint64
+		ok       bool
+	}{
+		{epoch, 0, true},
+		{at(0, 0, 59), 0, true},
+		{at(0, 1, 0), 1, true},
+		{at(9, 30, 15), 570, true},
+		{epoch.Add(-time.Nanosecond), 0, false},
+		// Beyond the range of a time.Duration
+		{time.Date(2524, 3, 4, 0, 0, 0, 0, time.UTC), 262974240, true},
+		// The location of t does not matter
+		{time.Date(2024, 3, 4, 10, 0, 0, 0, time.FixedZone("UTC+1", 3600)), 540, true},
+	}
+	for _, c := range cases {
+		if n, ok := s.Pos(c.t); n != c.expected || ok != c.ok {
+			t.Errorf("Pos(%v) = %d, %t, expected %d, %t", c.t, n, ok, c.expected, c.ok)
+		}
+	}
+
+	// Nanosecond borrow from the seconds
+	s = Scale{Epoch: epoch.Add(900 * time.Millisecond), Resolution: time.Second}
+	if n, _ := s.Pos(at(0, 0, 2).Add(100 * time.Millisecond)); n != 1 {
+		t.Errorf("Pos() = %d, expected 1", n)
+	}
+
+	if n, ok := UnixSeconds.Pos(time.Unix(1700000000, 5)); n != 1700000000 || !ok {
+		t.Errorf("UnixSeconds.Pos() = %d, %t", n, ok)
+	}
+	if n, _ := UnixMinutes.Pos(time.Unix(1700000000, 0)); n != 1700000000/60 {
+		t.Errorf("UnixMinutes.Pos() = %d", n)
+	}
+
+	// Positions past math.MaxUint64 clamp
+	s = Scale{Epoch: epoch, Resolution: time.Nanosecond}
+	if n, _ := s.Pos(time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)); n != math.MaxUint64 {
+		t.Errorf("Pos() = %d, expected math.MaxUint64", n)
+	}
+}
+
+func Test_Time(t *testing.T) {
+	s := Scale{Epoch: epoch, Resolution: time.Minute}
+	for _, n := range []uint64{0, 1, 570, 262974240} {
+		tm := s.Time(n)
+		if p, ok := s.Pos(tm); p != n || !ok {
+			t.Errorf("Pos(Time(%d)) = %d", n, p)
+		}
+		if tm.Location() != time.UTC {
+			t.Errorf("Time(%d) in %v", n, tm.Location())
+		}
+	}
+	if tm := s.Time(570); !tm.Equal(at(9, 30, 0)) {
+		t.Errorf("Time(570) = %v", tm)
+	}
+	// Clamped rather than wrapping
+	if tm := s.Time(math.MaxUint64); tm.Before(epoch) {
+		t.Errorf("Time(math.MaxUint64) = %v", tm)
+	}
+}
+
+func Test_WithinCovering(t *testing.T) {
+	s := Scale{Epoch: epoch, Resolution: time.Hour}
+	cases := []struct {
+		r                 Range
+		within, covering  [2]uint64
+		withinOk, coverOk bool
+	}{
+		{Range{at(9, 0, 0), at(17, 0, 0)}, [2]uint64{9, 16}, [2]uint64{9, 16}, true, true},
+		{Range{at(9, 30, 0), at(17, 30, 0)}, [2]uint64{10, 16}, [2]uint64{9, 17}, true, true},
+		{Range{at(9, 10, 0), at(9, 50, 0)}, [2]uint64{}, [2]uint64{9, 9}, false, true},
+		{Range{epoch.Add(-time.Hour), at(2, 0, 0)}, [2]uint64{0, 1}, [2]uint64{0, 1}, true, true},
+		{Range{epoch.Add(-2 * time.Hour), epoch}, [2]uint64{}, [2]uint64{}, false, false},
+		{Range{at(5, 0, 0), at(5, 0, 0)}, [2]uint64{}, [2]uint64{}, false, false},
+		{Range{at(6, 0, 0), at(5, 0, 0)}, [2]uint64{}, [2]uint64{}, false, false},
+	}
+	for _, c := range cases {
+		if first, last, ok := s.Within(c.r); ok != c.withinOk || (ok && [2]uint64{first, last} != c.within) {
+			t.Errorf("Within(%v) = %d, %d, %t, expected %v, %t", c.r, first, last, ok, c.within, c.withinOk)
+		}
+		if first, last, ok := s.Covering(c.r); ok != c.coverOk || (ok && [2]uint64{first, last} != c.covering) {
+			t.Errorf("Covering(%v) = %d, %d, %t, expected %v, %t", c.r, first, last, ok, c.covering, c.coverOk)
+		}
+	}
+}
+
+func Test_Mask(t *testing.T) {
+	s := Scale{Epoch: epoch, Resolution: time.Hour}
+	// Unordered and overlapping
+	mask := s.Mask(
+		Range{at(13, 0, 0), at(15, 0, 0)},
+		Range{at(9, 0, 0), at(12, 0, 0)},
+		Range{at(10, 0, 0), at(11, 0, 0)},
+		Range{at(11, 30, 0), at(14, 0, 0)},
+		Range{at(20, 15, 0), at(20, 45, 0)},
+	)
+	expected := skiptake.FromRaw(9, 6)
+	if mask.String() != expected.String() {
+		t.Errorf("Mask() = %v, expected %v", mask, expected)
+	}
+
+	covering := s.MaskCovering(Range{at(1, 30, 0), at(2, 30, 0)}, Range{at(20, 15, 0), at(20, 45, 0)})
+	expected = skiptake.FromRaw(1, 2, 17, 1)
+	if covering.String() != expected.String() {
+		t.Errorf("MaskCovering() = %v, expected %v", covering, expected)
+	}
+
+	if !s.Mask().IsEmpty() {
+		t.Error("Mask() of no ranges not empty")
+	}
+}
+
+func Test_Ranges(t *testing.T) {
+	s := Scale{Epoch: epoch, Resolution: time.Hour}
+	ranges := s.Ranges(skiptake.FromRaw(9, 3, 2, 1))
+	expected := []Range{{at(9, 0, 0), at(12, 0, 0)}, {at(14, 0, 0), at(15, 0, 0)}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Ranges() = %v, expected %v", ranges, expected)
+	}
+	for i := range ranges {
+		if !ranges[i].Start.Equal(expected[i].Start) || !ranges[i].End.Equal(expected[i].End) {
+			t.Errorf("Ranges()[%d] = %v, expected %v", i, ranges[i], expected[i])
+		}
+	}
+}
+
+func Test_Common(t *testing.T) {
+	s := Scale{Epoch: epoch, Resolution: 15 * time.Minute}
+	alice := []Range{{at(9, 0, 0), at(12, 0, 0)}, {at(13, 0, 0), at(17, 0, 0)}}
+	bob := []Range{{at(10, 30, 0), at(14, 10, 0)}}
+	carol := []Range{{at(8, 0, 0), at(11, 0, 0)}, {at(13, 30, 0), at(18, 0, 0)}}
+	common := s.Common(alice, bob, carol)
+	expected := []Range{{at(10, 30, 0), at(11, 0, 0)}, {at(13, 30, 0), at(14, 0, 0)}}
+	if len(common) != len(expected) {
+		t.Fatalf("Common() = %v, expected %v", common, expected)
+	}
+	for i := range common {
+		if !common[i].Start.Equal(expected[i].Start) || !common[i].End.Equal(expected[i].End) {
+			t.Errorf("Common()[%d] = %v, expected %v", i, common[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package skiptake
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var (
+	// ErrTruncated is returned by Validate when the list ends within a
+	// varint.
+	ErrTruncated = errors.New("skiptake: truncated varint")
+
+	// ErrOverflow is returned by Validate when a varint encodes a value
+	// larger than 64 bits.
+	ErrOverflow = errors.New("skiptake: varint overflows 64 bits")
+
+	// ErrRange is returned by Validate when the expanded sequence extends
+	// past math.MaxUint64.
+	ErrRange = errors.New("skiptake: sequence exceeds uint64 range")
+)
+
+// Validate checks that the list is well formed, that is it consists of
+// complete varints holding 64-bit values, and its expanded sequence lies
+// within [0, math.MaxUint64]. Lists built by this package are always valid.
+// Validate should be used on lists read from untrusted sources.
+//
+// Invalid lists are still safe to decode, but their contents are unspecified.
+func (l List) Validate() error {
+	return l.validate(nil)
+}
+
+// validate implements Validate, reporting to p if it is not nil.
+func (l List) validate(p *progress) error {
+	for i := 0; i < len(l); {
+		if err := checkVarint2(l, &i); err != nil {
+			return err
+		}
+	}
+
+	// The count of integers preceeding the current position, carry set if
+	// this has reached 2^64.
+	var n, carry uint64
+	dec := l.Decode()
+	d := &dec
+	if p != nil {
+		d = p.decode(l)
+	}
+	for !d.EOS() {
+		skip, take, count := d.NextRun()
+		if p != nil {
+			p.add(count)
+		}
+		hi, step := bits.Mul64(skip+take, count)
+		if skip+take < skip {
+			hi += count
+		}
+		if carry != 0 && (skip|take) != 0 {
+			return ErrRange
+		}
+		var c uint64
+		n, c = bits.Add64(n, step, 0)
+		carry += hi + c
+		if carry > 1 || (carry == 1 && n != 0) {
+			return ErrRange
+		}
+	}
+	if p != nil {
+		p.report()
+	}
+	return nil
+}
+
+// checkVarint2 checks the varint at *i, and advances past it.
+func checkVarint2(b []byte, i *int) error {
+	// The first byte holds 7 - split bits of value, each following byte 7.
+	s := 7 - defaultSplit
+	for k := 0; ; k++ {
+		if *i >= len(b) {
+			return ErrTruncated
+		}
+		x := b[*i]
+		*i++
+		if k > 0 {
+			if s+7 > 64 && x&0x7f >= 1<<(64-s) {
+				return ErrOverflow
+			}
+			s += 7
+		}
+		if x < 0x80 {
+			return nil
+		}
+		if s >= 64 {
+			return ErrOverflow
+		}
+	}
+}
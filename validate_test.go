@@ -0,0 +1,43 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_Validate(t *testing.T) {
+	valid := map[string]List{
+		"Empty":     List{},
+		"Create":    Create(0, 1, 2, 10, 20, 21),
+		"MaxValue":  Create(5, 0xfffffffffffffffe, 0xffffffffffffffff),
+		"OnlyMax":   Create(0xffffffffffffffff),
+		"Full":      FromRaw(0, 0xffffffffffffffff, 0, 1),
+		"LargeTake": FromRaw(0x10, 0xffffffff, 0, 1),
+	}
+	for name, l := range valid {
+		t.Run(name, func(t *testing.T) {
+			if err := l.Validate(); err != nil {
+				t.Errorf("Validate(%v) = %v", []byte(l), err)
+			}
+		})
+	}
+
+	invalid := map[string]struct {
+		l   List
+		err error
+	}{
+		"Truncated":     {List{0x02, 0x81}, ErrTruncated},
+		"TruncatedLong": {List{0xff, 0xff, 0xff}, ErrTruncated},
+		"Overflow":      {List{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x04}, ErrOverflow},
+		"TooLong":       {List{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x81, 0x00}, ErrOverflow},
+		"PastMax":       {FromRaw(0xffffffffffffffff, 1, 0, 1), ErrRange},
+		"PastMaxSum":    {FromRaw(0x8000000000000000, 0x8000000000000000, 1, 1), ErrRange},
+		"PastMaxRepeat": {FromRaw(0x4000000000000000, 1, 0x4000000000000000, 1, 0x4000000000000000, 1, 0x4000000000000000, 1), ErrRange},
+	}
+	for name, c := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if err := c.l.Validate(); err != c.err {
+				t.Errorf("Validate(%v) = %v, expected %v", []byte(c.l), err, c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package skiptake
+
+// View is a read-only list over memory owned by the caller, such as a memory
+// mapped file. A View never copies or modifies the underlying bytes, and none
+// of its methods return a List over them. The Decoders it returns do hold the
+// bytes, as their Elements field, which must be treated as read-only.
+//
+// Views can be used as inputs to set operations through their iterators, Eg:
+//
+//		a, b := viewA.Iterate(), viewB.Iterate()
+//		result := skiptake.IntersectionOf(&a, &b)
+//
+type View struct {
+	l List
+}
+
+// OpenView returns a View over b, after checking that b holds a valid list.
+// The contents of b must not change while the View is in use.
+func OpenView(b []byte) (View, error) {
+	l := List(b)
+	if err := l.Validate(); err != nil {
+		return View{}, err
+	}
+	return View{l: l}, nil
+}
+
+// Size returns the size of the encoded list in bytes.
+func (v View) Size() int {
+	return len(v.l)
+}
+
+// Len returns how many values are in the expanded sequence.
+func (v View) Len() uint64 {
+	return v.l.Len()
+}
+
+// Iterate returns a new skiptake.Iterator for the list. The Elements of its
+// Decoder are the bytes of the View.
+func (v View) Iterate() Iterator {
+	return v.l.Iterate()
+}
+
+// Decode returns a new skiptake.Decoder for the list. Its Elements are the
+// bytes of the View.
+func (v View) Decode() Decoder {
+	return v.l.Decode()
+}
+
+// Expand expands the sequence as a slice of uint64 values. See List.Expand().
+func (v View) Expand() []uint64 {
+	return v.l.Expand()
+}
+
+// Clone returns a copy of the list, which is safe to modify.
+func (v View) Clone() List {
+	return v.l.Clone()
+}
+
+// Format returns a human-friendly representation of the list. See
+// List.Format().
+func (v View) Format(maxLen int) string {
+	return v.l.Format(maxLen)
+}
+
+// String implements the fmt.Stringer interface.
+func (v View) String() string {
+	return v.l.String()
+}
@@ -0,0 +1,40 @@
+package skiptake
+
+import (
+	"testing"
+)
+
+func Test_View(t *testing.T) {
+	a := Create(1, 2, 3, 10, 11, 12, 50)
+	b := Create(2, 3, 4, 11, 50, 51)
+
+	va, err := OpenView(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vb, err := OpenView(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectUint64(t, va.Len(), 7)
+	if !equalUint64(va.Expand(), a.Expand()) {
+		t.Errorf("%v != %v", va.Expand(), a.Expand())
+	}
+
+	ia, ib := va.Iterate(), vb.Iterate()
+	intersection := IntersectionOf(&ia, &ib)
+	if expected := Intersection(a, b); !Equal(intersection, expected) {
+		t.Errorf("%v != %v", intersection, expected)
+	}
+
+	ia, ib = va.Iterate(), vb.Iterate()
+	union := UnionOf(&ia, &ib)
+	if expected := Union(a, b); !Equal(union, expected) {
+		t.Errorf("%v != %v", union, expected)
+	}
+
+	if _, err := OpenView([]byte{0x81}); err != ErrTruncated {
+		t.Errorf("OpenView() of invalid list: %v", err)
+	}
+}